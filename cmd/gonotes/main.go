@@ -1,18 +1,62 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"gonotes/internal/attachments/attachmentshandler"
+	"gonotes/internal/attachments/blobstore"
+	"gonotes/internal/attachments/collector"
+	"gonotes/internal/attachments/storage/attachmentssqlite"
 	"gonotes/internal/auth/authhandler"
 	"gonotes/internal/auth/storage/authsqlite"
+	"gonotes/internal/collab"
+	"gonotes/internal/collab/storage/collabsqlite"
 	"gonotes/internal/config"
+	"gonotes/internal/events"
+	"gonotes/internal/export/exporthandler"
+	"gonotes/internal/feed"
+	"gonotes/internal/feed/feedhandler"
+	"gonotes/internal/feed/storage/feedsqlite"
+	"gonotes/internal/importer"
+	"gonotes/internal/importer/importhandler"
 	"gonotes/internal/lib/logger"
+	"gonotes/internal/links/linkshandler"
+	"gonotes/internal/links/storage/linkssqlite"
+	"gonotes/internal/live"
+	"gonotes/internal/live/livehandler"
 	"gonotes/internal/middleware"
+	"gonotes/internal/notebooks/notebookshandler"
+	"gonotes/internal/notebooks/storage/notebookssqlite"
 	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/purger"
 	"gonotes/internal/notes/storage/notessqlite"
+	"gonotes/internal/publiclinks/publiclinkshandler"
+	"gonotes/internal/publiclinks/storage/publiclinkssqlite"
+	"gonotes/internal/publiclinks/token"
+	"gonotes/internal/reminders/notifier"
+	"gonotes/internal/reminders/remindershandler"
+	"gonotes/internal/reminders/scheduler"
+	"gonotes/internal/reminders/storage/reminderssqlite"
+	"gonotes/internal/revisions/revisionshandler"
+	"gonotes/internal/revisions/storage/revisionssqlite"
+	"gonotes/internal/shares/shareshandler"
+	"gonotes/internal/shares/storage/sharessqlite"
 	"gonotes/internal/storage"
+	"gonotes/internal/tags/storage/tagssqlite"
+	"gonotes/internal/tags/tagshandler"
+	"gonotes/internal/templates/storage/templatessqlite"
+	"gonotes/internal/templates/templateshandler"
+	"gonotes/internal/webhooks/dispatcher"
+	"gonotes/internal/webhooks/sender"
+	"gonotes/internal/webhooks/storage/webhookssqlite"
+	"gonotes/internal/webhooks/webhookshandler"
+	"gonotes/internal/webhooks/worker"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/cors"
@@ -32,17 +76,82 @@ func main() {
 
 	notesRepository := notessqlite.NewNoteRepository(db)
 	userRepository := authsqlite.NewUserRepository(db)
+	tagRepository := tagssqlite.NewTagRepository(db)
+	notebookRepository := notebookssqlite.NewNotebookRepository(db)
+	revisionRepository := revisionssqlite.NewRevisionRepository(db)
+	linkRepository := linkssqlite.NewLinkRepository(db)
+	attachmentRepository := attachmentssqlite.NewAttachmentRepository(db)
+	shareRepository := sharessqlite.NewShareRepository(db)
+	publicLinkRepository := publiclinkssqlite.NewPublicLinkRepository(db)
+	reminderRepository := reminderssqlite.NewReminderRepository(db)
+	webhookRepository := webhookssqlite.NewWebhookRepository(db)
+	templateRepository := templatessqlite.NewTemplateRepository(db)
+	eventLog := feedsqlite.NewEventLog(db)
 
-	noteshandler := noteshandler.NewHandler(log, notesRepository)
+	indexed, err := notesRepository.IndexLinks()
+	if err != nil {
+		log.Error("failed to index note links", slog.Any("err", err))
+		os.Exit(1)
+	}
+	if indexed > 0 {
+		log.Info("indexed note links", slog.Int("notes", indexed))
+	}
+
+	blobs, err := blobstore.New(cfg.Attachments.Dir)
+	if err != nil {
+		log.Error("failed to init attachment storage", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	bus := events.NewBus()
+	webhookWorker := worker.New(log, webhookRepository, sender.New(cfg.Webhooks.Timeout), cfg.Webhooks.PollInterval, cfg.Webhooks.Lease, cfg.Webhooks.Retention)
+	bus.Subscribe(dispatcher.New(log, webhookRepository, webhookWorker.Wake).Handle)
+	hub := feed.NewHub()
+	changeFeed := feed.New(log, eventLog, hub, cfg.Events.Retention)
+	bus.Subscribe(changeFeed.Handle)
+	liveHub := live.NewHub()
+	sessions := collab.New(log, notesRepository, collabsqlite.NewStore(db), liveHub, bus, cfg.Collab.CompactInterval)
+
+	noteshandler := noteshandler.NewHandler(log, notesRepository, bus)
 	authhandler := authhandler.NewHandler(log, userRepository, cfg.SecretKey)
+	tagshandler := tagshandler.NewHandler(log, tagRepository, notesRepository, bus)
+	notebookshandler := notebookshandler.NewHandler(log, notebookRepository, notesRepository, bus)
+	revisionshandler := revisionshandler.NewHandler(log, revisionRepository, notesRepository, bus)
+	linkshandler := linkshandler.NewHandler(log, linkRepository)
+	attachmentshandler := attachmentshandler.NewHandler(log, attachmentRepository, blobs, cfg.Attachments.MaxSize)
+	shareshandler := shareshandler.NewHandler(log, shareRepository)
+	exporthandler := exporthandler.NewHandler(log, notesRepository, notebookRepository)
+	importhandler := importhandler.NewHandler(log, importer.NewRunner(notesRepository, notebookRepository, bus), importer.NewJobs(cfg.Import.JobRetention), cfg.Import.MaxSize)
+	publiclinkshandler := publiclinkshandler.NewHandler(log, publicLinkRepository, token.NewSigner(cfg.SecretKey))
+	remindershandler := remindershandler.NewHandler(log, reminderRepository)
+	webhookshandler := webhookshandler.NewHandler(log, webhookRepository)
+	templateshandler := templateshandler.NewHandler(log, templateRepository, notesRepository, userRepository, bus)
+	feedhandler := feedhandler.NewHandler(log, eventLog, hub, cfg.Events.Heartbeat)
+	livehandler := livehandler.NewHandler(log, notesRepository, liveHub, hub, sessions, cfg.WebSocket.PingInterval)
 
 	authMW := middleware.NewAuthMiddleware(cfg.SecretKey)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	blobCollector := collector.New(blobs, attachmentRepository, collector.DefaultGrace)
+	go purger.New(log, notesRepository, blobCollector, cfg.Trash.Retention, cfg.Trash.PurgeInterval).Run(ctx)
+
+	reminderNotifier, err := newNotifier(cfg.Reminders, log)
+	if err != nil {
+		log.Error("failed to init reminder notifier", slog.Any("err", err))
+		os.Exit(1)
+	}
+	go scheduler.New(log, reminderRepository, reminderNotifier, cfg.Reminders.PollInterval, cfg.Reminders.Lease).Run(ctx)
+	go webhookWorker.Run(ctx)
+	go changeFeed.Run(ctx)
+	go sessions.Run(ctx)
+
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"http://127.0.0.1:*", "http://localhost:*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", "Last-Event-ID", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"Accept-Ranges", "Content-Disposition", "Content-Range", "ETag", "Link", "Location"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -50,14 +159,131 @@ func main() {
 	router.Post("/auth/register", authhandler.Create)
 	router.Post("/auth/login", authhandler.Login)
 
+	router.Get("/p/{token}", publiclinkshandler.View)
+	router.Post("/p/{token}", publiclinkshandler.View)
+
 	router.Route("/notes", func(r chi.Router) {
 		r.Use(authMW.Auth)
-		r.Post("/", noteshandler.Create)
+		r.With(templateshandler.FromTemplate).Post("/", noteshandler.Create)
+		r.Post("/batch", noteshandler.Batch)
+		r.Get("/search", noteshandler.Search)
+		r.Get("/shared-with-me", shareshandler.SharedWithMe)
 		r.Get("/{id}", noteshandler.Get)
+		r.Get("/{id}/html", noteshandler.HTML)
+		r.Put("/{id}", noteshandler.Update)
+		r.Patch("/{id}", noteshandler.Patch)
+		r.Post("/{id}/move", noteshandler.Move)
+		r.Put("/{id}/pin", noteshandler.Pin)
+		r.Delete("/{id}/pin", noteshandler.Unpin)
+		r.Put("/{id}/archive", noteshandler.Archive)
+		r.Delete("/{id}/archive", noteshandler.Unarchive)
+		r.Put("/{id}/favourite", noteshandler.Favourite)
+		r.Delete("/{id}/favourite", noteshandler.Unfavourite)
+		r.Get("/{id}/revisions", revisionshandler.GetAll)
+		r.Get("/{id}/revisions/diff", revisionshandler.Diff)
+		r.Get("/{id}/revisions/{rev}", revisionshandler.Get)
+		r.Post("/{id}/revisions/{rev}/revert", revisionshandler.Revert)
+		r.Get("/{id}/links", linkshandler.Links)
+		r.Get("/{id}/backlinks", linkshandler.Backlinks)
+		r.Get("/{id}/shares", shareshandler.GetAll)
+		r.Post("/{id}/shares", shareshandler.Grant)
+		r.Delete("/{id}/shares", shareshandler.Revoke)
+		r.Get("/{id}/public-links", publiclinkshandler.GetAll)
+		r.Post("/{id}/public-links", publiclinkshandler.Create)
+		r.Delete("/{id}/public-links/{linkID}", publiclinkshandler.Delete)
+		r.Post("/{id}/attachments", attachmentshandler.Upload)
+		r.Get("/{id}/attachments", attachmentshandler.GetAll)
+		r.Get("/{id}/attachments/{attachmentID}", attachmentshandler.Download)
+		r.Delete("/{id}/attachments/{attachmentID}", attachmentshandler.Delete)
+		r.Get("/{id}/reminders", remindershandler.GetAll)
+		r.Post("/{id}/reminders", remindershandler.Create)
 		r.Delete("/{id}", noteshandler.Delete)
 		r.Get("/", noteshandler.GetAll)
 	})
 
+	router.Route("/trash", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", noteshandler.GetTrash)
+		r.Post("/{id}/restore", noteshandler.Restore)
+		r.Delete("/{id}", noteshandler.Purge)
+	})
+
+	router.Route("/sync", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", noteshandler.Pull)
+		r.Post("/", noteshandler.Push)
+	})
+
+	router.Route("/notebooks", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Post("/", notebookshandler.Create)
+		r.Get("/", notebookshandler.GetAll)
+		r.Get("/{id}", notebookshandler.Get)
+		r.Patch("/{id}", notebookshandler.Update)
+		r.Get("/{id}/tree", notebookshandler.Tree)
+		r.Delete("/{id}", notebookshandler.Delete)
+	})
+
+	router.Route("/export", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", exporthandler.Export)
+	})
+
+	router.Route("/import", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Post("/", importhandler.Import)
+		r.Get("/", importhandler.GetAll)
+		r.Get("/{id}", importhandler.Get)
+	})
+
+	router.Route("/reminders", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", remindershandler.Upcoming)
+		r.Post("/{id}/snooze", remindershandler.Snooze)
+		r.Post("/{id}/dismiss", remindershandler.Dismiss)
+	})
+
+	router.Route("/webhooks", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Post("/", webhookshandler.Create)
+		r.Get("/", webhookshandler.GetAll)
+		r.Get("/{id}", webhookshandler.Get)
+		r.Get("/{id}/deliveries", webhookshandler.Deliveries)
+		r.Delete("/{id}", webhookshandler.Delete)
+	})
+
+	router.Route("/events", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", feedhandler.Stream)
+	})
+
+	router.Route("/ws", func(r chi.Router) {
+		r.Use(middleware.TokenFromQuery, authMW.Auth)
+		r.Get("/", livehandler.Serve)
+	})
+
+	router.Route("/graph", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", linkshandler.Graph)
+	})
+
+	router.Route("/templates", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Post("/", templateshandler.Create)
+		r.Get("/", templateshandler.GetAll)
+		r.Get("/{id}", templateshandler.Get)
+		r.Put("/{id}", templateshandler.Update)
+		r.Delete("/{id}", templateshandler.Delete)
+	})
+
+	router.Route("/tags", func(r chi.Router) {
+		r.Use(authMW.Auth)
+		r.Get("/", tagshandler.GetAll)
+		r.Patch("/{id}", tagshandler.Rename)
+		r.Post("/{id}/merge", tagshandler.Merge)
+		r.Delete("/{id}", tagshandler.Delete)
+	})
+
 	srv := &http.Server{
 		Addr:         cfg.HTTPServer.Address,
 		Handler:      router,
@@ -65,12 +291,45 @@ func main() {
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
 	}
+	srv.RegisterOnShutdown(hub.Close)
+	srv.RegisterOnShutdown(liveHub.Close)
 
 	log.Info("starting server", "port", cfg.HTTPServer.Address)
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Error("server error", slog.Any("err", err))
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("server error", slog.Any("err", err))
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTPServer.Timeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down server", slog.Any("err", err))
 	}
+	sessions.Compact()
 
 	log.Info("server stopped")
 }
+
+// newNotifier builds the reminder delivery channel named in the config.
+func newNotifier(cfg config.Reminders, log *slog.Logger) (notifier.Notifier, error) {
+	switch cfg.Notifier {
+	case "", "log":
+		return notifier.NewLog(log), nil
+	case "webhook":
+		if cfg.Webhook.URL == "" {
+			return nil, fmt.Errorf("reminders.webhook.url is required")
+		}
+		return notifier.NewWebhook(cfg.Webhook.URL, cfg.Webhook.Timeout), nil
+	case "email":
+		if cfg.SMTP.Host == "" {
+			return nil, fmt.Errorf("reminders.smtp.host is required")
+		}
+		return notifier.NewEmail(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+	}
+	return nil, fmt.Errorf("unknown reminder notifier %q", cfg.Notifier)
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+// ETag formats a resource version as a strong entity tag.
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// IfMatch returns the version required by the If-Match header, or 0 when the
+// header is absent or "*". Weak tags never match (RFC 9110, 13.1.1).
+func IfMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return 0, fmt.Errorf("only a single entity tag is supported in If-Match")
+	}
+	if strings.HasPrefix(header, "W/") {
+		return 0, ErrPreconditionFailed
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, `"`))
+	if err != nil || version <= 0 {
+		return 0, ErrPreconditionFailed
+	}
+
+	return version, nil
+}
@@ -0,0 +1,5 @@
+package api
+
+// MultipartOverhead leaves room for the boundaries and part headers around an
+// uploaded file when limiting the request body.
+const MultipartOverhead = 1 << 20
@@ -0,0 +1,338 @@
+package attachmentshandler
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/attachments/dto"
+	"gonotes/internal/attachments/entity"
+	"gonotes/internal/attachments/storage"
+	"gonotes/internal/middleware"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Blobs interface {
+	Put(r io.Reader) (string, int64, error)
+	Open(sum string) (*os.File, error)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.AttachmentRepository
+	blobs   Blobs
+	maxSize int64
+}
+
+func NewHandler(log *slog.Logger, storage storage.AttachmentRepository, blobs Blobs, maxSize int64) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		blobs:   blobs,
+		maxSize: maxSize,
+	}
+}
+
+// Upload stores the multipart field named file as an attachment of the note.
+// The body is streamed to the blob store rather than buffered in memory.
+func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
+	const op = "attachments.handler.upload"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	// Large files take longer to upload than the server's read timeout, and
+	// the response is only written once the upload is done.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear read deadline", slog.Any("err", err))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear write deadline", slog.Any("err", err))
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+api.MultipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		log.Error("invalid multipart request", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("expected a multipart/form-data body")))
+		return
+	}
+
+	var part *multipart.Part
+	for {
+		p, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			h.renderUploadError(w, r, log, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part = p
+			break
+		}
+	}
+	if part == nil {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("missing file field")))
+		return
+	}
+
+	name := filepath.Base(strings.ReplaceAll(part.FileName(), `\`, "/"))
+	if name == "" || name == "." || name == "/" {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("missing file name")))
+		return
+	}
+
+	body := bufio.NewReaderSize(part, 512)
+	mimeType := detectType(part.Header.Get("Content-Type"), body)
+
+	sum, size, err := h.blobs.Put(io.LimitReader(body, h.maxSize+1))
+	if err != nil {
+		h.renderUploadError(w, r, log, err)
+		return
+	}
+	if size > h.maxSize {
+		h.renderUploadError(w, r, log, &http.MaxBytesError{Limit: h.maxSize})
+		return
+	}
+
+	// A blob left behind by a failed insert is unreferenced and gets removed
+	// by the collector once its grace period is over.
+	attachment, err := h.storage.Create(&entity.Attachment{
+		NoteID:   noteID,
+		UserID:   userID,
+		Name:     name,
+		MIMEType: mimeType,
+		Size:     size,
+		SHA256:   sum,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		if errors.Is(err, storage.ErrForbidden) {
+			log.Error("note is read-only for user", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusForbidden, err))
+			return
+		}
+		log.Error("failed to create attachment", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewAttachmentResponse(attachment))
+
+	log.Info("attachment uploaded", slog.Int("id", attachment.ID), slog.Int("note_id", noteID), slog.Int64("size", size), slog.Int("user_id", userID))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "attachments.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	list, err := h.storage.List(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list attachments", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewAttachmentListResponse(list))
+
+	log.Info("attachments retrieved", slog.Int("note_id", noteID), slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+// Download streams the attachment's content. Range and conditional requests
+// are handled by http.ServeContent.
+func (h *Handler) Download(w http.ResponseWriter, r *http.Request) {
+	const op = "attachments.handler.download"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, id, err := parseIDs(r)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	attachment, err := h.storage.Get(id, noteID, userID)
+	if err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+
+	f, err := h.blobs.Open(attachment.SHA256)
+	if err != nil {
+		log.Error("failed to open blob", slog.Any("err", err), slog.String("sha256", attachment.SHA256))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, fmt.Errorf("failed to read attachment")))
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", attachment.MIMEType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition(attachment.MIMEType), map[string]string{"filename": attachment.Name}))
+	w.Header().Set("ETag", `"`+attachment.SHA256+`"`)
+	// Uploaded files are untrusted: never let the browser sniff them into
+	// something executable or run them with the API's origin.
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+
+	// Large files take longer to download than the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear write deadline", slog.Any("err", err))
+	}
+
+	http.ServeContent(w, r, attachment.Name, attachment.CreatedAt, f)
+
+	log.Info("attachment downloaded", slog.Int("id", id), slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+// Delete removes the attachment. Its blob stays on disk until the collector
+// finds it unreferenced, since another attachment may share it.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "attachments.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, id, err := parseIDs(r)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	if _, err := h.storage.Delete(id, noteID, userID); err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("attachment deleted", slog.Int("id", id), slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) renderGetError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrAttachmentNotFound) {
+		log.Error("attachment not found", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		return
+	}
+	if errors.Is(err, storage.ErrForbidden) {
+		log.Error("note is read-only for user", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusForbidden, err))
+		return
+	}
+	log.Error("failed to get attachment", slog.Any("err", err))
+	render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+}
+
+func (h *Handler) renderUploadError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		log.Error("attachment too large", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusRequestEntityTooLarge, fmt.Errorf("attachment exceeds %d bytes", h.maxSize)))
+		return
+	}
+	log.Error("failed to store attachment", slog.Any("err", err))
+	render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+}
+
+// detectType prefers the type declared in the part header and falls back to
+// sniffing the first bytes of the content.
+func detectType(declared string, body *bufio.Reader) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return declared
+	}
+	head, _ := body.Peek(512)
+	return http.DetectContentType(head)
+}
+
+// disposition lets browsers display types that cannot carry script inline and
+// downloads everything else.
+func disposition(mimeType string) string {
+	mediaType, _, _ := mime.ParseMediaType(mimeType)
+	switch {
+	case mediaType == "image/svg+xml":
+		return "attachment"
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/pdf",
+		mediaType == "text/plain":
+		return "inline"
+	}
+	return "attachment"
+}
+
+func parseIDs(r *http.Request) (int, int, error) {
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid id format")
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid attachment id format")
+	}
+	return noteID, id, nil
+}
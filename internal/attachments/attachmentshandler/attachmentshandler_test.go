@@ -0,0 +1,336 @@
+package attachmentshandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gonotes/internal/attachments/attachmentshandler"
+	"gonotes/internal/attachments/blobstore"
+	"gonotes/internal/attachments/dto"
+	"gonotes/internal/attachments/entity"
+	"gonotes/internal/attachments/storage"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockAttachmentsRepo holds the attachments of note 1. Note 3 is shared with
+// the user read-only and every other note is missing.
+type mockAttachmentsRepo struct {
+	attachments map[int]entity.Attachment
+}
+
+func (m *mockAttachmentsRepo) Create(a *entity.Attachment) (*entity.Attachment, error) {
+	if a.NoteID == 3 {
+		return nil, storage.ErrForbidden
+	}
+	if a.NoteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	created := *a
+	created.ID = len(m.attachments) + 1
+	created.CreatedAt = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.attachments[created.ID] = created
+	return &created, nil
+}
+func (m *mockAttachmentsRepo) List(noteID, userID int) ([]entity.Attachment, error) {
+	if noteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	list := []entity.Attachment{}
+	for i := 1; i <= len(m.attachments); i++ {
+		if a, ok := m.attachments[i]; ok {
+			list = append(list, a)
+		}
+	}
+	return list, nil
+}
+func (m *mockAttachmentsRepo) Get(id, noteID, userID int) (*entity.Attachment, error) {
+	if noteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	a, ok := m.attachments[id]
+	if !ok {
+		return nil, storage.ErrAttachmentNotFound
+	}
+	return &a, nil
+}
+func (m *mockAttachmentsRepo) Delete(id, noteID, userID int) (*entity.Attachment, error) {
+	if noteID == 3 {
+		return nil, storage.ErrForbidden
+	}
+	a, err := m.Get(id, noteID, userID)
+	if err != nil {
+		return nil, err
+	}
+	delete(m.attachments, id)
+	return a, nil
+}
+func (m *mockAttachmentsRepo) Referenced(sum string) (bool, error) {
+	for _, a := range m.attachments {
+		if a.SHA256 == sum {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newHandler(t *testing.T, maxSize int64) (*attachmentshandler.Handler, *mockAttachmentsRepo, *blobstore.Store) {
+	blobs, err := blobstore.New(t.TempDir())
+	require.NoError(t, err)
+	repo := &mockAttachmentsRepo{attachments: map[int]entity.Attachment{}}
+	return attachmentshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, blobs, maxSize), repo, blobs
+}
+
+func newRequest(method, target string, body io.Reader, noteID, attachmentID string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", noteID)
+	rctx.URLParams.Add("attachmentID", attachmentID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+// multipartBody builds a form with a single file field. An empty contentType
+// leaves the part header out so the handler has to sniff the content.
+func multipartBody(t *testing.T, field, name, contentType, content string) (*bytes.Buffer, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+name+`"`)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	part, err := mw.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	return &buf, mw.FormDataContentType()
+}
+
+func Test_Upload(t *testing.T) {
+	cases := []struct {
+		name         string
+		noteID       string
+		field        string
+		fileName     string
+		contentType  string
+		content      string
+		withUser     bool
+		expectedCode int
+		expectedMIME string
+		expectedName string
+	}{
+		{
+			name:         "declared type kept",
+			noteID:       "1",
+			field:        "file",
+			fileName:     "notes.md",
+			contentType:  "text/markdown",
+			content:      "# Hello",
+			withUser:     true,
+			expectedCode: http.StatusCreated,
+			expectedMIME: "text/markdown",
+			expectedName: "notes.md",
+		},
+		{
+			name:         "type sniffed when missing",
+			noteID:       "1",
+			field:        "file",
+			fileName:     "image",
+			content:      "\x89PNG\r\n\x1a\n0000",
+			withUser:     true,
+			expectedCode: http.StatusCreated,
+			expectedMIME: "image/png",
+			expectedName: "image",
+		},
+		{
+			name:         "path stripped from name",
+			noteID:       "1",
+			field:        "file",
+			fileName:     `C:\Users\me\report.txt`,
+			contentType:  "text/plain",
+			content:      "report",
+			withUser:     true,
+			expectedCode: http.StatusCreated,
+			expectedMIME: "text/plain",
+			expectedName: "report.txt",
+		},
+		{
+			name:         "too large",
+			noteID:       "1",
+			field:        "file",
+			fileName:     "big.bin",
+			content:      strings.Repeat("x", 33),
+			withUser:     true,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:         "missing file field",
+			noteID:       "1",
+			field:        "other",
+			fileName:     "a.txt",
+			content:      "a",
+			withUser:     true,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "note not found",
+			noteID:       "2",
+			field:        "file",
+			fileName:     "a.txt",
+			content:      "a",
+			withUser:     true,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "read-only note",
+			noteID:       "3",
+			field:        "file",
+			fileName:     "a.txt",
+			content:      "a",
+			withUser:     true,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "unauthorized",
+			noteID:       "1",
+			field:        "file",
+			fileName:     "a.txt",
+			content:      "a",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, repo, _ := newHandler(t, 32)
+
+			body, contentType := multipartBody(t, tc.field, tc.fileName, tc.contentType, tc.content)
+			req := newRequest(http.MethodPost, "/notes/"+tc.noteID+"/attachments", body, tc.noteID, "", tc.withUser)
+			req.Header.Set("Content-Type", contentType)
+			rr := httptest.NewRecorder()
+
+			h.Upload(rr, req)
+
+			assert.Equal(t, tc.expectedCode, rr.Code)
+			if tc.expectedCode != http.StatusCreated {
+				assert.Empty(t, repo.attachments)
+				return
+			}
+
+			var resp dto.AttachmentResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedName, resp.Name)
+			assert.Equal(t, tc.expectedMIME, resp.MIMEType)
+			assert.Equal(t, int64(len(tc.content)), resp.Size)
+			assert.Len(t, resp.SHA256, 64)
+		})
+	}
+}
+
+func Test_Download(t *testing.T) {
+	h, repo, blobs := newHandler(t, 1024)
+
+	sum, size, err := blobs.Put(strings.NewReader("0123456789"))
+	require.NoError(t, err)
+	repo.attachments[1] = entity.Attachment{ID: 1, NoteID: 1, Name: "digits.txt", MIMEType: "text/plain; charset=utf-8", Size: size, SHA256: sum}
+	repo.attachments[2] = entity.Attachment{ID: 2, NoteID: 1, Name: "page.html", MIMEType: "text/html", Size: size, SHA256: sum}
+
+	cases := []struct {
+		name                string
+		attachmentID        string
+		rangeHeader         string
+		expectedCode        int
+		expectedBody        string
+		expectedDisposition string
+	}{
+		{
+			name:                "whole file",
+			attachmentID:        "1",
+			expectedCode:        http.StatusOK,
+			expectedBody:        "0123456789",
+			expectedDisposition: `inline; filename=digits.txt`,
+		},
+		{
+			name:                "range",
+			attachmentID:        "1",
+			rangeHeader:         "bytes=2-4",
+			expectedCode:        http.StatusPartialContent,
+			expectedBody:        "234",
+			expectedDisposition: `inline; filename=digits.txt`,
+		},
+		{
+			name:                "active content downloaded",
+			attachmentID:        "2",
+			expectedCode:        http.StatusOK,
+			expectedBody:        "0123456789",
+			expectedDisposition: `attachment; filename=page.html`,
+		},
+		{
+			name:         "not found",
+			attachmentID: "3",
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := newRequest(http.MethodGet, "/notes/1/attachments/"+tc.attachmentID, nil, "1", tc.attachmentID, true)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+			rr := httptest.NewRecorder()
+
+			h.Download(rr, req)
+
+			assert.Equal(t, tc.expectedCode, rr.Code)
+			if tc.expectedBody == "" {
+				return
+			}
+			assert.Equal(t, tc.expectedBody, rr.Body.String())
+			assert.Equal(t, tc.expectedDisposition, rr.Header().Get("Content-Disposition"))
+			assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, `"`+sum+`"`, rr.Header().Get("ETag"))
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	h, repo, _ := newHandler(t, 1024)
+	repo.attachments[1] = entity.Attachment{ID: 1, NoteID: 1, Name: "a.txt"}
+
+	req := newRequest(http.MethodDelete, "/notes/1/attachments/1", nil, "1", "1", true)
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, repo.attachments)
+
+	rr = httptest.NewRecorder()
+	h.Delete(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Delete(rr, newRequest(http.MethodDelete, "/notes/3/attachments/1", nil, "3", "1", true))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
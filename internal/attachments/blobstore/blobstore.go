@@ -0,0 +1,173 @@
+// Package blobstore keeps files on disk addressed by the hex SHA-256 of their
+// content. Storing the same bytes twice yields the same blob.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var ErrInvalidSum = errors.New("invalid blob sum")
+
+type Store struct {
+	dir string
+	// mu orders Put against RemoveIf, so a blob is not removed while an
+	// upload is reusing it.
+	mu sync.Mutex
+}
+
+// New opens a store rooted at dir, creating the directory if needed.
+func New(dir string) (*Store, error) {
+	const op = "blobstore.New"
+
+	if err := os.MkdirAll(filepath.Join(dir, "tmp"), 0o750); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Put stores the content of r and returns its sum and size. When the blob
+// already exists its modification time is refreshed so a concurrent RemoveIf
+// does not remove it before the caller records a reference.
+func (s *Store) Put(r io.Reader) (string, int64, error) {
+	const op = "blobstore.Put"
+
+	tmp, err := os.CreateTemp(filepath.Join(s.dir, "tmp"), "upload-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	path := s.path(sum)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+		if err := os.Chtimes(path, now, now); err != nil {
+			return "", 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return sum, size, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sum, size, nil
+}
+
+// Open returns the blob with the given sum for reading.
+func (s *Store) Open(sum string) (*os.File, error) {
+	if !validSum(sum) {
+		return nil, ErrInvalidSum
+	}
+	return os.Open(s.path(sum))
+}
+
+// Remove deletes a blob. Removing a missing blob is not an error.
+func (s *Store) Remove(sum string) error {
+	if !validSum(sum) {
+		return ErrInvalidSum
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.remove(sum)
+}
+
+// RemoveIf deletes a blob when stale, called with its current modification
+// time, agrees, and reports whether it did. Put waits until it is done, so a
+// blob refreshed by an upload after stale looked at it is kept. A missing
+// blob is not an error.
+func (s *Store) RemoveIf(sum string, stale func(modTime time.Time) (bool, error)) (bool, error) {
+	if !validSum(sum) {
+		return false, ErrInvalidSum
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path(sum))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	ok, err := stale(info.ModTime())
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := s.remove(sum); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *Store) remove(sum string) error {
+	if err := os.Remove(s.path(sum)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Walk calls fn for every stored blob.
+func (s *Store) Walk(fn func(sum string, modTime time.Time) error) error {
+	return filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == "tmp" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !validSum(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(d.Name(), info.ModTime())
+	})
+}
+
+// path spreads blobs over subdirectories named after the first two hex digits.
+func (s *Store) path(sum string) string {
+	return filepath.Join(s.dir, sum[:2], sum)
+}
+
+func validSum(sum string) bool {
+	if len(sum) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(sum)
+	return err == nil
+}
@@ -0,0 +1,90 @@
+package blobstore
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PutDeduplicates(t *testing.T) {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	sum, size, err := s.Put(strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", sum)
+	assert.Equal(t, int64(5), size)
+
+	again, _, err := s.Put(strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, sum, again)
+
+	var sums []string
+	require.NoError(t, s.Walk(func(sum string, _ time.Time) error {
+		sums = append(sums, sum)
+		return nil
+	}))
+	assert.Equal(t, []string{sum}, sums)
+
+	f, err := s.Open(sum)
+	require.NoError(t, err)
+	b, err := io.ReadAll(f)
+	f.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+}
+
+func Test_Remove(t *testing.T) {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	sum, _, err := s.Put(strings.NewReader("bye"))
+	require.NoError(t, err)
+
+	require.NoError(t, s.Remove(sum))
+	require.NoError(t, s.Remove(sum))
+
+	_, err = s.Open(sum)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_RemoveIf(t *testing.T) {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	sum, _, err := s.Put(strings.NewReader("bye"))
+	require.NoError(t, err)
+
+	var seen time.Time
+	removed, err := s.RemoveIf(sum, func(modTime time.Time) (bool, error) {
+		seen = modTime
+		return false, nil
+	})
+	require.NoError(t, err)
+	assert.False(t, removed)
+	assert.WithinDuration(t, time.Now(), seen, time.Minute)
+
+	removed, err = s.RemoveIf(sum, func(time.Time) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.True(t, removed)
+	_, err = s.Open(sum)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	removed, err = s.RemoveIf(sum, func(time.Time) (bool, error) { return true, nil })
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
+
+func Test_RejectsInvalidSum(t *testing.T) {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+
+	_, err = s.Open("../../etc/passwd")
+	assert.ErrorIs(t, err, ErrInvalidSum)
+	assert.ErrorIs(t, s.Remove("zz"), ErrInvalidSum)
+}
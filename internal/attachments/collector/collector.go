@@ -0,0 +1,76 @@
+// Package collector removes blobs that no attachment refers to any more, for
+// example after their notes were purged from the trash.
+package collector
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultGrace keeps fresh blobs around long enough for an upload to record
+// its attachment row after the blob has been written.
+const DefaultGrace = time.Hour
+
+type Blobs interface {
+	Walk(fn func(sum string, modTime time.Time) error) error
+	RemoveIf(sum string, stale func(modTime time.Time) (bool, error)) (bool, error)
+}
+
+type Referencer interface {
+	Referenced(sha256 string) (bool, error)
+}
+
+type Collector struct {
+	blobs   Blobs
+	storage Referencer
+	grace   time.Duration
+	now     func() time.Time
+}
+
+func New(blobs Blobs, storage Referencer, grace time.Duration) *Collector {
+	return &Collector{
+		blobs:   blobs,
+		storage: storage,
+		grace:   grace,
+		now:     time.Now,
+	}
+}
+
+// Collect removes unreferenced blobs older than the grace period and returns
+// how many were removed. Blobs are checked once more as they are removed, as
+// an upload may reuse one after the walk found it.
+func (c *Collector) Collect() (int, error) {
+	const op = "attachments.collector.Collect"
+
+	cutoff := c.now().Add(-c.grace)
+
+	var old []string
+	err := c.blobs.Walk(func(sum string, modTime time.Time) error {
+		if !modTime.After(cutoff) {
+			old = append(old, sum)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	removed := 0
+	for _, sum := range old {
+		ok, err := c.blobs.RemoveIf(sum, func(modTime time.Time) (bool, error) {
+			if modTime.After(cutoff) {
+				return false, nil
+			}
+			referenced, err := c.storage.Referenced(sum)
+			return !referenced, err
+		})
+		if err != nil {
+			return removed, fmt.Errorf("%s: %w", op, err)
+		}
+		if ok {
+			removed++
+		}
+	}
+
+	return removed, nil
+}
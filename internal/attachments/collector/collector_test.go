@@ -0,0 +1,79 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBlobs struct {
+	blobs   map[string]time.Time
+	removed []string
+	// afterWalk changes modification times once the walk is done, the way
+	// an upload reusing a blob would.
+	afterWalk map[string]time.Time
+}
+
+func (m *mockBlobs) Walk(fn func(sum string, modTime time.Time) error) error {
+	for sum, mod := range m.blobs {
+		if err := fn(sum, mod); err != nil {
+			return err
+		}
+	}
+	for sum, mod := range m.afterWalk {
+		m.blobs[sum] = mod
+	}
+	return nil
+}
+
+func (m *mockBlobs) RemoveIf(sum string, stale func(modTime time.Time) (bool, error)) (bool, error) {
+	ok, err := stale(m.blobs[sum])
+	if err != nil || !ok {
+		return false, err
+	}
+	m.removed = append(m.removed, sum)
+	return true, nil
+}
+
+type mockReferencer struct {
+	referenced map[string]bool
+	err        error
+}
+
+func (m *mockReferencer) Referenced(sum string) (bool, error) {
+	return m.referenced[sum], m.err
+}
+
+func Test_Collect(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	blobs := &mockBlobs{
+		blobs: map[string]time.Time{
+			"orphan": now.Add(-2 * time.Hour),
+			"used":   now.Add(-2 * time.Hour),
+			"fresh":  now.Add(-time.Minute),
+			"reused": now.Add(-2 * time.Hour),
+		},
+		afterWalk: map[string]time.Time{"reused": now},
+	}
+	refs := &mockReferencer{referenced: map[string]bool{"used": true}}
+
+	c := New(blobs, refs, time.Hour)
+	c.now = func() time.Time { return now }
+
+	n, err := c.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"orphan"}, blobs.removed)
+}
+
+func Test_CollectStopsOnStorageError(t *testing.T) {
+	blobs := &mockBlobs{blobs: map[string]time.Time{"old": time.Time{}}}
+	refs := &mockReferencer{err: errors.New("db error")}
+
+	_, err := New(blobs, refs, time.Hour).Collect()
+	assert.Error(t, err)
+	assert.Empty(t, blobs.removed)
+}
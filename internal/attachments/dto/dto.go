@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"gonotes/internal/attachments/entity"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type AttachmentResponse struct {
+	ID        int       `json:"id"`
+	NoteID    int       `json:"note_id"`
+	Name      string    `json:"name"`
+	MIMEType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (ar *AttachmentResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewAttachmentResponse(a *entity.Attachment) *AttachmentResponse {
+	return &AttachmentResponse{
+		ID:        a.ID,
+		NoteID:    a.NoteID,
+		Name:      a.Name,
+		MIMEType:  a.MIMEType,
+		Size:      a.Size,
+		SHA256:    a.SHA256,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+func NewAttachmentListResponse(attachments []entity.Attachment) []render.Renderer {
+	list := make([]render.Renderer, len(attachments))
+	for i := range attachments {
+		list[i] = NewAttachmentResponse(&attachments[i])
+	}
+	return list
+}
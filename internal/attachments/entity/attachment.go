@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// Attachment is a file attached to a note. The file itself lives in the blob
+// store under its SHA-256 sum, so identical uploads share one blob.
+type Attachment struct {
+	ID        int       `json:"id"`
+	NoteID    int       `json:"note_id"`
+	UserID    int       `json:"user_id"`
+	Name      string    `json:"name"`
+	MIMEType  string    `json:"mime_type"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,180 @@
+package attachmentssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/attachments/entity"
+	"gonotes/internal/attachments/storage"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+	"time"
+)
+
+const attachmentColumns = "id, note_id, user_id, name, mime_type, size, sha256, created_at"
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+func (r *AttachmentRepository) Create(a *entity.Attachment) (*entity.Attachment, error) {
+	const op = "attachments.sqlite.Create"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := notessqlite.CheckNote(tx, a.NoteID, a.UserID, notesstorage.AccessEdit); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrForbidden) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created := *a
+	created.CreatedAt = time.Now().UTC()
+
+	res, err := tx.Exec("INSERT INTO attachments (note_id, user_id, name, mime_type, size, sha256, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		created.NoteID, created.UserID, created.Name, created.MIMEType, created.Size, created.SHA256, created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	created.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &created, nil
+}
+
+// List returns the attachments of a note, oldest first.
+func (r *AttachmentRepository) List(noteID int, userID int) ([]entity.Attachment, error) {
+	const op = "attachments.sqlite.List"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query("SELECT "+attachmentColumns+" FROM attachments WHERE note_id = ? ORDER BY id", noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attachments := []entity.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		attachments = append(attachments, *a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attachments, nil
+}
+
+func (r *AttachmentRepository) Get(id int, noteID int, userID int) (*entity.Attachment, error) {
+	const op = "attachments.sqlite.Get"
+
+	a, err := get(r.db, id, noteID, userID, notesstorage.AccessRead)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrAttachmentNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+// Delete removes an attachment and returns it so the caller can release the
+// blob once nothing references it any more.
+func (r *AttachmentRepository) Delete(id int, noteID int, userID int) (*entity.Attachment, error) {
+	const op = "attachments.sqlite.Delete"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	a, err := get(tx, id, noteID, userID, notesstorage.AccessEdit)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrForbidden) || errors.Is(err, storage.ErrAttachmentNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+func (r *AttachmentRepository) Referenced(sha256 string) (bool, error) {
+	const op = "attachments.sqlite.Referenced"
+
+	var exists int
+	err := r.db.QueryRow("SELECT 1 FROM attachments WHERE sha256 = ? LIMIT 1", sha256).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func get(q querier, id int, noteID int, userID int, access notesstorage.Access) (*entity.Attachment, error) {
+	if err := notessqlite.CheckNote(q, noteID, userID, access); err != nil {
+		return nil, err
+	}
+
+	a, err := scanAttachment(q.QueryRow("SELECT "+attachmentColumns+" FROM attachments WHERE id = ? AND note_id = ?", id, noteID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrAttachmentNotFound
+	}
+	return a, err
+}
+
+func scanAttachment(s scanner) (*entity.Attachment, error) {
+	var a entity.Attachment
+	if err := s.Scan(&a.ID, &a.NoteID, &a.UserID, &a.Name, &a.MIMEType, &a.Size, &a.SHA256, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
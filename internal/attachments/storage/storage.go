@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/attachments/entity"
+	notesstorage "gonotes/internal/notes/storage"
+)
+
+var (
+	ErrNoteNotFound       = notesstorage.ErrNoteNotFound
+	ErrForbidden          = notesstorage.ErrForbidden
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+type AttachmentRepository interface {
+	Create(a *entity.Attachment) (*entity.Attachment, error)
+	List(noteID int, userID int) ([]entity.Attachment, error)
+	Get(id int, noteID int, userID int) (*entity.Attachment, error)
+	Delete(id int, noteID int, userID int) (*entity.Attachment, error)
+	// Referenced reports whether any attachment still points at a blob.
+	Referenced(sha256 string) (bool, error)
+}
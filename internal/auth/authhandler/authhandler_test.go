@@ -21,6 +21,7 @@ import (
 type mockUserRepo struct {
 	createFunc func(u *entity.User) (int, error)
 	getFunc    func(email string) (*entity.User, error)
+	getByID    func(id int) (*entity.User, error)
 	checkFunc  func(id, something int) bool
 	deleteFunc func(id int) error
 }
@@ -31,6 +32,9 @@ func (m *mockUserRepo) Create(u *entity.User) (int, error) {
 func (m *mockUserRepo) Get(e string) (*entity.User, error) {
 	return m.getFunc(e)
 }
+func (m *mockUserRepo) GetByID(id int) (*entity.User, error) {
+	return m.getByID(id)
+}
 func (m *mockUserRepo) Check(id, smth int) bool {
 	return m.checkFunc(id, smth)
 }
@@ -67,6 +67,21 @@ func (r *UserRepository) Get(email string) (*entity.User, error) {
 	return &user, nil
 }
 
+func (r *UserRepository) GetByID(id int) (*entity.User, error) {
+	const op = "auth.sqlite.GetByID"
+
+	var user entity.User
+	err := r.db.QueryRow(
+		"SELECT id, email, password FROM users WHERE id = ?",
+		id,
+	).Scan(&user.ID, &user.Email, &user.Password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &user, nil
+}
+
 func (r *UserRepository) Delete(id int) error {
 	const op = "auth.sqlite.Delete"
 
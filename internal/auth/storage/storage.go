@@ -5,6 +5,7 @@ import "gonotes/internal/auth/entity"
 type UserRepository interface {
 	Create(*entity.User) (int, error)
 	Get(email string) (*entity.User, error)
+	GetByID(id int) (*entity.User, error)
 	Check(int, int) bool
 	Delete(int) error
 }
@@ -0,0 +1,572 @@
+// Package collab runs the editing sessions of notes. Everyone editing a note
+// holds a replica of its content as a text CRDT and sends the operations they
+// make through the session, so concurrent edits merge character by character
+// instead of overwriting each other. Every compaction interval the session
+// writes the merged text back to the note and folds in changes made to the
+// note through the rest of the API.
+package collab
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gonotes/internal/collab/entity"
+	"gonotes/internal/collab/storage"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/crdt"
+	"gonotes/internal/lib/diff"
+	"gonotes/internal/live"
+	"gonotes/internal/live/dto"
+	notesentity "gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength caps the length of a note edited in a session, in characters.
+	MaxLength = 1 << 20
+
+	// serverSite makes the operations that merge in changes made outside
+	// the session.
+	serverSite = "server"
+)
+
+var (
+	ErrNotEditing = errors.New("not editing the note")
+	// ErrOutOfSync means an operation refers to text the session does not
+	// have. The client should join again to reload the state.
+	ErrOutOfSync = errors.New("edit refers to unknown text; join again")
+	ErrTooLong   = errors.New("note is too long")
+)
+
+type editor struct {
+	site    string
+	canEdit bool
+}
+
+type session struct {
+	mu      sync.Mutex
+	noteID  int
+	doc     *crdt.Doc
+	editors map[*live.Client]editor
+	// The note's content at version, which base holds, and the IDs its
+	// characters have in doc.
+	version  int
+	baseText string
+	baseIDs  []crdt.ID
+	// changed is set when doc has changed since it was last saved.
+	changed bool
+	// userID is the user the note is read and written as: the last one to
+	// edit it while they still can, or else its owner.
+	userID  int
+	ownerID int
+	closed  bool
+}
+
+type Manager struct {
+	log      *slog.Logger
+	notes    notesstorage.NoteRepository
+	store    storage.Store
+	hub      *live.Hub
+	events   events.Publisher
+	interval time.Duration
+
+	mu       sync.Mutex
+	sessions map[int]*session
+}
+
+// New returns a manager that sends edits through hub and writes sessions
+// back to their notes every interval.
+func New(log *slog.Logger, notes notesstorage.NoteRepository, store storage.Store, hub *live.Hub, events events.Publisher, interval time.Duration) *Manager {
+	return &Manager{
+		log:      log,
+		notes:    notes,
+		store:    store,
+		hub:      hub,
+		events:   events,
+		interval: interval,
+		sessions: map[int]*session{},
+	}
+}
+
+// Join adds c to the note's editing session, starting it if needed, and
+// sends c the session's state. Users the note is shared with read-only join
+// to follow the edits of others.
+func (m *Manager) Join(c *live.Client, noteID int) error {
+	const op = "collab.join"
+
+	userID := c.UserID()
+	if _, err := m.notes.Get(noteID, userID); err != nil {
+		if errors.Is(err, notesstorage.ErrNoteNotFound) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	canEdit, err := m.store.CanEdit(noteID, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for {
+		s := m.session(noteID)
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			m.forget(s)
+			continue
+		}
+		err := m.join(s, c, canEdit)
+		s.mu.Unlock()
+		return err
+	}
+}
+
+func (m *Manager) join(s *session, c *live.Client, canEdit bool) error {
+	const op = "collab.join"
+
+	if s.doc == nil {
+		if err := m.load(s, c.UserID()); err != nil {
+			if errors.Is(err, notesstorage.ErrNoteNotFound) {
+				return err
+			}
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	site, err := newSite(c.UserID())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	s.editors[c] = editor{site: site, canEdit: canEdit}
+
+	state := s.doc.State()
+	m.hub.Send(c, dto.ServerMessage{Type: dto.TypeEditState, NoteID: s.noteID, Site: site, State: &state, ReadOnly: !canEdit})
+	return nil
+}
+
+// Leave removes c from the note's editing session and reports whether it
+// was editing the note.
+func (m *Manager) Leave(c *live.Client, noteID int) bool {
+	m.mu.Lock()
+	s := m.sessions[noteID]
+	m.mu.Unlock()
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.editors[c]
+	delete(s.editors, c)
+	return ok
+}
+
+// Disconnect removes c from every session it is editing.
+func (m *Manager) Disconnect(c *live.Client) {
+	m.mu.Lock()
+	sessions := m.list()
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		s.mu.Lock()
+		delete(s.editors, c)
+		s.mu.Unlock()
+	}
+}
+
+// Apply applies operations c made to the note and sends them to the other
+// editors. Operations are applied in order up to the first one that fails;
+// the error tells which failed.
+func (m *Manager) Apply(c *live.Client, noteID int, ops []crdt.Op) error {
+	m.mu.Lock()
+	s := m.sessions[noteID]
+	m.mu.Unlock()
+	if s == nil {
+		return ErrNotEditing
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.editors[c]
+	if s.closed || !ok {
+		return ErrNotEditing
+	}
+	if !e.canEdit {
+		return notesstorage.ErrForbidden
+	}
+
+	var (
+		applied int
+		err     error
+	)
+	for i, op := range ops {
+		if op.Type == crdt.OpInsert {
+			if op.ID.Site != e.site {
+				err = fmt.Errorf("ops[%d]: %w: inserts must be made as %s", i, crdt.ErrInvalidOp, e.site)
+				break
+			}
+			if s.doc.Len()+utf8.RuneCountInString(op.Text) > MaxLength {
+				err = ErrTooLong
+				break
+			}
+		}
+		if !s.doc.Ready(op) {
+			err = ErrOutOfSync
+			break
+		}
+		if err = s.doc.Apply(op); err != nil {
+			err = fmt.Errorf("ops[%d]: %w", i, err)
+			break
+		}
+		applied++
+	}
+
+	if applied > 0 {
+		s.changed = true
+		s.userID = c.UserID()
+		m.broadcast(s, c, c.UserID(), ops[:applied])
+	}
+	return err
+}
+
+// Run compacts the sessions every interval until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Compact()
+		}
+	}
+}
+
+// Compact writes the text of every session back to its note and closes the
+// sessions no one is editing any more. It runs on every tick of Run, and is
+// meant to run once more when the server shuts down.
+func (m *Manager) Compact() {
+	m.mu.Lock()
+	sessions := m.list()
+	m.mu.Unlock()
+
+	for _, s := range sessions {
+		m.compact(s)
+
+		m.mu.Lock()
+		s.mu.Lock()
+		if len(s.editors) == 0 && !s.changed {
+			s.closed = true
+		}
+		if s.closed && m.sessions[s.noteID] == s {
+			delete(m.sessions, s.noteID)
+		}
+		s.mu.Unlock()
+		m.mu.Unlock()
+	}
+}
+
+func (m *Manager) compact(s *session) {
+	const op = "collab.compact"
+	log := m.log.With(slog.String("op", op), slog.Int("note_id", s.noteID))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.doc == nil {
+		return
+	}
+
+	m.checkAccess(s)
+
+	note, err := m.notes.Get(s.noteID, s.userID)
+	if err != nil {
+		if errors.Is(err, notesstorage.ErrNoteNotFound) {
+			m.end(s, err)
+			return
+		}
+		log.Error("failed to get note", slog.Any("err", err))
+		return
+	}
+	m.merge(s, note)
+
+	text := s.doc.Text()
+	if text != note.Content {
+		updated, err := m.notes.Update(s.noteID, s.userID, &notesentity.Note{
+			Title:   note.Title,
+			Content: text,
+			Format:  note.Format,
+			Version: note.Version,
+		})
+		switch {
+		case errors.Is(err, notesstorage.ErrVersionMismatch):
+			// The change is merged on the next compaction.
+			log.Info("note changed while compacting")
+			return
+		case errors.Is(err, notesstorage.ErrNoteNotFound):
+			m.end(s, err)
+			return
+		case errors.Is(err, notesstorage.ErrForbidden):
+			// Access changed since it was checked; the next compaction
+			// writes as the owner.
+			log.Info("note became read-only while compacting")
+			return
+		case err != nil:
+			log.Error("failed to update note", slog.Any("err", err))
+			return
+		}
+
+		s.version = updated.Version
+		s.baseText = text
+		s.baseIDs = s.doc.IDs()
+		m.events.Publish(events.NewNoteEvent(events.NoteUpdated, s.userID, updated))
+	}
+
+	if !s.changed {
+		return
+	}
+	snap := &entity.Snapshot{NoteID: s.noteID, State: s.doc.State(), Version: s.version, UpdatedAt: time.Now()}
+	if err := m.store.Save(snap); err != nil {
+		log.Error("failed to save snapshot", slog.Any("err", err))
+		return
+	}
+	s.changed = false
+}
+
+// load starts a session from its saved snapshot, or from scratch, and merges
+// in whatever changed in the note since; s.mu must be held.
+func (m *Manager) load(s *session, userID int) error {
+	note, err := m.notes.Get(s.noteID, userID)
+	if err != nil {
+		return err
+	}
+
+	doc := crdt.New(serverSite)
+	version := 0
+	snap, err := m.store.Load(s.noteID)
+	switch {
+	case err == nil:
+		restored, err := crdt.FromState(serverSite, snap.State)
+		if err != nil {
+			m.log.Warn("discarding invalid snapshot", slog.Int("note_id", s.noteID), slog.Any("err", err))
+			break
+		}
+		doc, version = restored, snap.Version
+	case !errors.Is(err, storage.ErrSnapshotNotFound):
+		return err
+	}
+
+	s.doc = doc
+	s.userID = note.UserID
+	s.ownerID = note.UserID
+	s.version = version
+	s.baseText = doc.Text()
+	s.baseIDs = doc.IDs()
+	m.merge(s, note)
+	return nil
+}
+
+// merge folds the changes made to the note since s.version into the session.
+// The note's content at s.version is diffed against its current content line
+// by line, and each change is applied to the characters it covers, so it
+// merges with the edits made in the session meanwhile; s.mu must be held.
+func (m *Manager) merge(s *session, note *notesentity.Note) {
+	if note.Version == s.version {
+		return
+	}
+
+	var (
+		ops   []crdt.Op
+		ids   []crdt.ID
+		pos   int
+		after crdt.ID
+		text  strings.Builder
+	)
+	// flush inserts the lines added since the last unchanged one.
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		// after is a character of the doc, so the insert cannot fail.
+		op, _ := s.doc.InsertAfter(after, text.String())
+		ops = append(ops, op)
+		id := op.ID
+		for range utf8.RuneCountInString(op.Text) {
+			ids = append(ids, id)
+			id.Clock++
+		}
+		text.Reset()
+	}
+
+	for _, e := range diff.Lines(splitLines(s.baseText), splitLines(note.Content)) {
+		n := utf8.RuneCountInString(e.Line)
+		switch e.Op {
+		case diff.Equal:
+			flush()
+			ids = append(ids, s.baseIDs[pos:pos+n]...)
+			pos += n
+			after = s.baseIDs[pos-1]
+		case diff.Delete:
+			for _, id := range s.baseIDs[pos : pos+n] {
+				op := crdt.Op{Type: crdt.OpDelete, ID: id}
+				s.doc.Apply(op)
+				ops = append(ops, op)
+			}
+			pos += n
+		case diff.Insert:
+			text.WriteString(e.Line)
+		}
+	}
+	flush()
+
+	s.version = note.Version
+	s.baseText = note.Content
+	s.baseIDs = ids
+	if len(ops) > 0 {
+		s.changed = true
+		m.broadcast(s, nil, 0, ops)
+	}
+}
+
+// checkAccess checks again who may edit the note, as shares can change
+// while the session runs. Editors who can no longer edit it become
+// read-only, those who can no longer read it are removed, and the session
+// falls back to writing as the owner when the last one to edit it lost
+// access; s.mu must be held.
+func (m *Manager) checkAccess(s *session) {
+	const op = "collab.checkAccess"
+	log := m.log.With(slog.String("op", op), slog.Int("note_id", s.noteID))
+
+	access := map[int]bool{s.ownerID: true}
+	canEdit := func(userID int) (bool, error) {
+		ok, seen := access[userID]
+		if seen {
+			return ok, nil
+		}
+		ok, err := m.store.CanEdit(s.noteID, userID)
+		if err != nil {
+			return false, err
+		}
+		access[userID] = ok
+		return ok, nil
+	}
+
+	for c, e := range s.editors {
+		ok, err := canEdit(c.UserID())
+		if err != nil {
+			log.Error("failed to check edit access", slog.Any("err", err))
+			return
+		}
+		if !ok {
+			if _, err := m.notes.Get(s.noteID, c.UserID()); errors.Is(err, notesstorage.ErrNoteNotFound) {
+				delete(s.editors, c)
+				m.hub.Send(c, dto.NewErrorMessage(s.noteID, err))
+				m.hub.Send(c, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: s.noteID})
+				continue
+			}
+		}
+		if ok == e.canEdit {
+			continue
+		}
+		e.canEdit = ok
+		s.editors[c] = e
+		state := s.doc.State()
+		m.hub.Send(c, dto.ServerMessage{Type: dto.TypeEditState, NoteID: s.noteID, Site: e.site, State: &state, ReadOnly: !ok})
+	}
+
+	ok, err := canEdit(s.userID)
+	if err != nil {
+		log.Error("failed to check edit access", slog.Any("err", err))
+		return
+	}
+	if !ok {
+		s.userID = s.ownerID
+	}
+}
+
+// end closes a session that can no longer be written back, telling its
+// editors why; s.mu must be held.
+func (m *Manager) end(s *session, err error) {
+	m.log.Info("editing session ended", slog.Int("note_id", s.noteID), slog.Any("reason", err))
+
+	for c := range s.editors {
+		m.hub.Send(c, dto.NewErrorMessage(s.noteID, err))
+		m.hub.Send(c, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: s.noteID})
+	}
+	s.editors = map[*live.Client]editor{}
+	s.changed = false
+	s.closed = true
+}
+
+// broadcast sends ops to every editor of the session except skip; s.mu must
+// be held.
+func (m *Manager) broadcast(s *session, skip *live.Client, userID int, ops []crdt.Op) {
+	for c := range s.editors {
+		if c != skip {
+			m.hub.Send(c, dto.ServerMessage{Type: dto.TypeEditOps, NoteID: s.noteID, UserID: userID, Ops: ops})
+		}
+	}
+}
+
+// session returns the note's session, adding an empty one when there is
+// none.
+func (m *Manager) session(noteID int) *session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[noteID]
+	if !ok {
+		s = &session{noteID: noteID, editors: map[*live.Client]editor{}}
+		m.sessions[noteID] = s
+	}
+	return s
+}
+
+// forget removes a closed session so the next join starts a new one.
+func (m *Manager) forget(s *session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sessions[s.noteID] == s {
+		delete(m.sessions, s.noteID)
+	}
+}
+
+// list returns the sessions; m.mu must be held.
+func (m *Manager) list() []*session {
+	sessions := make([]*session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		sessions = append(sessions, s)
+	}
+	return sessions
+}
+
+// newSite names a replica of a user. Every join gets a new one, so no two
+// replicas make operations with the same IDs.
+func newSite(userID int) (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("u%d-%s", userID, hex.EncodeToString(b)), nil
+}
+
+// splitLines splits text into lines that keep their newlines, so joining
+// them gives the text back.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,479 @@
+package collab_test
+
+import (
+	"fmt"
+	"gonotes/internal/collab"
+	collabentity "gonotes/internal/collab/entity"
+	collabstorage "gonotes/internal/collab/storage"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/crdt"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/live"
+	"gonotes/internal/live/dto"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNotesRepo holds one note that user 1 owns, shared for editing with user
+// 2 and read-only with user 3. The other methods are unimplemented and panic
+// if called.
+type mockNotesRepo struct {
+	storage.NoteRepository
+
+	mu      sync.Mutex
+	note    entity.Note
+	shares  map[int]string
+	deleted bool
+	updates int
+	// updatedBy is the user the note was last updated as.
+	updatedBy int
+}
+
+func newNotesRepo(content string) *mockNotesRepo {
+	return &mockNotesRepo{
+		note:   entity.Note{ID: 1, UserID: 1, Title: "t", Content: content, Format: entity.FormatPlain, Version: 1},
+		shares: map[int]string{2: "edit", 3: "read"},
+	}
+}
+
+// share changes the permission the user has on the note; an empty one
+// revokes the share.
+func (m *mockNotesRepo) share(userID int, permission string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shares[userID] = permission
+}
+
+func (m *mockNotesRepo) canEdit(userID int) bool {
+	return userID == m.note.UserID || m.shares[userID] == "edit"
+}
+
+func (m *mockNotesRepo) Get(id int, userID int) (*entity.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id != m.note.ID || m.deleted || (userID != m.note.UserID && m.shares[userID] == "") {
+		return nil, storage.ErrNoteNotFound
+	}
+	n := m.note
+	return &n, nil
+}
+
+func (m *mockNotesRepo) Update(id int, userID int, note *entity.Note) (*entity.Note, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if id != m.note.ID || m.deleted || (userID != m.note.UserID && m.shares[userID] == "") {
+		return nil, storage.ErrNoteNotFound
+	}
+	if !m.canEdit(userID) {
+		return nil, storage.ErrForbidden
+	}
+	if note.Version != m.note.Version {
+		return nil, storage.ErrVersionMismatch
+	}
+	m.note.Title, m.note.Content = note.Title, note.Content
+	m.note.Version++
+	m.updates++
+	m.updatedBy = userID
+	n := m.note
+	return &n, nil
+}
+
+// edit changes the note the way the REST API would.
+func (m *mockNotesRepo) edit(f func(string) string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.note.Content = f(m.note.Content)
+	m.note.Version++
+}
+
+func (m *mockNotesRepo) content() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.note.Content
+}
+
+// mockStore answers CanEdit from the shares of notes.
+type mockStore struct {
+	notes *mockNotesRepo
+	snap  *collabentity.Snapshot
+}
+
+func (s *mockStore) Load(noteID int) (*collabentity.Snapshot, error) {
+	if s.snap == nil {
+		return nil, collabstorage.ErrSnapshotNotFound
+	}
+	snap := *s.snap
+	return &snap, nil
+}
+
+func (s *mockStore) Save(snap *collabentity.Snapshot) error {
+	s.snap = snap
+	return nil
+}
+
+func (s *mockStore) CanEdit(noteID int, userID int) (bool, error) {
+	s.notes.mu.Lock()
+	defer s.notes.mu.Unlock()
+	return s.notes.canEdit(userID), nil
+}
+
+type env struct {
+	notes   *mockNotesRepo
+	store   *mockStore
+	hub     *live.Hub
+	events  *storagetest.EventRecorder
+	manager *collab.Manager
+}
+
+func newEnv(content string) *env {
+	notes := newNotesRepo(content)
+	e := &env{notes: notes, store: &mockStore{notes: notes}, hub: live.NewHub(), events: &storagetest.EventRecorder{}}
+	e.manager = collab.New(slogdiscard.NewDiscardLogger(), e.notes, e.store, e.hub, e.events, time.Hour)
+	return e
+}
+
+// replica is a client editing the note: it applies the operations the server
+// sends and queues its own until they are delivered.
+type replica struct {
+	client *live.Client
+	doc    *crdt.Doc
+	// inbox holds messages received but not yet handled.
+	inbox    []dto.ServerMessage
+	outbox   []crdt.Op
+	readOnly bool
+}
+
+func (e *env) join(t *testing.T, userID int) *replica {
+	t.Helper()
+
+	c := e.hub.Register(userID)
+	require.NoError(t, e.hub.Join(c, 1))
+	require.NoError(t, e.manager.Join(c, 1))
+
+	r := &replica{client: c}
+	for _, msg := range r.receive() {
+		if msg.Type == dto.TypeEditState {
+			doc, err := crdt.FromState(msg.Site, *msg.State)
+			require.NoError(t, err)
+			r.doc = doc
+			r.readOnly = msg.ReadOnly
+		}
+	}
+	require.NotNil(t, r.doc, "edit.state was not sent")
+	r.inbox = nil
+	return r
+}
+
+// receive moves the messages sent to the replica into its inbox and returns
+// what was received.
+func (r *replica) receive() []dto.ServerMessage {
+	var got []dto.ServerMessage
+	for {
+		select {
+		case msg := <-r.client.Messages():
+			got = append(got, msg)
+		default:
+			r.inbox = append(r.inbox, got...)
+			return got
+		}
+	}
+}
+
+// handle applies the operations in the inbox.
+func (r *replica) handle(t *testing.T) {
+	t.Helper()
+
+	r.receive()
+	for _, msg := range r.inbox {
+		if msg.Type == dto.TypeEditOps {
+			for _, op := range msg.Ops {
+				require.NoError(t, r.doc.Apply(op))
+			}
+		}
+	}
+	r.inbox = nil
+}
+
+// flush delivers the queued operations.
+func (r *replica) flush(t *testing.T, m *collab.Manager) {
+	t.Helper()
+
+	if len(r.outbox) > 0 {
+		require.NoError(t, m.Apply(r.client, 1, r.outbox))
+		r.outbox = nil
+	}
+}
+
+func (r *replica) insert(t *testing.T, pos int, text string) {
+	t.Helper()
+
+	op, err := r.doc.Insert(pos, text)
+	require.NoError(t, err)
+	r.outbox = append(r.outbox, op)
+}
+
+func Test_Edit(t *testing.T) {
+	e := newEnv("hello\n")
+	alice := e.join(t, 1)
+	bob := e.join(t, 2)
+	assert.Equal(t, "hello\n", alice.doc.Text())
+	assert.NotEqual(t, alice.doc.Site(), bob.doc.Site())
+
+	alice.insert(t, 5, " world")
+	sent := alice.outbox
+	alice.flush(t, e.manager)
+	bob.insert(t, 0, "> ")
+	bob.flush(t, e.manager)
+
+	received := bob.receive()
+	require.Len(t, received, 1)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeEditOps, NoteID: 1, UserID: 1, Ops: sent}, received[0])
+	alice.handle(t)
+	bob.handle(t)
+	assert.Equal(t, "> hello world\n", alice.doc.Text())
+	assert.Equal(t, alice.doc.Text(), bob.doc.Text())
+
+	assert.Equal(t, "hello\n", e.notes.content(), "edits are written on compaction")
+	e.manager.Compact()
+	assert.Equal(t, "> hello world\n", e.notes.content())
+	published := e.events.Events()
+	require.Len(t, published, 1)
+	assert.Equal(t, events.NoteUpdated, published[0].Type)
+	assert.Equal(t, 2, published[0].UserID)
+	require.NotNil(t, e.store.snap)
+	assert.Equal(t, 2, e.store.snap.Version)
+
+	// Nothing changed, so nothing is written.
+	e.manager.Compact()
+	assert.Equal(t, 1, e.notes.updates)
+	assert.Len(t, e.events.Events(), 1)
+}
+
+func Test_MergeOutsideChanges(t *testing.T) {
+	e := newEnv("one\ntwo\nthree\n")
+	alice := e.join(t, 1)
+
+	alice.insert(t, 3, "!")
+	alice.flush(t, e.manager)
+	e.notes.edit(func(string) string { return "one\n2\nthree\nfour\n" })
+
+	e.manager.Compact()
+	assert.Equal(t, "one!\n2\nthree\nfour\n", e.notes.content())
+
+	alice.handle(t)
+	assert.Equal(t, e.notes.content(), alice.doc.Text())
+}
+
+func Test_ReadOnly(t *testing.T) {
+	e := newEnv("text")
+	alice := e.join(t, 1)
+	carol := e.join(t, 3)
+	assert.True(t, carol.readOnly)
+	assert.False(t, alice.readOnly)
+
+	carol.insert(t, 0, "x")
+	assert.ErrorIs(t, e.manager.Apply(carol.client, 1, carol.outbox), storage.ErrForbidden)
+
+	alice.insert(t, 4, "!")
+	alice.flush(t, e.manager)
+	carol.handle(t)
+	assert.Equal(t, "xtext!", carol.doc.Text(), "read-only replicas still follow")
+}
+
+func Test_AccessChanged(t *testing.T) {
+	e := newEnv("text")
+	alice := e.join(t, 1)
+	bob := e.join(t, 2)
+	carol := e.join(t, 3)
+
+	bob.insert(t, 4, "!")
+	bob.flush(t, e.manager)
+	alice.handle(t)
+	bob.handle(t)
+	carol.handle(t)
+
+	// Bob made the last change but can no longer edit, so the session
+	// writes it as the owner instead of ending.
+	e.notes.share(2, "read")
+	e.notes.share(3, "")
+	e.manager.Compact()
+	assert.Equal(t, "text!", e.notes.content())
+	assert.Equal(t, 1, e.notes.updatedBy)
+	published := e.events.Events()
+	require.Len(t, published, 1)
+	assert.Equal(t, 1, published[0].UserID)
+
+	got := bob.receive()
+	require.Len(t, got, 1)
+	assert.Equal(t, dto.TypeEditState, got[0].Type)
+	assert.True(t, got[0].ReadOnly)
+	assert.Equal(t, bob.doc.Site(), got[0].Site)
+	bob.insert(t, 0, "x")
+	assert.ErrorIs(t, e.manager.Apply(bob.client, 1, bob.outbox), storage.ErrForbidden)
+
+	got = carol.receive()
+	require.Len(t, got, 2)
+	assert.Equal(t, dto.NewErrorMessage(1, storage.ErrNoteNotFound), got[0])
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: 1}, got[1])
+	assert.False(t, e.manager.Leave(carol.client, 1))
+
+	alice.insert(t, 0, "> ")
+	alice.flush(t, e.manager)
+	e.manager.Compact()
+	assert.Equal(t, "> text!", e.notes.content())
+
+	// Getting edit access back lifts the restriction.
+	e.notes.share(2, "edit")
+	e.manager.Compact()
+	got = bob.receive()
+	require.Len(t, got, 2)
+	assert.Equal(t, dto.TypeEditOps, got[0].Type)
+	assert.Equal(t, dto.TypeEditState, got[1].Type)
+	assert.False(t, got[1].ReadOnly)
+}
+
+func Test_ApplyErrors(t *testing.T) {
+	e := newEnv("text")
+	alice := e.join(t, 1)
+	bob := e.join(t, 2)
+
+	// Inserts must be made as the replica's own site.
+	bob.insert(t, 0, "x")
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 1, bob.outbox), crdt.ErrInvalidOp)
+
+	unknown := crdt.Op{Type: crdt.OpDelete, ID: crdt.ID{Clock: 99, Site: "nowhere"}}
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 1, []crdt.Op{unknown}), collab.ErrOutOfSync)
+
+	// Operations before the failing one are applied.
+	alice.insert(t, 0, "a")
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 1, append(alice.outbox, unknown)), collab.ErrOutOfSync)
+	bob.receive()
+	require.Len(t, bob.inbox, 1)
+	assert.Equal(t, alice.outbox, bob.inbox[0].Ops)
+
+	assert.True(t, e.manager.Leave(alice.client, 1))
+	assert.False(t, e.manager.Leave(alice.client, 1))
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 1, alice.outbox), collab.ErrNotEditing)
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 2, alice.outbox), collab.ErrNotEditing)
+}
+
+func Test_NoteDeleted(t *testing.T) {
+	e := newEnv("text")
+	alice := e.join(t, 1)
+	alice.insert(t, 0, "x")
+	alice.flush(t, e.manager)
+
+	e.notes.mu.Lock()
+	e.notes.deleted = true
+	e.notes.mu.Unlock()
+	e.manager.Compact()
+
+	got := alice.receive()
+	require.Len(t, got, 2)
+	assert.Equal(t, dto.NewErrorMessage(1, storage.ErrNoteNotFound), got[0])
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: 1}, got[1])
+	assert.ErrorIs(t, e.manager.Apply(alice.client, 1, alice.outbox), collab.ErrNotEditing)
+}
+
+func Test_Restart(t *testing.T) {
+	e := newEnv("text\n")
+	alice := e.join(t, 1)
+	alice.insert(t, 4, " more")
+	alice.flush(t, e.manager)
+	e.manager.Compact()
+
+	e.manager.Disconnect(alice.client)
+	e.manager.Compact()
+
+	// A new manager picks the session up from the snapshot, keeping the IDs
+	// the characters had, and merges what changed in the note meanwhile.
+	e.notes.edit(func(s string) string { return s + "last\n" })
+	e.manager = collab.New(slogdiscard.NewDiscardLogger(), e.notes, e.store, e.hub, e.events, time.Hour)
+	bob := e.join(t, 2)
+	assert.Equal(t, "text more\nlast\n", bob.doc.Text())
+	assert.Equal(t, alice.doc.IDs(), bob.doc.IDs()[:len(alice.doc.IDs())])
+}
+
+// Test_Convergence has several replicas edit the note while the note is also
+// edited outside the session. Operations reach the server, and the server's
+// messages reach the replicas, after random delays. Once everything has been
+// delivered every replica and the note hold the same text.
+func Test_Convergence(t *testing.T) {
+	for seed := int64(1); seed <= 30; seed++ {
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(seed))
+			e := newEnv("first line\nsecond line\n")
+
+			replicas := []*replica{e.join(t, 1), e.join(t, 2), e.join(t, 1)}
+			for step := 0; step < 300; step++ {
+				r := replicas[rnd.Intn(len(replicas))]
+				switch n := rnd.Intn(20); {
+				case n < 8:
+					randomEdit(t, rnd, r)
+				case n < 12:
+					r.flush(t, e.manager)
+				case n < 16:
+					r.handle(t)
+				case n < 17:
+					e.notes.edit(func(s string) string { return randomLineEdit(rnd, s) })
+				default:
+					e.manager.Compact()
+				}
+				// Keep the hub from dropping replicas that have not
+				// handled their messages yet.
+				for _, r := range replicas {
+					r.receive()
+				}
+			}
+
+			for _, r := range replicas {
+				r.flush(t, e.manager)
+			}
+			e.manager.Compact()
+			for _, r := range replicas {
+				r.handle(t)
+				require.Equal(t, e.notes.content(), r.doc.Text())
+			}
+		})
+	}
+}
+
+func randomEdit(t *testing.T, rnd *rand.Rand, r *replica) {
+	t.Helper()
+
+	if r.doc.Len() > 0 && rnd.Intn(3) == 0 {
+		pos := rnd.Intn(r.doc.Len())
+		ops, err := r.doc.Delete(pos, 1+rnd.Intn(min(4, r.doc.Len()-pos)))
+		require.NoError(t, err)
+		r.outbox = append(r.outbox, ops...)
+		return
+	}
+	texts := []string{"a", "bc", "\n", "word ", "ü", "line\n"}
+	r.insert(t, rnd.Intn(r.doc.Len()+1), texts[rnd.Intn(len(texts))])
+}
+
+func randomLineEdit(rnd *rand.Rand, s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	i := rnd.Intn(len(lines))
+	switch rnd.Intn(3) {
+	case 0:
+		lines = append(lines[:i], append([]string{"rest\n"}, lines[i:]...)...)
+	case 1:
+		lines = append(lines[:i], lines[i+1:]...)
+	default:
+		lines[i] = "changed " + lines[i]
+	}
+	return strings.Join(lines, "")
+}
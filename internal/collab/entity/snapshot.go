@@ -0,0 +1,15 @@
+package entity
+
+import (
+	"gonotes/internal/lib/crdt"
+	"time"
+)
+
+// Snapshot is the saved editing state of a note. Version is the version of
+// the note whose content is the state's text.
+type Snapshot struct {
+	NoteID    int
+	State     crdt.State
+	Version   int
+	UpdatedAt time.Time
+}
@@ -0,0 +1,70 @@
+package collabsqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gonotes/internal/collab/entity"
+	"gonotes/internal/collab/storage"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Load(noteID int) (*entity.Snapshot, error) {
+	const op = "collab.sqlite.Load"
+
+	var (
+		snap  entity.Snapshot
+		state string
+	)
+	err := s.db.QueryRow("SELECT note_id, state, version, updated_at FROM note_crdt WHERE note_id = ?", noteID).
+		Scan(&snap.NoteID, &state, &snap.Version, &snap.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrSnapshotNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = json.Unmarshal([]byte(state), &snap.State); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &snap, nil
+}
+
+func (s *Store) Save(snap *entity.Snapshot) error {
+	const op = "collab.sqlite.Save"
+
+	state, err := json.Marshal(snap.State)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = s.db.Exec(`INSERT INTO note_crdt (note_id, state, version, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (note_id) DO UPDATE SET state = excluded.state, version = excluded.version, updated_at = excluded.updated_at`,
+		snap.NoteID, string(state), snap.Version, snap.UpdatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (s *Store) CanEdit(noteID int, userID int) (bool, error) {
+	const op = "collab.sqlite.CanEdit"
+
+	var ok bool
+	err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM notes WHERE id = ? AND user_id = ?)
+		OR EXISTS (SELECT 1 FROM note_shares WHERE note_id = ? AND user_id = ? AND permission = 'edit')`,
+		noteID, userID, noteID, userID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return ok, nil
+}
@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/collab/entity"
+)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+type Store interface {
+	Load(noteID int) (*entity.Snapshot, error)
+	// Save replaces the note's snapshot.
+	Save(s *entity.Snapshot) error
+	// CanEdit reports whether the user owns the note or it is shared with
+	// them for editing.
+	CanEdit(noteID int, userID int) (bool, error)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -13,10 +14,18 @@ const (
 )
 
 type Config struct {
-	Env         string     `yaml:"env" env-default:"local"`
-	SecretKey   string     `yaml:"secret_key" env-required:"true"`
-	StoragePath string     `yaml:"storage_path" env-required:"true"`
-	HTTPServer  HTTPServer `yaml:"http_server"`
+	Env         string      `yaml:"env" env-default:"local"`
+	SecretKey   string      `yaml:"secret_key" env-required:"true"`
+	StoragePath string      `yaml:"storage_path" env-required:"true"`
+	HTTPServer  HTTPServer  `yaml:"http_server"`
+	Trash       Trash       `yaml:"trash"`
+	Attachments Attachments `yaml:"attachments"`
+	Import      Import      `yaml:"import"`
+	Reminders   Reminders   `yaml:"reminders"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	Events      Events      `yaml:"events"`
+	WebSocket   WebSocket   `yaml:"websocket"`
+	Collab      Collab      `yaml:"collab"`
 }
 
 type HTTPServer struct {
@@ -27,6 +36,78 @@ type HTTPServer struct {
 	Password    string        `yaml:"password" env-required:"true"`
 }
 
+// Trash controls how long deleted notes are kept before they are purged.
+type Trash struct {
+	Retention     time.Duration `yaml:"retention" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+// Attachments controls where uploaded files are kept. An empty Dir places them
+// in an attachments directory next to the database.
+type Attachments struct {
+	Dir     string `yaml:"dir"`
+	MaxSize int64  `yaml:"max_size" env-default:"26214400"`
+}
+
+// Import limits uploaded archives and controls how long finished import jobs
+// can still be looked up.
+type Import struct {
+	MaxSize      int64         `yaml:"max_size" env-default:"104857600"`
+	JobRetention time.Duration `yaml:"job_retention" env-default:"24h"`
+}
+
+// Reminders controls the scheduler that fires due reminders. Notifier picks
+// the delivery channel: log, webhook or email.
+type Reminders struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"30s"`
+	Lease        time.Duration `yaml:"lease" env-default:"5m"`
+	Notifier     string        `yaml:"notifier" env-default:"log"`
+	Webhook      Webhook       `yaml:"webhook"`
+	SMTP         SMTP          `yaml:"smtp"`
+}
+
+type Webhook struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+}
+
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port" env-default:"587"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// Webhooks controls the worker that delivers note events to user-registered
+// endpoints. Finished deliveries are kept for Retention so they show up in
+// the delivery log.
+type Webhooks struct {
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"10s"`
+	Lease        time.Duration `yaml:"lease" env-default:"5m"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	Retention    time.Duration `yaml:"retention" env-default:"168h"`
+}
+
+// Events controls the change feed at /events. Events stay in the log for
+// Retention, which is how long a client can be away and still resume.
+type Events struct {
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+	Retention time.Duration `yaml:"retention" env-default:"24h"`
+}
+
+// WebSocket controls connections to /ws. Each is pinged every PingInterval and
+// closed when it misses two.
+type WebSocket struct {
+	PingInterval time.Duration `yaml:"ping_interval" env-default:"30s"`
+}
+
+// Collab controls editing sessions. The text of each session is written back
+// to its note every CompactInterval.
+type Collab struct {
+	CompactInterval time.Duration `yaml:"compact_interval" env-default:"10s"`
+}
+
 func MustLoadConfig() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -39,5 +120,8 @@ func MustLoadConfig() *Config {
 	if err != nil {
 		log.Fatalf("Failed to read config file: %s", configPath)
 	}
+	if cfg.Attachments.Dir == "" {
+		cfg.Attachments.Dir = filepath.Join(filepath.Dir(cfg.StoragePath), "attachments")
+	}
 	return &cfg
 }
@@ -0,0 +1,95 @@
+// Package events carries note changes from the handlers that make them to
+// the features that react to them, such as webhooks.
+package events
+
+import (
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/entity"
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	NoteCreated Type = "note.created"
+	NoteUpdated Type = "note.updated"
+	// NoteDeleted is published when a note is moved to the trash.
+	NoteDeleted Type = "note.deleted"
+)
+
+// Types lists every event type, in the order they are documented.
+var Types = []Type{NoteCreated, NoteUpdated, NoteDeleted}
+
+func (t Type) Valid() bool {
+	switch t {
+	case NoteCreated, NoteUpdated, NoteDeleted:
+		return true
+	}
+	return false
+}
+
+// Event is a change to a note. Note is the note as it was stored by the
+// change; its UserID is the owner, while UserID is the user who made the
+// change.
+type Event struct {
+	Type       Type
+	UserID     int
+	Note       *entity.Note
+	OccurredAt time.Time
+}
+
+// NewNoteEvent stamps an event for a change the user made to n.
+func NewNoteEvent(t Type, userID int, n *entity.Note) Event {
+	return Event{Type: t, UserID: userID, Note: n, OccurredAt: time.Now().UTC()}
+}
+
+// Payload is how an event is sent to clients, as the body of a webhook or the
+// data of a feed message.
+type Payload struct {
+	Event      Type              `json:"event"`
+	OccurredAt time.Time         `json:"occurred_at"`
+	UserID     int               `json:"user_id"`
+	OwnerID    int               `json:"owner_id"`
+	Note       *dto.NoteResponse `json:"note"`
+}
+
+func NewPayload(ev Event) *Payload {
+	return &Payload{
+		Event:      ev.Type,
+		OccurredAt: ev.OccurredAt,
+		UserID:     ev.UserID,
+		OwnerID:    ev.Note.UserID,
+		Note:       dto.NewNoteResponse(ev.Note),
+	}
+}
+
+type Publisher interface {
+	Publish(ev Event)
+}
+
+// Bus hands every published event to each subscriber in turn, on the
+// publishing goroutine. Subscribers must return quickly; slow work belongs
+// in a queue of their own.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, fn)
+}
+
+func (b *Bus) Publish(ev Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subs {
+		fn(ev)
+	}
+}
@@ -0,0 +1,27 @@
+package events
+
+import (
+	"gonotes/internal/notes/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bus(t *testing.T) {
+	bus := NewBus()
+	bus.Publish(NewNoteEvent(NoteCreated, 1, &entity.Note{ID: 1}))
+
+	var got []string
+	bus.Subscribe(func(ev Event) { got = append(got, "first "+string(ev.Type)) })
+	bus.Subscribe(func(ev Event) { got = append(got, "second "+string(ev.Type)) })
+	bus.Publish(NewNoteEvent(NoteDeleted, 1, &entity.Note{ID: 1}))
+
+	assert.Equal(t, []string{"first note.deleted", "second note.deleted"}, got)
+}
+
+func Test_TypeValid(t *testing.T) {
+	for _, typ := range Types {
+		assert.True(t, typ.Valid())
+	}
+	assert.False(t, Type("note.viewed").Valid())
+}
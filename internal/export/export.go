@@ -0,0 +1,252 @@
+// Package export writes a user's notes as a zip of Markdown files. Notes are
+// read page by page and written straight into the archive, so neither the
+// notes nor the archive are held in memory.
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	nbentity "gonotes/internal/notebooks/entity"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"io"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ManifestName    = "manifest.json"
+	ManifestVersion = 1
+
+	pageSize      = 200
+	maxNameLength = 100
+)
+
+type NoteLister interface {
+	List(userID int, q storage.ListQuery) (*storage.NotePage, error)
+}
+
+// FrontMatter is the YAML header at the top of every exported note.
+type FrontMatter struct {
+	ID        int       `yaml:"id"`
+	Title     string    `yaml:"title"`
+	CreatedAt time.Time `yaml:"created_at"`
+	UpdatedAt time.Time `yaml:"updated_at"`
+	Tags      []string  `yaml:"tags"`
+	Format    string    `yaml:"format"`
+	Pinned    bool      `yaml:"pinned,omitempty"`
+	Archived  bool      `yaml:"archived,omitempty"`
+	Favourite bool      `yaml:"favourite,omitempty"`
+}
+
+// Manifest lists the archive's contents; it is written last.
+type Manifest struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Notebooks  []ManifestNotebook `json:"notebooks"`
+	Notes      []ManifestNote     `json:"notes"`
+}
+
+type ManifestNotebook struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+	Path     string `json:"path"`
+}
+
+type ManifestNote struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	Path       string    `json:"path"`
+	NotebookID *int      `json:"notebook_id"`
+	Tags       []string  `json:"tags"`
+	Format     string    `json:"format"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Write streams the user's notes, including archived ones, into a zip on w.
+// Notes filed in a notebook are placed in folders mirroring the notebook
+// tree.
+func Write(w io.Writer, userID int, notes NoteLister, notebooks []nbentity.Notebook, now time.Time) error {
+	const op = "export.Write"
+
+	zw := zip.NewWriter(w)
+
+	dirs := notebookDirs(notebooks)
+	manifest := Manifest{Version: ManifestVersion, ExportedAt: now.UTC(), Notebooks: []ManifestNotebook{}, Notes: []ManifestNote{}}
+	for _, nb := range notebooks {
+		manifest.Notebooks = append(manifest.Notebooks, ManifestNotebook{ID: nb.ID, Name: nb.Name, ParentID: nb.ParentID, Path: dirs[nb.ID]})
+	}
+
+	used := map[string]bool{}
+	q := storage.ListQuery{Limit: pageSize, Sort: storage.SortCreatedAt, IncludeArchived: true}
+	for {
+		page, err := notes.List(userID, q)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		for i := range page.Notes {
+			n := &page.Notes[i]
+
+			dir := ""
+			if n.NotebookID != nil {
+				dir = dirs[*n.NotebookID]
+			}
+			name := uniquePath(used, dir, sanitizeName(n.Title), fmt.Sprintf(" (%d)", n.ID), ".md")
+
+			if err := writeNote(zw, name, n); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+
+			manifest.Notes = append(manifest.Notes, ManifestNote{
+				ID:         n.ID,
+				Title:      n.Title,
+				Path:       name,
+				NotebookID: n.NotebookID,
+				Tags:       nonNil(n.Tags),
+				Format:     string(n.Format),
+				CreatedAt:  n.CreatedAt,
+				UpdatedAt:  n.UpdatedAt,
+			})
+		}
+
+		if page.Next == nil {
+			break
+		}
+		q.Cursor = page.Next
+	}
+
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: ManifestName, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	enc := json.NewEncoder(mw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(manifest); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func writeNote(zw *zip.Writer, name string, n *entity.Note) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: n.UpdatedAt})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err = enc.Encode(FrontMatter{
+		ID:        n.ID,
+		Title:     n.Title,
+		CreatedAt: n.CreatedAt,
+		UpdatedAt: n.UpdatedAt,
+		Tags:      nonNil(n.Tags),
+		Format:    string(n.Format),
+		Pinned:    n.Pinned,
+		Archived:  n.Archived,
+		Favourite: n.Favourite,
+	})
+	if err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	buf.WriteString("---\n")
+
+	if _, err := fw.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err = io.WriteString(fw, n.Content)
+	return err
+}
+
+// notebookDirs maps every notebook to its folder path in the archive.
+func notebookDirs(notebooks []nbentity.Notebook) map[int]string {
+	byID := make(map[int]*nbentity.Notebook, len(notebooks))
+	for i := range notebooks {
+		byID[notebooks[i].ID] = &notebooks[i]
+	}
+
+	dirs := make(map[int]string, len(notebooks))
+	used := map[string]bool{}
+
+	var resolve func(nb *nbentity.Notebook) string
+	resolve = func(nb *nbentity.Notebook) string {
+		if dir, ok := dirs[nb.ID]; ok {
+			return dir
+		}
+		parent := ""
+		if nb.ParentID != nil {
+			if p, ok := byID[*nb.ParentID]; ok {
+				parent = resolve(p)
+			}
+		}
+		dir := uniquePath(used, parent, sanitizeName(nb.Name), fmt.Sprintf(" (%d)", nb.ID), "")
+		dirs[nb.ID] = dir
+		return dir
+	}
+
+	for i := range notebooks {
+		resolve(&notebooks[i])
+	}
+	return dirs
+}
+
+// uniquePath joins dir and name+ext, adding suffix before ext when the path
+// is already taken. Paths are compared case-insensitively so archives
+// extract cleanly on case-insensitive file systems.
+func uniquePath(used map[string]bool, dir, name, suffix, ext string) string {
+	p := path.Join(dir, name+ext)
+	if used[strings.ToLower(p)] {
+		p = path.Join(dir, name+suffix+ext)
+	}
+	used[strings.ToLower(p)] = true
+	return p
+}
+
+// sanitizeName turns a title into a file name that is valid on common file
+// systems.
+func sanitizeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r), unicode.IsControl(r):
+			b.WriteRune('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	name := strings.Trim(strings.TrimSpace(b.String()), ".")
+	if utf8.RuneCountInString(name) > maxNameLength {
+		name = strings.TrimSpace(string([]rune(name)[:maxNameLength]))
+	}
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+func nonNil(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	nbentity "gonotes/internal/notebooks/entity"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// pagedNotes serves notes one per page to exercise cursor handling.
+type pagedNotes struct {
+	notes []entity.Note
+	err   error
+}
+
+func (p *pagedNotes) List(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	i := 0
+	if q.Cursor != nil {
+		i = q.Cursor.ID
+	}
+	page := &storage.NotePage{Notes: p.notes[i : i+1]}
+	if i+1 < len(p.notes) {
+		page.Next = &storage.Cursor{ID: i + 1}
+	}
+	return page, nil
+}
+
+func intPtr(i int) *int { return &i }
+
+func Test_Write(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	notes := &pagedNotes{notes: []entity.Note{
+		{ID: 1, Title: "Plan", Content: "# Plan\n", Format: entity.FormatMarkdown, Tags: []string{"work"}, CreatedAt: created, UpdatedAt: created},
+		{ID: 2, Title: "Plan", Content: "dup", Format: entity.FormatPlain, CreatedAt: created, UpdatedAt: created},
+		{ID: 3, Title: "a/b: c?", Content: "nested", NotebookID: intPtr(11), Pinned: true, CreatedAt: created, UpdatedAt: created},
+		{ID: 4, Title: "", Content: "untitled", NotebookID: intPtr(10), CreatedAt: created, UpdatedAt: created},
+	}}
+	notebooks := []nbentity.Notebook{
+		{ID: 11, Name: "Trips", ParentID: intPtr(10)},
+		{ID: 10, Name: "Personal"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, 1, notes, notebooks, created))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	var names []string
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(b)
+		names = append(names, f.Name)
+	}
+
+	assert.Equal(t, []string{
+		"Plan.md",
+		"Plan (2).md",
+		"Personal/Trips/a-b- c-.md",
+		"Personal/Untitled.md",
+		ManifestName,
+	}, names)
+
+	parts := strings.SplitN(files["Plan.md"], "---\n", 3)
+	require.Len(t, parts, 3)
+	assert.Equal(t, "# Plan\n", parts[2])
+
+	var fm FrontMatter
+	require.NoError(t, yaml.Unmarshal([]byte(parts[1]), &fm))
+	assert.Equal(t, FrontMatter{ID: 1, Title: "Plan", CreatedAt: created, UpdatedAt: created, Tags: []string{"work"}, Format: "markdown"}, fm)
+
+	var m Manifest
+	require.NoError(t, json.Unmarshal([]byte(files[ManifestName]), &m))
+	assert.Equal(t, ManifestVersion, m.Version)
+	require.Len(t, m.Notes, 4)
+	assert.Equal(t, "Personal/Trips/a-b- c-.md", m.Notes[2].Path)
+	assert.Equal(t, []string{}, m.Notes[1].Tags)
+	assert.Equal(t, "Personal/Trips", m.Notebooks[0].Path)
+}
+
+func Test_WriteListError(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, 1, &pagedNotes{err: errors.New("db error")}, nil, time.Now())
+	assert.Error(t, err)
+	assert.Zero(t, buf.Len())
+}
+
+func Test_sanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"Plain":                  "Plain",
+		"  ..hidden..  ":         "hidden",
+		"what? <now>":            "what- -now-",
+		"":                       "Untitled",
+		"tab\there":              "tab-here",
+		strings.Repeat("é", 120): strings.Repeat("é", maxNameLength),
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, sanitizeName(in), in)
+	}
+}
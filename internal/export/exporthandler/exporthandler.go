@@ -0,0 +1,88 @@
+package exporthandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/export"
+	"gonotes/internal/middleware"
+	nbstorage "gonotes/internal/notebooks/storage"
+	notesstorage "gonotes/internal/notes/storage"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log       *slog.Logger
+	notes     notesstorage.NoteRepository
+	notebooks nbstorage.NotebookRepository
+	now       func() time.Time
+}
+
+func NewHandler(log *slog.Logger, notes notesstorage.NoteRepository, notebooks nbstorage.NotebookRepository) *Handler {
+	return &Handler{
+		log:       log,
+		notes:     notes,
+		notebooks: notebooks,
+		now:       time.Now,
+	}
+}
+
+// Export streams a zip of all the user's notes. Errors after the first byte
+// has been sent can only be logged; the client sees a truncated archive.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	const op = "export.handler.export"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notebooks, err := h.notebooks.List(userID)
+	if err != nil {
+		log.Error("failed to list notebooks", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	// Large exports take longer than the server's write timeout.
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear write deadline", slog.Any("err", err))
+	}
+
+	now := h.now().UTC()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gonotes-export-%s.zip"`, now.Format(time.DateOnly)))
+
+	cw := &countingWriter{w: w}
+	if err := export.Write(cw, userID, h.notes, notebooks, now); err != nil {
+		if cw.n == 0 {
+			log.Error("failed to export notes", slog.Any("err", err))
+			w.Header().Del("Content-Disposition")
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+			return
+		}
+		log.Error("export aborted", slog.Any("err", err), slog.Int64("bytes", cw.n))
+		return
+	}
+
+	log.Info("notes exported", slog.Int64("bytes", cw.n), slog.Int("user_id", userID))
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
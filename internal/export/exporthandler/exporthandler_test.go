@@ -0,0 +1,95 @@
+package exporthandler_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"gonotes/internal/export"
+	"gonotes/internal/export/exporthandler"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	nbentity "gonotes/internal/notebooks/entity"
+	nbstorage "gonotes/internal/notebooks/storage"
+	"gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockNotebooksRepo only implements List; other methods panic if called.
+type mockNotebooksRepo struct {
+	nbstorage.NotebookRepository
+	listFunc func(userID int) ([]nbentity.Notebook, error)
+}
+
+func (m *mockNotebooksRepo) List(userID int) ([]nbentity.Notebook, error) {
+	return m.listFunc(userID)
+}
+
+func newRequest(withUser bool) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/export", nil)
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_Export(t *testing.T) {
+	notes := &storagetest.NoteRepository{ListFunc: func(userID int, q notesstorage.ListQuery) (*notesstorage.NotePage, error) {
+		if !q.IncludeArchived {
+			return nil, errors.New("archived notes must be exported")
+		}
+		return &notesstorage.NotePage{Notes: []entity.Note{{ID: 1, Title: "One", Content: "body"}}}, nil
+	}}
+	notebooks := &mockNotebooksRepo{listFunc: func(userID int) ([]nbentity.Notebook, error) { return nil, nil }}
+	h := exporthandler.NewHandler(slogdiscard.NewDiscardLogger(), notes, notebooks)
+
+	rr := httptest.NewRecorder()
+	h.Export(rr, newRequest(true))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/zip", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+
+	zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 2)
+	assert.Equal(t, "One.md", zr.File[0].Name)
+	assert.Equal(t, export.ManifestName, zr.File[1].Name)
+}
+
+func Test_ExportErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		withUser       bool
+		notesErr       error
+		notebooksErr   error
+		expectedStatus int
+	}{
+		{name: "unauthorized", expectedStatus: http.StatusUnauthorized},
+		{name: "notebooks error", withUser: true, notebooksErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+		{name: "notes error", withUser: true, notesErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := &storagetest.NoteRepository{ListFunc: func(userID int, q notesstorage.ListQuery) (*notesstorage.NotePage, error) {
+				return nil, tt.notesErr
+			}}
+			notebooks := &mockNotebooksRepo{listFunc: func(userID int) ([]nbentity.Notebook, error) { return nil, tt.notebooksErr }}
+			h := exporthandler.NewHandler(slogdiscard.NewDiscardLogger(), notes, notebooks)
+
+			rr := httptest.NewRecorder()
+			h.Export(rr, newRequest(tt.withUser))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Empty(t, rr.Header().Get("Content-Disposition"))
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"gonotes/internal/events"
+	"time"
+)
+
+// Event is a note event as recorded for one user who can see the note. IDs
+// increase across all users, so a client resumes its stream from the last ID
+// it saw.
+type Event struct {
+	ID        int
+	UserID    int
+	Type      events.Type
+	NoteID    int
+	Payload   []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,183 @@
+// Package feed keeps a log of note events for every user who can see the
+// note and pushes new events to connected clients. Clients that disconnect,
+// or fall too far behind to be kept up to date, resume from the log.
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"gonotes/internal/events"
+	"gonotes/internal/feed/entity"
+	"gonotes/internal/feed/storage"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// subscriptionBuffer is how many events a client may lag behind before it
+	// is dropped.
+	subscriptionBuffer = 64
+	pruneInterval      = time.Hour
+)
+
+// Subscription receives a user's events as they are recorded.
+type Subscription struct {
+	userID int
+	events chan entity.Event
+}
+
+// Events is closed when the hub drops the subscription, because the client
+// fell behind or the server is shutting down.
+func (s *Subscription) Events() <-chan entity.Event {
+	return s.events
+}
+
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[int]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: map[int]map[*Subscription]struct{}{}}
+}
+
+func (h *Hub) Subscribe(userID int) *Subscription {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := &Subscription{userID: userID, events: make(chan entity.Event, subscriptionBuffer)}
+	if h.closed {
+		close(s.events)
+		return s
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[*Subscription]struct{}{}
+	}
+	h.subs[userID][s] = struct{}{}
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(s)
+}
+
+// Broadcast hands each event to its user's subscriptions without blocking.
+func (h *Hub) Broadcast(evs []entity.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ev := range evs {
+		for s := range h.subs[ev.UserID] {
+			select {
+			case s.events <- ev:
+			default:
+				h.drop(s)
+			}
+		}
+	}
+}
+
+// Close ends every subscription and refuses new ones. It is meant to run when
+// the server shuts down, since open streams would otherwise hold it up.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subs {
+		for s := range subs {
+			h.drop(s)
+		}
+	}
+}
+
+// drop removes and closes a subscription; h.mu must be held.
+func (h *Hub) drop(s *Subscription) {
+	subs := h.subs[s.userID]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(h.subs, s.userID)
+	}
+	close(s.events)
+}
+
+type Feed struct {
+	log       *slog.Logger
+	store     storage.EventLog
+	hub       *Hub
+	retention time.Duration
+	// mu keeps events reaching the hub in the order the log numbered them.
+	mu  sync.Mutex
+	now func() time.Time
+}
+
+// New returns a feed that records events in store, pushes them through hub
+// and forgets them after retention.
+func New(log *slog.Logger, store storage.EventLog, hub *Hub, retention time.Duration) *Feed {
+	return &Feed{
+		log:       log,
+		store:     store,
+		hub:       hub,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Handle records ev for everyone who can see the note and pushes it to their
+// open streams. It is meant to be subscribed to an events.Bus.
+func (f *Feed) Handle(ev events.Event) {
+	const op = "feed.handle"
+	log := f.log.With(slog.String("op", op), slog.String("event", string(ev.Type)), slog.Int("note_id", ev.Note.ID))
+
+	payload, err := json.Marshal(events.NewPayload(ev))
+	if err != nil {
+		log.Error("failed to encode payload", slog.Any("err", err))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	recorded, err := f.store.Append(ev.Type, ev.Note.ID, payload, ev.OccurredAt)
+	if err != nil {
+		log.Error("failed to record event", slog.Any("err", err))
+		return
+	}
+	f.hub.Broadcast(recorded)
+}
+
+// Run prunes the log straight away and then every hour until ctx is done.
+func (f *Feed) Run(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		f.prune()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (f *Feed) prune() {
+	const op = "feed.prune"
+
+	n, err := f.store.Prune(f.now().Add(-f.retention))
+	if err != nil {
+		f.log.Error("failed to prune events", slog.String("op", op), slog.Any("err", err))
+		return
+	}
+	if n > 0 {
+		f.log.Info("events pruned", slog.String("op", op), slog.Int("count", n))
+	}
+}
@@ -0,0 +1,135 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/feed/entity"
+	"gonotes/internal/lib/logger/slogdiscard"
+	notesentity "gonotes/internal/notes/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLog struct {
+	nextID     int
+	recipients []int
+	appended   []entity.Event
+	err        error
+	prunedAt   time.Time
+}
+
+func (m *mockLog) Append(t events.Type, noteID int, payload []byte, at time.Time) ([]entity.Event, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	var evs []entity.Event
+	for _, userID := range m.recipients {
+		m.nextID++
+		evs = append(evs, entity.Event{ID: m.nextID, UserID: userID, Type: t, NoteID: noteID, Payload: payload, CreatedAt: at})
+	}
+	m.appended = append(m.appended, evs...)
+	return evs, nil
+}
+
+func (m *mockLog) Since(userID int, afterID int, limit int) ([]entity.Event, error) {
+	return nil, nil
+}
+
+func (m *mockLog) FirstID() (int, error) {
+	return 1, nil
+}
+
+func (m *mockLog) Prune(before time.Time) (int, error) {
+	m.prunedAt = before
+	return 0, nil
+}
+
+func Test_HubBroadcast(t *testing.T) {
+	hub := NewHub()
+	a := hub.Subscribe(1)
+	b := hub.Subscribe(2)
+
+	hub.Broadcast([]entity.Event{{ID: 1, UserID: 1}, {ID: 2, UserID: 2}, {ID: 3, UserID: 1}})
+
+	assert.Equal(t, 1, (<-a.Events()).ID)
+	assert.Equal(t, 3, (<-a.Events()).ID)
+	assert.Equal(t, 2, (<-b.Events()).ID)
+	assert.Empty(t, a.Events())
+
+	hub.Unsubscribe(a)
+	hub.Unsubscribe(a)
+	_, ok := <-a.Events()
+	assert.False(t, ok, "unsubscribing closes the channel once")
+}
+
+func Test_HubDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(1)
+
+	for i := range subscriptionBuffer + 1 {
+		hub.Broadcast([]entity.Event{{ID: i + 1, UserID: 1}})
+	}
+
+	n := 0
+	for range slow.Events() {
+		n++
+	}
+	assert.Equal(t, subscriptionBuffer, n, "the channel is closed after the buffered events")
+	hub.Unsubscribe(slow)
+}
+
+func Test_HubClose(t *testing.T) {
+	hub := NewHub()
+	open := hub.Subscribe(1)
+	hub.Close()
+
+	_, ok := <-open.Events()
+	assert.False(t, ok)
+
+	late := hub.Subscribe(1)
+	_, ok = <-late.Events()
+	assert.False(t, ok, "no subscriptions after close")
+}
+
+func Test_Handle(t *testing.T) {
+	store := &mockLog{recipients: []int{1, 2}}
+	hub := NewHub()
+	owner := hub.Subscribe(1)
+	sharee := hub.Subscribe(2)
+	f := New(slogdiscard.NewDiscardLogger(), store, hub, time.Hour)
+
+	note := &notesentity.Note{ID: 7, UserID: 1, Title: "Plan"}
+	f.Handle(events.NewNoteEvent(events.NoteUpdated, 2, note))
+
+	require.Len(t, store.appended, 2)
+	ev := <-owner.Events()
+	assert.Equal(t, events.NoteUpdated, ev.Type)
+	assert.Equal(t, 7, ev.NoteID)
+
+	var p events.Payload
+	require.NoError(t, json.Unmarshal(ev.Payload, &p))
+	assert.Equal(t, 2, p.UserID)
+	assert.Equal(t, 1, p.OwnerID)
+	assert.Equal(t, "Plan", p.Note.Title)
+
+	assert.Equal(t, 2, (<-sharee.Events()).ID)
+
+	store.err = errors.New("db error")
+	f.Handle(events.NewNoteEvent(events.NoteDeleted, 1, note))
+	assert.Empty(t, owner.Events())
+}
+
+func Test_prune(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := &mockLog{}
+	f := New(slogdiscard.NewDiscardLogger(), store, NewHub(), 24*time.Hour)
+	f.now = func() time.Time { return now }
+
+	f.prune()
+
+	assert.Equal(t, now.Add(-24*time.Hour), store.prunedAt)
+}
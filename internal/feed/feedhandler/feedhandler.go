@@ -0,0 +1,165 @@
+package feedhandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/feed"
+	"gonotes/internal/feed/entity"
+	"gonotes/internal/feed/storage"
+	"gonotes/internal/middleware"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	// retryAfter tells clients how long to wait before reconnecting.
+	retryAfter  = 3 * time.Second
+	replayBatch = 500
+)
+
+type Handler struct {
+	log       *slog.Logger
+	store     storage.EventLog
+	hub       *feed.Hub
+	heartbeat time.Duration
+}
+
+// NewHandler returns a handler that writes a comment to idle streams every
+// heartbeat so proxies do not close them.
+func NewHandler(log *slog.Logger, store storage.EventLog, hub *feed.Hub, heartbeat time.Duration) *Handler {
+	return &Handler{
+		log:       log,
+		store:     store,
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream sends the user's note events as Server-Sent Events until the client
+// goes away. With a Last-Event-ID header the events recorded after that ID
+// are replayed first. A reset event means some of them are no longer in the
+// log and the client should reload its notes.
+func (h *Handler) Stream(w http.ResponseWriter, r *http.Request) {
+	const op = "feed.handler.stream"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	lastID := 0
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 0 {
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID")))
+			return
+		}
+		lastID = id
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout.
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear write deadline", slog.Any("err", err))
+	}
+
+	// Subscribe before replaying so nothing recorded in between is missed;
+	// events already replayed are skipped when they arrive live.
+	sub := h.hub.Subscribe(userID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryAfter.Milliseconds())
+
+	if lastID > 0 {
+		var err error
+		lastID, err = h.replay(w, userID, lastID)
+		if err != nil {
+			log.Error("failed to replay events", slog.Any("err", err))
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Error("failed to flush stream", slog.Any("err", err))
+		return
+	}
+
+	log.Info("stream opened", slog.Int("user_id", userID), slog.Int("last_event_id", lastID))
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Info("stream closed", slog.Int("user_id", userID))
+			return
+		case ev, ok := <-sub.Events():
+			if !ok {
+				log.Info("stream dropped", slog.Int("user_id", userID))
+				return
+			}
+			if ev.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(w, &ev); err != nil {
+				return
+			}
+			lastID = ev.ID
+		case <-ticker.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// replay writes the user's events after lastID and returns the ID of the
+// last one written.
+func (h *Handler) replay(w io.Writer, userID int, lastID int) (int, error) {
+	first, err := h.store.FirstID()
+	if err != nil {
+		return lastID, err
+	}
+	if lastID+1 < first {
+		if _, err := io.WriteString(w, "event: reset\ndata: {}\n\n"); err != nil {
+			return lastID, err
+		}
+	}
+
+	for {
+		evs, err := h.store.Since(userID, lastID, replayBatch)
+		if err != nil {
+			return lastID, err
+		}
+		for i := range evs {
+			if err := writeEvent(w, &evs[i]); err != nil {
+				return lastID, err
+			}
+			lastID = evs[i].ID
+		}
+		if len(evs) < replayBatch {
+			return lastID, nil
+		}
+	}
+}
+
+func writeEvent(w io.Writer, ev *entity.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, ev.Payload)
+	return err
+}
@@ -0,0 +1,165 @@
+package feedhandler_test
+
+import (
+	"bufio"
+	"context"
+	"gonotes/internal/events"
+	"gonotes/internal/feed"
+	"gonotes/internal/feed/entity"
+	"gonotes/internal/feed/feedhandler"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLog struct {
+	events  []entity.Event
+	firstID int
+}
+
+func (m *mockLog) Append(events.Type, int, []byte, time.Time) ([]entity.Event, error) {
+	return nil, nil
+}
+
+func (m *mockLog) Since(userID int, afterID int, limit int) ([]entity.Event, error) {
+	var list []entity.Event
+	for _, ev := range m.events {
+		if ev.UserID == userID && ev.ID > afterID && len(list) < limit {
+			list = append(list, ev)
+		}
+	}
+	return list, nil
+}
+
+func (m *mockLog) FirstID() (int, error) {
+	return m.firstID, nil
+}
+
+func (m *mockLog) Prune(time.Time) (int, error) {
+	return 0, nil
+}
+
+func event(id, userID int, t events.Type) entity.Event {
+	return entity.Event{ID: id, UserID: userID, Type: t, Payload: []byte(`{"event":"` + string(t) + `"}`)}
+}
+
+// startStream serves the handler as user 1 and returns a reader positioned
+// after the retry line.
+func startStream(t *testing.T, h *feedhandler.Handler, lastEventID string) (*bufio.Reader, func()) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Stream(w, r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, 1)))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(resp.Body)
+	assert.Equal(t, "retry: 3000", readMessage(t, body))
+
+	return body, func() {
+		cancel()
+		resp.Body.Close()
+		srv.Close()
+	}
+}
+
+// readMessage reads up to the blank line that ends a message and returns its
+// lines joined by "|".
+func readMessage(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "|")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func Test_StreamReplaysAndFollows(t *testing.T) {
+	store := &mockLog{firstID: 1, events: []entity.Event{
+		event(1, 1, events.NoteCreated),
+		event(2, 2, events.NoteCreated),
+		event(3, 1, events.NoteUpdated),
+		event(4, 1, events.NoteDeleted),
+	}}
+	hub := feed.NewHub()
+	h := feedhandler.NewHandler(slogdiscard.NewDiscardLogger(), store, hub, time.Hour)
+
+	body, stop := startStream(t, h, "1")
+	defer stop()
+
+	assert.Equal(t, `id: 3|event: note.updated|data: {"event":"note.updated"}`, readMessage(t, body))
+	assert.Equal(t, `id: 4|event: note.deleted|data: {"event":"note.deleted"}`, readMessage(t, body))
+
+	// Already replayed events that also arrive live are not sent twice.
+	hub.Broadcast([]entity.Event{event(4, 1, events.NoteDeleted), event(5, 2, events.NoteUpdated), event(6, 1, events.NoteCreated)})
+	assert.Equal(t, `id: 6|event: note.created|data: {"event":"note.created"}`, readMessage(t, body))
+}
+
+func Test_StreamResetsAfterPrunedEvents(t *testing.T) {
+	store := &mockLog{firstID: 10, events: []entity.Event{event(10, 1, events.NoteUpdated)}}
+	h := feedhandler.NewHandler(slogdiscard.NewDiscardLogger(), store, feed.NewHub(), time.Hour)
+
+	body, stop := startStream(t, h, "4")
+	defer stop()
+
+	assert.Equal(t, "event: reset|data: {}", readMessage(t, body))
+	assert.Equal(t, `id: 10|event: note.updated|data: {"event":"note.updated"}`, readMessage(t, body))
+}
+
+func Test_StreamHeartbeat(t *testing.T) {
+	h := feedhandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLog{firstID: 1}, feed.NewHub(), 10*time.Millisecond)
+
+	body, stop := startStream(t, h, "")
+	defer stop()
+
+	assert.Equal(t, ": ping", readMessage(t, body))
+}
+
+func Test_StreamEndsOnShutdown(t *testing.T) {
+	hub := feed.NewHub()
+	h := feedhandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLog{firstID: 1}, hub, time.Hour)
+
+	body, stop := startStream(t, h, "")
+	defer stop()
+
+	hub.Close()
+	_, err := body.ReadString('\n')
+	assert.Error(t, err, "the server ends the response")
+}
+
+func Test_StreamRejects(t *testing.T) {
+	h := feedhandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLog{}, feed.NewHub(), time.Hour)
+
+	rr := httptest.NewRecorder()
+	h.Stream(rr, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	rr = httptest.NewRecorder()
+	h.Stream(rr, req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
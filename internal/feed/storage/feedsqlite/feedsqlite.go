@@ -0,0 +1,104 @@
+package feedsqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"gonotes/internal/events"
+	"gonotes/internal/feed/entity"
+	"time"
+)
+
+type EventLog struct {
+	db *sql.DB
+}
+
+func NewEventLog(db *sql.DB) *EventLog {
+	return &EventLog{db: db}
+}
+
+func (l *EventLog) Append(t events.Type, noteID int, payload []byte, at time.Time) ([]entity.Event, error) {
+	const op = "feed.sqlite.Append"
+
+	at = at.UTC()
+	rows, err := l.db.Query(`INSERT INTO note_events (user_id, type, note_id, payload, created_at)
+		SELECT user_id, ?, ?, ?, ? FROM (
+			SELECT user_id FROM notes WHERE id = ?
+			UNION SELECT user_id FROM note_shares WHERE note_id = ?
+		)
+		RETURNING id, user_id`, t, noteID, string(payload), at, noteID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	appended := []entity.Event{}
+	for rows.Next() {
+		ev := entity.Event{Type: t, NoteID: noteID, Payload: payload, CreatedAt: at}
+		if err := rows.Scan(&ev.ID, &ev.UserID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		appended = append(appended, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return appended, nil
+}
+
+func (l *EventLog) Since(userID int, afterID int, limit int) ([]entity.Event, error) {
+	const op = "feed.sqlite.Since"
+
+	rows, err := l.db.Query("SELECT id, user_id, type, note_id, payload, created_at FROM note_events WHERE user_id = ? AND id > ? ORDER BY id LIMIT ?",
+		userID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	list := []entity.Event{}
+	for rows.Next() {
+		var (
+			ev      entity.Event
+			payload string
+		)
+		if err := rows.Scan(&ev.ID, &ev.UserID, &ev.Type, &ev.NoteID, &payload, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ev.Payload = []byte(payload)
+		list = append(list, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return list, nil
+}
+
+func (l *EventLog) FirstID() (int, error) {
+	const op = "feed.sqlite.FirstID"
+
+	// With an empty log the next ID comes from the AUTOINCREMENT sequence, which
+	// keeps counting after rows are deleted.
+	var id int
+	err := l.db.QueryRow(`SELECT COALESCE(
+		(SELECT MIN(id) FROM note_events),
+		(SELECT seq + 1 FROM sqlite_sequence WHERE name = 'note_events'),
+		1)`).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return id, nil
+}
+
+func (l *EventLog) Prune(before time.Time) (int, error) {
+	const op = "feed.sqlite.Prune"
+
+	res, err := l.db.Exec("DELETE FROM note_events WHERE created_at < ?", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(n), nil
+}
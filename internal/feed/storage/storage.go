@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"gonotes/internal/events"
+	"gonotes/internal/feed/entity"
+	"time"
+)
+
+type EventLog interface {
+	// Append records payload for the owner of the note and every user it is
+	// shared with, and returns the recorded events.
+	Append(t events.Type, noteID int, payload []byte, at time.Time) ([]entity.Event, error)
+	// Since returns up to limit of the user's events after afterID, oldest
+	// first.
+	Since(userID int, afterID int, limit int) ([]entity.Event, error)
+	// FirstID returns the ID of the oldest event still in the log, or the ID
+	// the next event will get when the log is empty. A client that last saw
+	// an event before FirstID-1 may have missed pruned events.
+	FirstID() (int, error)
+	// Prune deletes events recorded before before.
+	Prune(before time.Time) (int, error)
+}
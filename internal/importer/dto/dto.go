@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"gonotes/internal/importer"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type SkippedItemResponse struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+type JobResponse struct {
+	ID           string                `json:"id"`
+	Format       importer.Format       `json:"format"`
+	FileName     string                `json:"file_name"`
+	Status       importer.Status       `json:"status"`
+	Total        int                   `json:"total"`
+	Processed    int                   `json:"processed"`
+	Imported     int                   `json:"imported"`
+	SkippedCount int                   `json:"skipped_count"`
+	Skipped      []SkippedItemResponse `json:"skipped"`
+	Error        string                `json:"error,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	FinishedAt   *time.Time            `json:"finished_at"`
+}
+
+func (jr *JobResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewJobResponse(s importer.JobState) *JobResponse {
+	skipped := make([]SkippedItemResponse, len(s.Skipped))
+	for i, item := range s.Skipped {
+		skipped[i] = SkippedItemResponse{Source: item.Source, Reason: item.Reason}
+	}
+	return &JobResponse{
+		ID:           s.ID,
+		Format:       s.Format,
+		FileName:     s.FileName,
+		Status:       s.Status,
+		Total:        s.Total,
+		Processed:    s.Processed,
+		Imported:     s.Imported,
+		SkippedCount: s.SkippedCount,
+		Skipped:      skipped,
+		Error:        s.Error,
+		CreatedAt:    s.CreatedAt,
+		FinishedAt:   s.FinishedAt,
+	}
+}
+
+func NewJobListResponse(states []importer.JobState) []render.Renderer {
+	list := make([]render.Renderer, len(states))
+	for i, s := range states {
+		list[i] = NewJobResponse(s)
+	}
+	return list
+}
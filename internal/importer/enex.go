@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"io"
+	"strings"
+	"time"
+)
+
+const enexTimeLayout = "20060102T150405Z"
+
+// ENEX reads an Evernote export. The file is decoded one note at a time and
+// each note's ENML body is converted to Markdown. Attached resources are not
+// imported; they are listed in the item's Dropped names instead.
+type ENEX struct{}
+
+type enexNote struct {
+	Title     string         `xml:"title"`
+	Content   string         `xml:"content"`
+	Created   string         `xml:"created"`
+	Updated   string         `xml:"updated"`
+	Tags      []string       `xml:"tag"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+func (ENEX) Import(r io.ReaderAt, size int64, sink Sink) error {
+	const op = "importer.ENEX.Import"
+
+	d := xml.NewDecoder(io.NewSectionReader(r, 0, size))
+	d.Entity = xml.HTMLEntity
+
+	seenRoot := false
+	n := 0
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "en-export":
+			seenRoot = true
+			continue
+		case "note":
+		default:
+			if err := d.Skip(); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			continue
+		}
+
+		n++
+		var note enexNote
+		if err := d.DecodeElement(&note, &start); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		source := fmt.Sprintf("note %d", n)
+		if t := strings.TrimSpace(note.Title); t != "" {
+			source = fmt.Sprintf("%s (%s)", source, t)
+		}
+
+		item, err := convertENEXNote(source, &note)
+		if err != nil {
+			sink.Skip(source, err.Error())
+			continue
+		}
+		if err := sink.Add(item); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if !seenRoot {
+		return fmt.Errorf("%s: not an Evernote export", op)
+	}
+	return nil
+}
+
+func convertENEXNote(source string, note *enexNote) (Item, error) {
+	if len(note.Content) > MaxNoteSize {
+		return Item{}, ErrTooLarge
+	}
+
+	content, err := enmlToMarkdown(note.Content)
+	if err != nil {
+		return Item{}, fmt.Errorf("invalid note content: %w", err)
+	}
+
+	item := Item{
+		Source:  source,
+		Title:   strings.TrimSpace(note.Title),
+		Content: content,
+		Format:  entity.FormatMarkdown,
+		Tags:    note.Tags,
+	}
+	for _, res := range note.Resources {
+		name := res.FileName
+		if name == "" {
+			name = res.Mime
+		}
+		item.Dropped = append(item.Dropped, name)
+	}
+	// Evernote always writes both timestamps; a malformed one is dropped
+	// rather than failing the note.
+	if t, err := time.Parse(enexTimeLayout, note.Created); err == nil {
+		item.CreatedAt = t
+	}
+	if t, err := time.Parse(enexTimeLayout, note.Updated); err == nil {
+		item.UpdatedAt = t
+	}
+	return item, nil
+}
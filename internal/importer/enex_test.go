@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"bytes"
+	"gonotes/internal/notes/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleENEX = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE en-export SYSTEM "http://xml.evernote.com/pub/evernote-export3.dtd">
+<en-export export-date="20240101T000000Z" application="Evernote">
+  <note>
+    <title>Groceries</title>
+    <content><![CDATA[<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<!DOCTYPE en-note SYSTEM "http://xml.evernote.com/pub/enml2.dtd">
+<en-note><div>Weekly <b>shop</b></div><div><en-todo checked="true"/>eggs</div><div><en-todo/>milk</div><en-media hash="abc" type="image/png"/></en-note>]]></content>
+    <created>20231105T101500Z</created>
+    <updated>20231106T080000Z</updated>
+    <tag>Home</tag>
+    <tag>errands</tag>
+    <resource>
+      <data encoding="base64">iVBORw0KGgo=</data>
+      <mime>image/png</mime>
+      <resource-attributes><file-name>receipt.png</file-name></resource-attributes>
+    </resource>
+  </note>
+  <note>
+    <title>Empty</title>
+    <content><![CDATA[<en-note></en-note>]]></content>
+  </note>
+</en-export>`
+
+func Test_ENEXImport(t *testing.T) {
+	sink := runImport(t, ENEX{}, []byte(sampleENEX))
+	require.Len(t, sink.items, 2)
+
+	n := sink.items[0]
+	assert.Equal(t, "note 1 (Groceries)", n.Source)
+	assert.Equal(t, "Groceries", n.Title)
+	assert.Equal(t, "Weekly **shop**\n\n- [x] eggs\n- [ ] milk", n.Content)
+	assert.Equal(t, entity.FormatMarkdown, n.Format)
+	assert.Equal(t, []string{"Home", "errands"}, n.Tags)
+	assert.Equal(t, []string{"receipt.png"}, n.Dropped)
+	assert.Equal(t, time.Date(2023, 11, 5, 10, 15, 0, 0, time.UTC), n.CreatedAt)
+	assert.Equal(t, time.Date(2023, 11, 6, 8, 0, 0, 0, time.UTC), n.UpdatedAt)
+
+	// Empty notes are left for the sink to judge.
+	assert.Equal(t, "", sink.items[1].Content)
+}
+
+func Test_ENEXImportRejectsOtherXML(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?><rss><channel/></rss>`)
+	err := ENEX{}.Import(bytes.NewReader(data), int64(len(data)), &recordingSink{})
+	assert.Error(t, err)
+}
+
+func Test_ENMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		enml     string
+		expected string
+	}{
+		{
+			"paragraphs",
+			`<en-note><div>one</div><div><br/></div><div>two</div></en-note>`,
+			"one\n\ntwo",
+		},
+		{
+			"inline formatting",
+			`<en-note><div>a <i>b</i> <strike>c</strike> <code>d</code></div></en-note>`,
+			"a *b* ~~c~~ `d`",
+		},
+		{
+			"headings and rules",
+			`<en-note><h2>Title</h2><div>text</div><hr/><div>after</div></en-note>`,
+			"## Title\n\ntext\n\n---\n\nafter",
+		},
+		{
+			"lists",
+			`<en-note><ul><li>a</li><li>b<ol><li>x</li><li>y</li></ol></li></ul></en-note>`,
+			"- a\n- b\n  1. x\n  2. y",
+		},
+		{
+			"links",
+			`<en-note><div><a href="https://example.com">site</a> <a href="https://go.dev">https://go.dev</a> <a href="evernote:///view/1">internal</a></div></en-note>`,
+			"[site](https://example.com) <https://go.dev> internal",
+		},
+		{
+			"preformatted",
+			"<en-note><pre>func main() {\n\treturn\n}</pre></en-note>",
+			"```\nfunc main() {\n\treturn\n}\n```",
+		},
+		{
+			"blockquote",
+			`<en-note><blockquote><div>quoted</div><div>twice</div></blockquote></en-note>`,
+			"> quoted\n>\n> twice",
+		},
+		{
+			"encrypted and media",
+			`<en-note><div>visible</div><en-crypt cipher="AES">c2VjcmV0</en-crypt><en-media hash="x" type="image/png"/></en-note>`,
+			"visible",
+		},
+		{
+			"table",
+			`<en-note><table><tr><td>a</td><td>b</td></tr><tr><td>c</td><td>d</td></tr></table></en-note>`,
+			"| a | b |\n| c | d |",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := enmlToMarkdown(tc.enml)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// enmlToMarkdown converts an Evernote note body to Markdown. Formatting that
+// has no Markdown equivalent, such as colours and fonts, is dropped; media
+// and encrypted sections are left out.
+func enmlToMarkdown(enml string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(enml))
+	if err != nil {
+		return "", err
+	}
+
+	w := &markdownWriter{}
+	w.node(doc)
+	return w.String(), nil
+}
+
+type markdownWriter struct {
+	b strings.Builder
+	// newlines counts the line breaks at the end of the output so far.
+	newlines int
+	// listLine is set while the current line starts with a list marker, so
+	// that the enclosing block ends the line without a blank line after it.
+	listLine bool
+	lists    []int
+	pre      int
+}
+
+func (w *markdownWriter) String() string {
+	lines := strings.Split(w.b.String(), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func (w *markdownWriter) write(s string) {
+	if s == "" {
+		return
+	}
+	w.b.WriteString(s)
+	trimmed := strings.TrimRight(s, "\n")
+	if trimmed == "" {
+		w.newlines += len(s)
+	} else {
+		w.newlines = len(s) - len(trimmed)
+	}
+	if w.newlines > 0 {
+		w.listLine = false
+	}
+}
+
+func (w *markdownWriter) atLineStart() bool {
+	return w.b.Len() == 0 || w.newlines > 0
+}
+
+// breakLine ends the current line, if any.
+func (w *markdownWriter) breakLine() {
+	if w.b.Len() > 0 && w.newlines == 0 {
+		w.write("\n")
+	}
+}
+
+// breakParagraph leaves a blank line after the current output.
+func (w *markdownWriter) breakParagraph() {
+	if w.b.Len() == 0 {
+		return
+	}
+	for w.newlines < 2 {
+		w.write("\n")
+	}
+}
+
+// endBlock closes a block element: list lines stay together, anything else
+// becomes its own paragraph.
+func (w *markdownWriter) endBlock() {
+	if w.listLine {
+		w.breakLine()
+		return
+	}
+	w.breakParagraph()
+}
+
+// text writes character data, collapsing whitespace runs to one space
+// outside preformatted blocks.
+func (w *markdownWriter) text(s string) {
+	if w.pre > 0 {
+		w.write(s)
+		return
+	}
+	if s == "" {
+		return
+	}
+
+	out := strings.Join(strings.Fields(s), " ")
+	if isSpace(s[0]) {
+		out = " " + out
+	}
+	if isSpace(s[len(s)-1]) && strings.TrimSpace(s) != "" {
+		out += " "
+	}
+	if w.atLineStart() || strings.HasSuffix(w.b.String(), " ") {
+		out = strings.TrimLeft(out, " ")
+	}
+	w.write(out)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f'
+}
+
+func (w *markdownWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *markdownWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.text(n.Data)
+		return
+	case html.DocumentNode:
+		w.children(n)
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	switch n.Data {
+	case "en-media", "en-crypt":
+		return
+	case "en-todo":
+		w.breakLine()
+		w.write(strings.Repeat("  ", max(len(w.lists)-1, 0)))
+		if attr(n, "checked") == "true" {
+			w.write("- [x] ")
+		} else {
+			w.write("- [ ] ")
+		}
+		w.listLine = true
+		w.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Script, atom.Style, atom.Title:
+		return
+	case atom.Br:
+		// Evernote marks blank lines with a lone <br/>; paragraphs already
+		// provide the spacing.
+		if w.newlines < 2 {
+			w.write("\n")
+		}
+	case atom.Hr:
+		w.breakParagraph()
+		w.write("---")
+		w.breakParagraph()
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.breakParagraph()
+		w.write(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.breakParagraph()
+	case atom.Ul, atom.Ol:
+		if len(w.lists) == 0 {
+			w.breakParagraph()
+		} else {
+			w.breakLine()
+		}
+		start := 0
+		if n.DataAtom == atom.Ol {
+			start = 1
+		}
+		w.lists = append(w.lists, start)
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		if len(w.lists) == 0 {
+			w.breakParagraph()
+		}
+	case atom.Li:
+		w.breakLine()
+		depth := len(w.lists)
+		w.write(strings.Repeat("  ", max(depth-1, 0)))
+		if depth > 0 && w.lists[depth-1] > 0 {
+			w.write(fmt.Sprintf("%d. ", w.lists[depth-1]))
+			w.lists[depth-1]++
+		} else {
+			w.write("- ")
+		}
+		w.listLine = true
+		w.children(n)
+		w.breakLine()
+	case atom.Pre:
+		w.breakParagraph()
+		w.write("```\n")
+		w.pre++
+		w.children(n)
+		w.pre--
+		w.breakLine()
+		w.write("```")
+		w.breakParagraph()
+	case atom.Blockquote:
+		w.breakParagraph()
+		inner := &markdownWriter{}
+		inner.children(n)
+		for _, line := range strings.Split(inner.String(), "\n") {
+			w.write("> " + line + "\n")
+		}
+		w.breakParagraph()
+	case atom.B, atom.Strong:
+		w.inline(n, "**")
+	case atom.I, atom.Em:
+		w.inline(n, "*")
+	case atom.S, atom.Strike, atom.Del:
+		w.inline(n, "~~")
+	case atom.Code:
+		if w.pre > 0 {
+			w.children(n)
+			return
+		}
+		w.inline(n, "`")
+	case atom.A:
+		href := attr(n, "href")
+		inner := &markdownWriter{}
+		inner.children(n)
+		label := inner.String()
+		switch {
+		case href == "" || strings.HasPrefix(href, "evernote:"):
+			w.write(label)
+		case label == "" || label == href:
+			w.write("<" + href + ">")
+		default:
+			w.write("[" + label + "](" + href + ")")
+		}
+	case atom.Img:
+		if src := attr(n, "src"); src != "" && !strings.HasPrefix(src, "data:") {
+			w.write("![" + attr(n, "alt") + "](" + src + ")")
+		}
+	case atom.Tr:
+		w.breakLine()
+		w.write("|")
+		w.children(n)
+		w.breakLine()
+	case atom.Td, atom.Th:
+		inner := &markdownWriter{}
+		inner.children(n)
+		w.write(" " + strings.ReplaceAll(inner.String(), "\n", " ") + " |")
+	case atom.P, atom.Div, atom.Table, atom.Section, atom.Article, atom.Header, atom.Footer:
+		if !w.listLine {
+			w.breakLine()
+		}
+		w.children(n)
+		w.endBlock()
+	default:
+		w.children(n)
+	}
+}
+
+// inline wraps the element's text in a Markdown marker, keeping the marker
+// off surrounding whitespace where Markdown would not recognise it.
+func (w *markdownWriter) inline(n *html.Node, marker string) {
+	inner := &markdownWriter{pre: w.pre}
+	inner.children(n)
+	raw := inner.b.String()
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		w.text(raw)
+		return
+	}
+	if isSpace(raw[0]) {
+		w.text(" ")
+	}
+	w.write(marker + trimmed + marker)
+	if isSpace(raw[len(raw)-1]) {
+		w.text(" ")
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+// Package importer reads notes exported by other applications. Each source
+// format has its own Importer; they all hand their notes to a Sink, which
+// decides where they end up.
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// MaxNoteSize caps the content of a single imported note, so that a small
+// compressed archive cannot expand into an unbounded amount of memory.
+const MaxNoteSize = 10 << 20
+
+var (
+	ErrUnknownFormat = errors.New("unknown import format")
+	ErrTooLarge      = fmt.Errorf("note is larger than %d bytes", MaxNoteSize)
+)
+
+// Format names a supported source.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatENEX     Format = "enex"
+	FormatKeep     Format = "keep"
+)
+
+func (f Format) Valid() bool {
+	return f == FormatMarkdown || f == FormatENEX || f == FormatKeep
+}
+
+// Item is one note read from a source. Notebook is the folder path the note
+// was filed under, outermost first; it is empty for unfiled notes. Dropped
+// names the parts of the note, such as attachments, that could not be
+// carried over.
+type Item struct {
+	Source    string
+	Title     string
+	Content   string
+	Format    entity.Format
+	Tags      []string
+	Notebook  []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Pinned    bool
+	Archived  bool
+	Favourite bool
+	Dropped   []string
+}
+
+// Sink receives what an importer reads. Add returns an error only when the
+// import cannot go on; problems with a single note are reported with Skip.
+type Sink interface {
+	// Total announces how many entries the source holds, when that is known
+	// up front.
+	Total(n int)
+	Add(item Item) error
+	Skip(source, reason string)
+}
+
+// Importer reads every note in a source into a sink.
+type Importer interface {
+	Import(r io.ReaderAt, size int64, sink Sink) error
+}
+
+// New returns the importer for a format.
+func New(f Format) (Importer, error) {
+	switch f {
+	case FormatMarkdown:
+		return Markdown{}, nil
+	case FormatENEX:
+		return ENEX{}, nil
+	case FormatKeep:
+		return Keep{}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Detect guesses the format of an upload from its file name and contents:
+// XML is taken for an Evernote export, and a zip holding a Keep folder of
+// JSON files for a Google Takeout archive. Any other zip is read as Markdown.
+func Detect(name string, r io.ReaderAt, size int64) (Format, error) {
+	if strings.EqualFold(path.Ext(name), ".enex") {
+		return FormatENEX, nil
+	}
+
+	head := make([]byte, 512)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	if bytes.HasPrefix(head, []byte("PK\x03\x04")) {
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return "", ErrUnknownFormat
+		}
+		for _, f := range zr.File {
+			if isKeepNote(f.Name) {
+				return FormatKeep, nil
+			}
+		}
+		return FormatMarkdown, nil
+	}
+
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<!DOCTYPE en-export")) || bytes.HasPrefix(trimmed, []byte("<en-export")) {
+		return FormatENEX, nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// readZipFile reads a whole archive entry, refusing entries that would
+// expand past MaxNoteSize.
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > MaxNoteSize {
+		return nil, ErrTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxNoteSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxNoteSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// hiddenEntry reports archive entries added by the archiving tool rather
+// than the user, such as macOS resource forks.
+func hiddenEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"gonotes/internal/export"
+	nbentity "gonotes/internal/notebooks/entity"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink keeps everything an importer hands it.
+type recordingSink struct {
+	total   int
+	items   []Item
+	skipped []SkippedItem
+}
+
+func (s *recordingSink) Total(n int) { s.total = n }
+func (s *recordingSink) Add(item Item) error {
+	s.items = append(s.items, item)
+	return nil
+}
+func (s *recordingSink) Skip(source, reason string) {
+	s.skipped = append(s.skipped, SkippedItem{Source: source, Reason: reason})
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func runImport(t *testing.T, imp Importer, data []byte) *recordingSink {
+	t.Helper()
+	sink := &recordingSink{}
+	require.NoError(t, imp.Import(bytes.NewReader(data), int64(len(data)), sink))
+	return sink
+}
+
+func Test_Detect(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     []byte
+		expected Format
+		err      error
+	}{
+		{"enex by extension", "notes.enex", []byte("anything"), FormatENEX, nil},
+		{"enex by content", "upload", []byte("\xef\xbb\xbf<?xml version=\"1.0\"?>\n<en-export>"), FormatENEX, nil},
+		{"keep takeout", "takeout.zip", buildZip(t, map[string]string{"Takeout/Keep/a.json": "{}", "Takeout/Keep/a.html": ""}), FormatKeep, nil},
+		{"markdown zip", "notes.zip", buildZip(t, map[string]string{"a.md": "x"}), FormatMarkdown, nil},
+		{"unknown", "notes.bin", []byte("hello"), "", ErrUnknownFormat},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := Detect(tc.fileName, bytes.NewReader(tc.data), int64(len(tc.data)))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, f)
+		})
+	}
+}
+
+func Test_MarkdownImport(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"Work/Projects/plan.md": "---\ntitle: The plan\ntags: [work, q3]\ncreated_at: 2024-01-02T03:04:05Z\nupdated_at: 2024-02-03T04:05:06Z\nformat: markdown\npinned: true\n---\n# Plan\n\nbody\n",
+		"todo.txt":              "buy milk",
+		"bare.md":               "no front matter",
+		"image.png":             "\x89PNG",
+		"broken.md":             "---\ntitle: [unclosed\n---\nbody",
+		export.ManifestName:     "{}",
+		"__MACOSX/._plan.md":    "junk",
+	})
+
+	sink := runImport(t, Markdown{}, data)
+	assert.Equal(t, 5, sink.total)
+
+	byTitle := map[string]Item{}
+	for _, it := range sink.items {
+		byTitle[it.Title] = it
+	}
+	require.Len(t, byTitle, 3)
+
+	plan := byTitle["The plan"]
+	assert.Equal(t, "# Plan\n\nbody\n", plan.Content)
+	assert.Equal(t, []string{"Work", "Projects"}, plan.Notebook)
+	assert.Equal(t, []string{"work", "q3"}, plan.Tags)
+	assert.Equal(t, entity.FormatMarkdown, plan.Format)
+	assert.True(t, plan.Pinned)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), plan.CreatedAt)
+	assert.Equal(t, time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC), plan.UpdatedAt)
+
+	assert.Equal(t, entity.FormatPlain, byTitle["todo"].Format)
+	assert.Equal(t, "buy milk", byTitle["todo"].Content)
+	assert.Equal(t, "no front matter", byTitle["bare"].Content)
+	assert.Nil(t, byTitle["bare"].Notebook)
+
+	reasons := map[string]string{}
+	for _, s := range sink.skipped {
+		reasons[s.Source] = s.Reason
+	}
+	assert.Equal(t, "unsupported file type", reasons["image.png"])
+	assert.Contains(t, reasons["broken.md"], "invalid front matter")
+}
+
+// exportedNotes serves a fixed set of notes in one page.
+type exportedNotes []entity.Note
+
+func (n exportedNotes) List(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+	return &storage.NotePage{Notes: n}, nil
+}
+
+func Test_MarkdownImportRoundTrip(t *testing.T) {
+	nb := 7
+	created := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+	notes := exportedNotes{
+		{ID: 1, Title: "Filed", Content: "---\nnot front matter\n", Format: entity.FormatMarkdown, Tags: []string{"a"}, NotebookID: &nb, CreatedAt: created, UpdatedAt: created, Archived: true},
+		{ID: 2, Title: "", Content: "untitled", Format: entity.FormatPlain, CreatedAt: created, UpdatedAt: created},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.Write(&buf, 1, notes, []nbentity.Notebook{{ID: nb, Name: "Inbox"}}, created))
+
+	sink := runImport(t, Markdown{}, buf.Bytes())
+	require.Len(t, sink.items, 2)
+	assert.Empty(t, sink.skipped)
+
+	filed := sink.items[0]
+	assert.Equal(t, "Filed", filed.Title)
+	assert.Equal(t, "---\nnot front matter\n", filed.Content)
+	assert.Equal(t, []string{"Inbox"}, filed.Notebook)
+	assert.Equal(t, []string{"a"}, filed.Tags)
+	assert.True(t, filed.Archived)
+	assert.True(t, filed.CreatedAt.Equal(created))
+
+	assert.Equal(t, "untitled", sink.items[1].Content)
+	assert.Equal(t, entity.FormatPlain, sink.items[1].Format)
+}
+
+func Test_KeepImport(t *testing.T) {
+	data := buildZip(t, map[string]string{
+		"Takeout/Keep/Shopping.json": `{"title":"Shopping","listContent":[{"text":"eggs","isChecked":true},{"text":"milk","isChecked":false}],"labels":[{"name":"Home"}],"isPinned":true,"createdTimestampUsec":1700000000000000,"userEditedTimestampUsec":1700000100000000}`,
+		"Takeout/Keep/Idea.json":     `{"title":"","textContent":"an idea","isArchived":true,"annotations":[{"title":"Example","url":"https://example.com"}],"attachments":[{"filePath":"photo.jpg"}]}`,
+		"Takeout/Keep/Old.json":      `{"title":"Old","textContent":"gone","isTrashed":true}`,
+		"Takeout/Keep/Bad.json":      `{not json`,
+		"Takeout/Keep/Shopping.html": "<html></html>",
+		"Takeout/Keep/photo.jpg":     "\xff\xd8",
+	})
+
+	sink := runImport(t, Keep{}, data)
+	assert.Equal(t, 4, sink.total)
+	require.Len(t, sink.items, 2)
+
+	var shopping, idea Item
+	for _, it := range sink.items {
+		if it.Title == "Shopping" {
+			shopping = it
+		} else {
+			idea = it
+		}
+	}
+
+	assert.Equal(t, "- [x] eggs\n- [ ] milk\n", shopping.Content)
+	assert.Equal(t, entity.FormatMarkdown, shopping.Format)
+	assert.Equal(t, []string{"Home"}, shopping.Tags)
+	assert.True(t, shopping.Pinned)
+	assert.Equal(t, time.UnixMicro(1700000000000000).UTC(), shopping.CreatedAt)
+	assert.Equal(t, time.UnixMicro(1700000100000000).UTC(), shopping.UpdatedAt)
+
+	assert.Equal(t, "an idea\n\nExample: https://example.com\n", idea.Content)
+	assert.Equal(t, entity.FormatPlain, idea.Format)
+	assert.True(t, idea.Archived)
+	assert.Equal(t, []string{"photo.jpg"}, idea.Dropped)
+
+	assert.ElementsMatch(t, []SkippedItem{
+		{Source: "Takeout/Keep/Old.json", Reason: "note is in the Keep trash"},
+		{Source: "Takeout/Keep/Bad.json", Reason: "invalid note file"},
+	}, sink.skipped)
+}
+
+func Test_ZipTooLarge(t *testing.T) {
+	data := buildZip(t, map[string]string{"big.md": string(make([]byte, MaxNoteSize+1))})
+
+	sink := runImport(t, Markdown{}, data)
+	assert.Empty(t, sink.items)
+	require.Len(t, sink.skipped, 1)
+	assert.Equal(t, ErrTooLarge.Error(), sink.skipped[0].Reason)
+}
+
+func Test_NotAZip(t *testing.T) {
+	data := []byte("plain text")
+	err := Markdown{}.Import(bytes.NewReader(data), int64(len(data)), &recordingSink{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package importhandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/importer"
+	"gonotes/internal/importer/dto"
+	"gonotes/internal/middleware"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Runner interface {
+	Run(job *importer.Job, imp importer.Importer, src io.ReaderAt, size int64)
+}
+
+type Handler struct {
+	log     *slog.Logger
+	runner  Runner
+	jobs    *importer.Jobs
+	maxSize int64
+}
+
+func NewHandler(log *slog.Logger, runner Runner, jobs *importer.Jobs, maxSize int64) *Handler {
+	return &Handler{
+		log:     log,
+		runner:  runner,
+		jobs:    jobs,
+		maxSize: maxSize,
+	}
+}
+
+// Import saves the multipart field named file to a temporary file and starts
+// importing it in the background. The format is taken from the format query
+// parameter or detected from the upload. The response points at the job.
+func (h *Handler) Import(w http.ResponseWriter, r *http.Request) {
+	const op = "import.handler.import"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	format := importer.Format(strings.ToLower(r.URL.Query().Get("format")))
+	if format != "" && !format.Valid() {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("format must be one of %s, %s, %s", importer.FormatMarkdown, importer.FormatENEX, importer.FormatKeep)))
+		return
+	}
+
+	// Large archives take longer to upload than the server's read timeout,
+	// and the response is only written once the upload is done.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear read deadline", slog.Any("err", err))
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Error("failed to clear write deadline", slog.Any("err", err))
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+api.MultipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		log.Error("invalid multipart request", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("expected a multipart/form-data body")))
+		return
+	}
+
+	var part *multipart.Part
+	for {
+		p, err := reader.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			h.renderUploadError(w, r, log, err)
+			return
+		}
+		if p.FormName() == "file" {
+			part = p
+			break
+		}
+	}
+	if part == nil {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("missing file field")))
+		return
+	}
+	name := filepath.Base(strings.ReplaceAll(part.FileName(), `\`, "/"))
+
+	f, err := os.CreateTemp("", "gonotes-import-*")
+	if err != nil {
+		log.Error("failed to create temporary file", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	size, err := io.Copy(f, io.LimitReader(part, h.maxSize+1))
+	if err != nil {
+		h.renderUploadError(w, r, log, err)
+		return
+	}
+	if size > h.maxSize {
+		h.renderUploadError(w, r, log, &http.MaxBytesError{Limit: h.maxSize})
+		return
+	}
+
+	if format == "" {
+		format, err = importer.Detect(name, f, size)
+		if err != nil {
+			log.Error("failed to detect import format", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("unrecognised file, pass format=%s, %s or %s", importer.FormatMarkdown, importer.FormatENEX, importer.FormatKeep)))
+			return
+		}
+	}
+	imp, err := importer.New(format)
+	if err != nil {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	job := h.jobs.New(userID, format, name)
+	started = true
+	go func() {
+		defer os.Remove(f.Name())
+		defer f.Close()
+
+		h.runner.Run(job, imp, f, size)
+
+		s := job.State()
+		log.Info("import finished",
+			slog.String("job_id", s.ID),
+			slog.String("status", string(s.Status)),
+			slog.Int("imported", s.Imported),
+			slog.Int("skipped", s.SkippedCount),
+			slog.String("error", s.Error),
+		)
+	}()
+
+	w.Header().Set("Location", "/import/"+job.ID())
+	render.Status(r, http.StatusAccepted)
+	render.Render(w, r, dto.NewJobResponse(job.State()))
+
+	log.Info("import started", slog.String("job_id", job.ID()), slog.String("format", string(format)), slog.Int64("size", size))
+}
+
+// Get reports the progress of an import and, once it has finished, the
+// entries it skipped.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "import.handler.get"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	job, ok := h.jobs.Get(chi.URLParam(r, "id"), userID)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, fmt.Errorf("import job not found")))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewJobResponse(job.State()))
+
+	log.Info("import job retrieved", slog.String("job_id", job.ID()))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "import.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	jobs := h.jobs.List(userID)
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewJobListResponse(jobs))
+
+	log.Info("import jobs retrieved", slog.Int("count", len(jobs)))
+}
+
+func (h *Handler) renderUploadError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		log.Error("import too large", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusRequestEntityTooLarge, fmt.Errorf("import exceeds %d bytes", h.maxSize)))
+		return
+	}
+	log.Error("failed to save import", slog.Any("err", err))
+	render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+}
@@ -0,0 +1,169 @@
+package importhandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gonotes/internal/importer"
+	"gonotes/internal/importer/dto"
+	"gonotes/internal/importer/importhandler"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRunner records the upload it was handed instead of importing it.
+type mockRunner struct {
+	done chan struct{}
+	imp  importer.Importer
+	data []byte
+}
+
+func (m *mockRunner) Run(job *importer.Job, imp importer.Importer, src io.ReaderAt, size int64) {
+	defer close(m.done)
+	m.imp = imp
+	m.data, _ = io.ReadAll(io.NewSectionReader(src, 0, size))
+}
+
+func newRequest(method, target string, body io.Reader, jobID string, userID int) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", jobID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if userID != 0 {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, userID))
+	}
+	return req
+}
+
+func uploadRequest(t *testing.T, target, field, name, content string, userID int) *http.Request {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile(field, name)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+
+	req := newRequest(http.MethodPost, target, &buf, "", userID)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func Test_Import(t *testing.T) {
+	const enex = `<?xml version="1.0"?><en-export></en-export>`
+
+	cases := []struct {
+		name           string
+		target         string
+		field          string
+		fileName       string
+		content        string
+		userID         int
+		expectedCode   int
+		expectedFormat importer.Format
+	}{
+		{"detected format", "/import", "file", "notes.enex", enex, 1, http.StatusAccepted, importer.FormatENEX},
+		{"explicit format", "/import?format=markdown", "file", "upload", "PK", 1, http.StatusAccepted, importer.FormatMarkdown},
+		{"invalid format", "/import?format=onenote", "file", "notes.one", "x", 1, http.StatusBadRequest, ""},
+		{"unrecognised file", "/import", "file", "notes.bin", "hello", 1, http.StatusBadRequest, ""},
+		{"too large", "/import", "file", "notes.enex", strings.Repeat("x", 65), 1, http.StatusRequestEntityTooLarge, ""},
+		{"missing file field", "/import", "other", "notes.enex", enex, 1, http.StatusBadRequest, ""},
+		{"unauthorized", "/import", "file", "notes.enex", enex, 0, http.StatusUnauthorized, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := &mockRunner{done: make(chan struct{})}
+			jobs := importer.NewJobs(time.Hour)
+			h := importhandler.NewHandler(slogdiscard.NewDiscardLogger(), runner, jobs, 64)
+
+			rr := httptest.NewRecorder()
+			h.Import(rr, uploadRequest(t, tc.target, tc.field, tc.fileName, tc.content, tc.userID))
+
+			require.Equal(t, tc.expectedCode, rr.Code, rr.Body.String())
+			if tc.expectedCode != http.StatusAccepted {
+				return
+			}
+
+			var resp dto.JobResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, "/import/"+resp.ID, rr.Header().Get("Location"))
+			assert.Equal(t, tc.expectedFormat, resp.Format)
+			assert.Equal(t, tc.fileName, resp.FileName)
+
+			select {
+			case <-runner.done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("import was not started")
+			}
+			assert.Equal(t, tc.content, string(runner.data))
+
+			_, ok := jobs.Get(resp.ID, tc.userID)
+			assert.True(t, ok)
+		})
+	}
+}
+
+func Test_Get(t *testing.T) {
+	jobs := importer.NewJobs(time.Hour)
+	job := jobs.New(1, importer.FormatKeep, "takeout.zip")
+	h := importhandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockRunner{}, jobs, 64)
+
+	cases := []struct {
+		name         string
+		jobID        string
+		userID       int
+		expectedCode int
+	}{
+		{"found", job.ID(), 1, http.StatusOK},
+		{"other user", job.ID(), 2, http.StatusNotFound},
+		{"unknown", "nope", 1, http.StatusNotFound},
+		{"unauthorized", job.ID(), 0, http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.Get(rr, newRequest(http.MethodGet, "/import/"+tc.jobID, nil, tc.jobID, tc.userID))
+
+			require.Equal(t, tc.expectedCode, rr.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+			var resp dto.JobResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, importer.StatusPending, resp.Status)
+			assert.Equal(t, importer.FormatKeep, resp.Format)
+			assert.NotNil(t, resp.Skipped)
+		})
+	}
+}
+
+func Test_GetAll(t *testing.T) {
+	jobs := importer.NewJobs(time.Hour)
+	jobs.New(1, importer.FormatKeep, "a.zip")
+	jobs.New(1, importer.FormatENEX, "b.enex")
+	jobs.New(2, importer.FormatENEX, "c.enex")
+	h := importhandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockRunner{}, jobs, 64)
+
+	rr := httptest.NewRecorder()
+	h.GetAll(rr, newRequest(http.MethodGet, "/import", nil, "", 1))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp []dto.JobResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Len(t, resp, 2)
+}
@@ -0,0 +1,181 @@
+package importer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MaxReportedSkips bounds the skip report kept for a job; SkippedCount keeps
+// counting past it.
+const MaxReportedSkips = 1000
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+type SkippedItem struct {
+	Source string
+	Reason string
+}
+
+// JobState is a point-in-time copy of a job's progress.
+type JobState struct {
+	ID           string
+	UserID       int
+	Format       Format
+	FileName     string
+	Status       Status
+	Total        int
+	Processed    int
+	Imported     int
+	SkippedCount int
+	Skipped      []SkippedItem
+	Error        string
+	CreatedAt    time.Time
+	FinishedAt   *time.Time
+}
+
+// Job tracks one import while it runs in the background.
+type Job struct {
+	mu    sync.Mutex
+	state JobState
+	now   func() time.Time
+}
+
+func (j *Job) ID() string {
+	return j.state.ID
+}
+
+// State returns a copy of the job's progress that is safe to read while the
+// import goes on.
+func (j *Job) State() JobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s := j.state
+	s.Skipped = slices.Clone(j.state.Skipped)
+	return s
+}
+
+func (j *Job) update(fn func(s *JobState)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	fn(&j.state)
+}
+
+func (j *Job) skip(source, reason string) {
+	j.update(func(s *JobState) {
+		s.SkippedCount++
+		if len(s.Skipped) < MaxReportedSkips {
+			s.Skipped = append(s.Skipped, SkippedItem{Source: source, Reason: reason})
+		}
+	})
+}
+
+func (j *Job) finish(err error) {
+	now := j.now().UTC()
+	j.update(func(s *JobState) {
+		s.FinishedAt = &now
+		if err != nil {
+			s.Status = StatusFailed
+			s.Error = err.Error()
+			return
+		}
+		s.Status = StatusCompleted
+	})
+}
+
+// Jobs keeps the imports of all users in memory. Finished jobs are forgotten
+// once they are older than the retention period.
+type Jobs struct {
+	mu        sync.Mutex
+	jobs      map[string]*Job
+	retention time.Duration
+	now       func() time.Time
+}
+
+func NewJobs(retention time.Duration) *Jobs {
+	return &Jobs{
+		jobs:      map[string]*Job{},
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// New registers a pending job for the user.
+func (js *Jobs) New(userID int, format Format, fileName string) *Job {
+	id := make([]byte, 12)
+	rand.Read(id)
+
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	js.prune()
+	job := &Job{
+		state: JobState{
+			ID:        hex.EncodeToString(id),
+			UserID:    userID,
+			Format:    format,
+			FileName:  fileName,
+			Status:    StatusPending,
+			CreatedAt: js.now().UTC(),
+		},
+		now: js.now,
+	}
+	js.jobs[job.state.ID] = job
+	return job
+}
+
+// Get returns a job if it belongs to the user.
+func (js *Jobs) Get(id string, userID int) (*Job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	js.prune()
+	job, ok := js.jobs[id]
+	if !ok || job.state.UserID != userID {
+		return nil, false
+	}
+	return job, true
+}
+
+// List returns the user's jobs, newest first.
+func (js *Jobs) List(userID int) []JobState {
+	js.mu.Lock()
+	js.prune()
+	var jobs []*Job
+	for _, job := range js.jobs {
+		if job.state.UserID == userID {
+			jobs = append(jobs, job)
+		}
+	}
+	js.mu.Unlock()
+
+	states := make([]JobState, 0, len(jobs))
+	for _, job := range jobs {
+		states = append(states, job.State())
+	}
+	slices.SortFunc(states, func(a, b JobState) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+	return states
+}
+
+// prune drops finished jobs past the retention period. The caller holds js.mu.
+func (js *Jobs) prune() {
+	cutoff := js.now().Add(-js.retention)
+	for id, job := range js.jobs {
+		s := job.State()
+		if s.FinishedAt != nil && s.FinishedAt.Before(cutoff) {
+			delete(js.jobs, id)
+		}
+	}
+}
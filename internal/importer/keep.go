@@ -0,0 +1,142 @@
+package importer
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Keep reads a Google Takeout archive of Keep notes. Every note is a JSON
+// file in a Keep folder; the HTML copies and media next to them are ignored.
+// Checklists become Markdown task lists and labels become tags. Notes in the
+// Keep trash are skipped.
+type Keep struct{}
+
+type keepNote struct {
+	Title       string `json:"title"`
+	TextContent string `json:"textContent"`
+	ListContent []struct {
+		Text      string `json:"text"`
+		IsChecked bool   `json:"isChecked"`
+	} `json:"listContent"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Annotations []struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	} `json:"annotations"`
+	Attachments []struct {
+		FilePath string `json:"filePath"`
+	} `json:"attachments"`
+	IsTrashed   bool  `json:"isTrashed"`
+	IsPinned    bool  `json:"isPinned"`
+	IsArchived  bool  `json:"isArchived"`
+	CreatedUsec int64 `json:"createdTimestampUsec"`
+	EditedUsec  int64 `json:"userEditedTimestampUsec"`
+}
+
+func isKeepNote(name string) bool {
+	return path.Base(path.Dir(name)) == "Keep" && strings.EqualFold(path.Ext(name), ".json") && !hiddenEntry(name)
+}
+
+func (Keep) Import(r io.ReaderAt, size int64, sink Sink) error {
+	const op = "importer.Keep.Import"
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var files []*zip.File
+	for _, f := range zr.File {
+		if isKeepNote(f.Name) {
+			files = append(files, f)
+		}
+	}
+	sink.Total(len(files))
+
+	for _, f := range files {
+		data, err := readZipFile(f)
+		if err != nil {
+			sink.Skip(f.Name, err.Error())
+			continue
+		}
+
+		var note keepNote
+		if err := json.Unmarshal(data, &note); err != nil {
+			sink.Skip(f.Name, "invalid note file")
+			continue
+		}
+		if note.IsTrashed {
+			sink.Skip(f.Name, "note is in the Keep trash")
+			continue
+		}
+
+		if err := sink.Add(convertKeepNote(f.Name, &note)); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func convertKeepNote(source string, note *keepNote) Item {
+	item := Item{
+		Source:   source,
+		Title:    strings.TrimSpace(note.Title),
+		Content:  note.TextContent,
+		Format:   entity.FormatPlain,
+		Pinned:   note.IsPinned,
+		Archived: note.IsArchived,
+	}
+
+	if len(note.ListContent) > 0 {
+		var b strings.Builder
+		for _, li := range note.ListContent {
+			box := "[ ]"
+			if li.IsChecked {
+				box = "[x]"
+			}
+			fmt.Fprintf(&b, "- %s %s\n", box, strings.ReplaceAll(li.Text, "\n", " "))
+		}
+		item.Content = b.String()
+		item.Format = entity.FormatMarkdown
+	}
+
+	if len(note.Annotations) > 0 {
+		var b strings.Builder
+		b.WriteString(strings.TrimRight(item.Content, "\n"))
+		b.WriteString("\n\n")
+		for _, a := range note.Annotations {
+			if a.Title != "" && a.Title != a.URL {
+				fmt.Fprintf(&b, "%s: %s\n", a.Title, a.URL)
+			} else {
+				fmt.Fprintf(&b, "%s\n", a.URL)
+			}
+		}
+		item.Content = strings.TrimLeft(b.String(), "\n")
+	}
+
+	for _, a := range note.Attachments {
+		item.Dropped = append(item.Dropped, a.FilePath)
+	}
+
+	for _, l := range note.Labels {
+		item.Tags = append(item.Tags, l.Name)
+	}
+
+	if note.CreatedUsec > 0 {
+		item.CreatedAt = time.UnixMicro(note.CreatedUsec).UTC()
+	}
+	if note.EditedUsec > 0 {
+		item.UpdatedAt = time.UnixMicro(note.EditedUsec).UTC()
+	}
+
+	return item
+}
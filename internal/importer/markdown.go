@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"gonotes/internal/export"
+	"gonotes/internal/notes/entity"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Markdown reads a zip of Markdown or text files, such as a gonotes export.
+// Folders become notebooks, and a YAML front matter block supplies the
+// title, tags, timestamps and flags.
+type Markdown struct{}
+
+func (Markdown) Import(r io.ReaderAt, size int64, sink Sink) error {
+	const op = "importer.Markdown.Import"
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var files []*zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || hiddenEntry(f.Name) || f.Name == export.ManifestName {
+			continue
+		}
+		files = append(files, f)
+	}
+	sink.Total(len(files))
+
+	for _, f := range files {
+		switch strings.ToLower(path.Ext(f.Name)) {
+		case ".md", ".markdown", ".txt":
+		default:
+			sink.Skip(f.Name, "unsupported file type")
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			sink.Skip(f.Name, err.Error())
+			continue
+		}
+
+		item, err := parseMarkdown(f.Name, data)
+		if err != nil {
+			sink.Skip(f.Name, err.Error())
+			continue
+		}
+		if err := sink.Add(item); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+func parseMarkdown(name string, data []byte) (Item, error) {
+	if !utf8.Valid(data) {
+		return Item{}, fmt.Errorf("file is not valid UTF-8")
+	}
+
+	ext := path.Ext(name)
+	item := Item{
+		Source: name,
+		Title:  strings.TrimSuffix(path.Base(name), ext),
+		Format: entity.FormatMarkdown,
+	}
+	if strings.EqualFold(ext, ".txt") {
+		item.Format = entity.FormatPlain
+	}
+	if dir := path.Dir(name); dir != "." {
+		item.Notebook = strings.Split(dir, "/")
+	}
+
+	body := data
+	if header, rest, ok := splitFrontMatter(data); ok {
+		var fm export.FrontMatter
+		if err := yaml.Unmarshal(header, &fm); err != nil {
+			return Item{}, fmt.Errorf("invalid front matter: %w", err)
+		}
+		if fm.Title != "" {
+			item.Title = fm.Title
+		}
+		if f := entity.Format(fm.Format); f.Valid() {
+			item.Format = f
+		}
+		item.Tags = fm.Tags
+		item.CreatedAt = fm.CreatedAt
+		item.UpdatedAt = fm.UpdatedAt
+		item.Pinned = fm.Pinned
+		item.Archived = fm.Archived
+		item.Favourite = fm.Favourite
+		body = rest
+	}
+
+	item.Content = string(body)
+	return item, nil
+}
+
+// splitFrontMatter separates a leading block fenced by "---" lines from the
+// rest of the file.
+func splitFrontMatter(data []byte) (header, body []byte, ok bool) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return nil, data, false
+	}
+	rest := data[len("---\n"):]
+	if bytes.HasPrefix(rest, []byte("---\n")) {
+		return nil, rest[len("---\n"):], true
+	}
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if bytes.HasSuffix(rest, []byte("\n---")) {
+			return rest[:len(rest)-len("\n---")], nil, true
+		}
+		return nil, data, false
+	}
+	return rest[:end+1], rest[end+len("\n---\n"):], true
+}
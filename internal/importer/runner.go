@@ -0,0 +1,212 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/events"
+	nbentity "gonotes/internal/notebooks/entity"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	tagentity "gonotes/internal/tags/entity"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxNotebookName = 255
+
+type NoteCreator interface {
+	Create(note *entity.Note) (*entity.Note, error)
+}
+
+type NotebookStore interface {
+	Create(nb *nbentity.Notebook) (*nbentity.Notebook, error)
+	List(userID int) ([]nbentity.Notebook, error)
+}
+
+// Runner stores what importers read as the user's notes, creating notebooks
+// for folder paths that do not exist yet. Every imported note is published
+// as created.
+type Runner struct {
+	notes     NoteCreator
+	notebooks NotebookStore
+	events    events.Publisher
+}
+
+func NewRunner(notes NoteCreator, notebooks NotebookStore, pub events.Publisher) *Runner {
+	return &Runner{notes: notes, notebooks: notebooks, events: pub}
+}
+
+// Run imports the source into the job's user, recording progress on the job
+// as it goes. It returns once the job has finished.
+func (r *Runner) Run(job *Job, imp Importer, src io.ReaderAt, size int64) {
+	job.update(func(s *JobState) { s.Status = StatusRunning })
+
+	sess := &session{runner: r, job: job, userID: job.State().UserID}
+	err := func() (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("import failed: %v", p)
+			}
+		}()
+		return imp.Import(src, size, sess)
+	}()
+	job.finish(err)
+}
+
+// session is the Sink for a single job.
+type session struct {
+	runner    *Runner
+	job       *Job
+	userID    int
+	notebooks map[notebookKey]int
+}
+
+type notebookKey struct {
+	parentID int
+	name     string
+}
+
+func (s *session) Total(n int) {
+	s.job.update(func(st *JobState) { st.Total = n })
+}
+
+func (s *session) Skip(source, reason string) {
+	s.job.skip(source, reason)
+	s.job.update(func(st *JobState) { st.Processed++ })
+}
+
+func (s *session) Add(item Item) error {
+	title := strings.TrimSpace(item.Title)
+	content := item.Content
+	if strings.TrimSpace(content) == "" {
+		if title == "" {
+			s.Skip(item.Source, "note is empty")
+			return nil
+		}
+		// Notes need content; a title-only note keeps its title as the body.
+		content = title
+	}
+
+	format := item.Format
+	if !format.Valid() {
+		format = entity.FormatPlain
+	}
+
+	notebookID, err := s.notebook(item.Notebook)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.runner.notes.Create(&entity.Note{
+		UserID:     s.userID,
+		NotebookID: notebookID,
+		Title:      title,
+		Content:    content,
+		Format:     format,
+		Tags:       importTags(item.Tags),
+		CreatedAt:  item.CreatedAt,
+		UpdatedAt:  item.UpdatedAt,
+		Pinned:     item.Pinned,
+		Archived:   item.Archived,
+		Favourite:  item.Favourite,
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			s.Skip(item.Source, "notebook was deleted during the import")
+			return nil
+		}
+		return err
+	}
+	s.runner.events.Publish(events.NewNoteEvent(events.NoteCreated, s.userID, created))
+
+	for _, name := range item.Dropped {
+		s.job.skip(item.Source+": "+name, "attachments are not imported")
+	}
+	s.job.update(func(st *JobState) {
+		st.Processed++
+		st.Imported++
+	})
+	return nil
+}
+
+// notebook returns the notebook at the folder path, creating any missing
+// notebooks along the way. Names match existing notebooks case-insensitively.
+func (s *session) notebook(path []string) (*int, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	if s.notebooks == nil {
+		existing, err := s.runner.notebooks.List(s.userID)
+		if err != nil {
+			return nil, err
+		}
+		s.notebooks = map[notebookKey]int{}
+		for _, nb := range existing {
+			parent := 0
+			if nb.ParentID != nil {
+				parent = *nb.ParentID
+			}
+			key := notebookKey{parent, strings.ToLower(nb.Name)}
+			if _, ok := s.notebooks[key]; !ok {
+				s.notebooks[key] = nb.ID
+			}
+		}
+	}
+
+	var parentID *int
+	for _, name := range path {
+		name = notebookName(name)
+		if name == "" {
+			continue
+		}
+
+		parent := 0
+		if parentID != nil {
+			parent = *parentID
+		}
+		key := notebookKey{parent, strings.ToLower(name)}
+
+		id, ok := s.notebooks[key]
+		if !ok {
+			nb, err := s.runner.notebooks.Create(&nbentity.Notebook{UserID: s.userID, ParentID: parentID, Name: name})
+			if err != nil {
+				return nil, err
+			}
+			id = nb.ID
+			s.notebooks[key] = id
+		}
+		parentID = &id
+	}
+	return parentID, nil
+}
+
+func notebookName(name string) string {
+	name = strings.TrimSpace(name)
+	for len(name) > maxNotebookName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
+
+// importTags turns labels from other applications into valid tag names.
+// Commas, which tags cannot hold, become spaces, and overlong names are cut.
+func importTags(labels []string) []string {
+	names := make([]string, 0, len(labels))
+	for _, l := range labels {
+		l = strings.Join(strings.Fields(strings.ReplaceAll(l, ",", " ")), " ")
+		if utf8.RuneCountInString(l) > tagentity.MaxNameLength {
+			l = strings.TrimSpace(string([]rune(l)[:tagentity.MaxNameLength]))
+		}
+		if l != "" {
+			names = append(names, l)
+		}
+	}
+	tags, err := tagentity.NormalizeNames(names)
+	if err != nil {
+		return []string{}
+	}
+	return tags
+}
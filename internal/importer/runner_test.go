@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"errors"
+	"gonotes/internal/events"
+	nbentity "gonotes/internal/notebooks/entity"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNotes struct {
+	created []entity.Note
+	err     error
+}
+
+func (f *fakeNotes) Create(note *entity.Note) (*entity.Note, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, *note)
+	return note, nil
+}
+
+type fakeNotebooks struct {
+	notebooks []nbentity.Notebook
+	lists     int
+}
+
+func (f *fakeNotebooks) List(userID int) ([]nbentity.Notebook, error) {
+	f.lists++
+	return f.notebooks, nil
+}
+
+func (f *fakeNotebooks) Create(nb *nbentity.Notebook) (*nbentity.Notebook, error) {
+	nb.ID = len(f.notebooks) + 100
+	f.notebooks = append(f.notebooks, *nb)
+	return nb, nil
+}
+
+// itemsImporter hands a fixed list of items to the sink.
+type itemsImporter struct {
+	items []Item
+	skips []SkippedItem
+	err   error
+}
+
+func (i itemsImporter) Import(r io.ReaderAt, size int64, sink Sink) error {
+	sink.Total(len(i.items) + len(i.skips))
+	for _, s := range i.skips {
+		sink.Skip(s.Source, s.Reason)
+	}
+	for _, item := range i.items {
+		if err := sink.Add(item); err != nil {
+			return err
+		}
+	}
+	return i.err
+}
+
+func Test_RunnerRun(t *testing.T) {
+	parent := 1
+	notes := &fakeNotes{}
+	notebooks := &fakeNotebooks{notebooks: []nbentity.Notebook{
+		{ID: 1, Name: "Work"},
+		{ID: 2, Name: "Archive", ParentID: &parent},
+	}}
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	imp := itemsImporter{
+		items: []Item{
+			{Source: "a", Title: "A", Content: "body", Format: entity.FormatMarkdown, Notebook: []string{"work", "archive"}, Tags: []string{"One, Two", "one  two"}, CreatedAt: created, Pinned: true},
+			{Source: "b", Title: "B", Content: "body", Notebook: []string{"Work", "New", "Deeper"}, Dropped: []string{"pic.png"}},
+			{Source: "c", Title: "Only a title"},
+			{Source: "d", Title: "  ", Content: " \n "},
+		},
+		skips: []SkippedItem{{Source: "e", Reason: "broken"}},
+	}
+
+	jobs := NewJobs(time.Hour)
+	job := jobs.New(7, FormatMarkdown, "notes.zip")
+	pub := &storagetest.EventRecorder{}
+	NewRunner(notes, notebooks, pub).Run(job, imp, nil, 0)
+
+	s := job.State()
+	assert.Equal(t, StatusCompleted, s.Status)
+	assert.NotNil(t, s.FinishedAt)
+	assert.Equal(t, 5, s.Total)
+	assert.Equal(t, 5, s.Processed)
+	assert.Equal(t, 3, s.Imported)
+	assert.Equal(t, 3, s.SkippedCount)
+	assert.Equal(t, []SkippedItem{
+		{Source: "e", Reason: "broken"},
+		{Source: "b: pic.png", Reason: "attachments are not imported"},
+		{Source: "d", Reason: "note is empty"},
+	}, s.Skipped)
+
+	require.Len(t, notes.created, 3)
+	a := notes.created[0]
+	assert.Equal(t, 7, a.UserID)
+	require.NotNil(t, a.NotebookID)
+	assert.Equal(t, 2, *a.NotebookID)
+	assert.Equal(t, []string{"one two"}, a.Tags)
+	assert.Equal(t, created, a.CreatedAt)
+	assert.True(t, a.Pinned)
+	assert.Equal(t, entity.FormatMarkdown, a.Format)
+
+	b := notes.created[1]
+	require.NotNil(t, b.NotebookID)
+	assert.Equal(t, entity.FormatPlain, b.Format)
+	require.Len(t, notebooks.notebooks, 4)
+	deeper := notebooks.notebooks[3]
+	assert.Equal(t, "Deeper", deeper.Name)
+	assert.Equal(t, deeper.ID, *b.NotebookID)
+	assert.Equal(t, notebooks.notebooks[2].ID, *deeper.ParentID)
+	assert.Equal(t, 1, *notebooks.notebooks[2].ParentID)
+
+	assert.Equal(t, "Only a title", notes.created[2].Content)
+	assert.Equal(t, 1, notebooks.lists)
+
+	published := pub.Events()
+	require.Len(t, published, 3)
+	for i, ev := range published {
+		assert.Equal(t, events.NoteCreated, ev.Type)
+		assert.Equal(t, 7, ev.UserID)
+		assert.Equal(t, notes.created[i].Title, ev.Note.Title)
+	}
+}
+
+func Test_RunnerRunFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		notes    *fakeNotes
+		imp      Importer
+		imported int
+	}{
+		{"importer error", &fakeNotes{}, itemsImporter{items: []Item{{Content: "x"}}, err: errors.New("corrupt archive")}, 1},
+		{"storage error", &fakeNotes{err: errors.New("disk full")}, itemsImporter{items: []Item{{Content: "x"}}}, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			job := NewJobs(time.Hour).New(1, FormatENEX, "x.enex")
+			NewRunner(tc.notes, &fakeNotebooks{}, events.NewBus()).Run(job, tc.imp, nil, 0)
+
+			s := job.State()
+			assert.Equal(t, StatusFailed, s.Status)
+			assert.NotEmpty(t, s.Error)
+			assert.Equal(t, tc.imported, s.Imported)
+		})
+	}
+}
+
+func Test_RunnerSkipsVanishedNotebook(t *testing.T) {
+	job := NewJobs(time.Hour).New(1, FormatMarkdown, "x.zip")
+	notes := &fakeNotes{err: storage.ErrNotebookNotFound}
+	NewRunner(notes, &fakeNotebooks{}, events.NewBus()).Run(job, itemsImporter{items: []Item{{Source: "a", Content: "x", Notebook: []string{"Gone"}}}}, nil, 0)
+
+	s := job.State()
+	assert.Equal(t, StatusCompleted, s.Status)
+	assert.Equal(t, 1, s.SkippedCount)
+}
+
+func Test_Jobs(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	jobs := NewJobs(time.Hour)
+	jobs.now = func() time.Time { return now }
+
+	first := jobs.New(1, FormatMarkdown, "a.zip")
+	now = now.Add(time.Minute)
+	second := jobs.New(1, FormatKeep, "b.zip")
+	other := jobs.New(2, FormatENEX, "c.enex")
+
+	_, ok := jobs.Get(other.ID(), 1)
+	assert.False(t, ok, "jobs of other users are hidden")
+
+	got, ok := jobs.Get(first.ID(), 1)
+	require.True(t, ok)
+	assert.Equal(t, StatusPending, got.State().Status)
+
+	list := jobs.List(1)
+	require.Len(t, list, 2)
+	assert.Equal(t, second.ID(), list[0].ID)
+
+	first.finish(nil)
+	now = now.Add(2 * time.Hour)
+	_, ok = jobs.Get(first.ID(), 1)
+	assert.False(t, ok, "finished jobs expire")
+	_, ok = jobs.Get(second.ID(), 1)
+	assert.True(t, ok, "unfinished jobs are kept")
+}
+
+func Test_JobSkipReportIsBounded(t *testing.T) {
+	job := NewJobs(time.Hour).New(1, FormatMarkdown, "a.zip")
+	for range MaxReportedSkips + 5 {
+		job.skip("x", "y")
+	}
+
+	s := job.State()
+	assert.Len(t, s.Skipped, MaxReportedSkips)
+	assert.Equal(t, MaxReportedSkips+5, s.SkippedCount)
+}
@@ -0,0 +1,408 @@
+// Package crdt implements RGA, a replicated growable array, for plain text.
+// Every character gets an ID that never changes, and replicas that have
+// applied the same operations, in any order, hold the same text.
+//
+// An insert names the character it goes after. Characters inserted after the
+// same one are ordered by ID, highest first, and IDs carry a Lamport clock,
+// so whatever a replica types after seeing a character sorts ahead of what
+// was typed concurrently. Deleted characters stay behind as tombstones so
+// later inserts can still refer to them.
+package crdt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidOp         = errors.New("invalid operation")
+	ErrMissingDependency = errors.New("operation refers to an unknown character")
+	ErrOutOfRange        = errors.New("position out of range")
+	ErrInvalidState      = errors.New("invalid state")
+)
+
+// maxPending caps how many operations wait for the ones they depend on.
+const maxPending = 10000
+
+// ID identifies a character. Site names the replica that inserted it and
+// Clock is a Lamport timestamp unique within the site. The zero ID stands for
+// the start of the text.
+type ID struct {
+	Clock int    `json:"c"`
+	Site  string `json:"s"`
+}
+
+func (id ID) IsZero() bool {
+	return id.Clock == 0 && id.Site == ""
+}
+
+func (id ID) String() string {
+	return fmt.Sprintf("%d@%s", id.Clock, id.Site)
+}
+
+// greater orders characters inserted at the same place; the greater one
+// comes first.
+func (id ID) greater(other ID) bool {
+	if id.Clock != other.Clock {
+		return id.Clock > other.Clock
+	}
+	return id.Site > other.Site
+}
+
+type OpType string
+
+const (
+	OpInsert OpType = "insert"
+	OpDelete OpType = "delete"
+)
+
+// Op is one change to a text. An insert places Text after the character
+// After, its characters taking IDs with consecutive clocks starting at ID. A
+// delete removes the character ID.
+type Op struct {
+	Type  OpType `json:"type"`
+	ID    ID     `json:"id"`
+	After ID     `json:"after,omitzero"`
+	Text  string `json:"text,omitempty"`
+}
+
+func (op Op) Validate() error {
+	if op.ID.Clock <= 0 || op.ID.Site == "" {
+		return fmt.Errorf("%w: id must have a positive clock and a site", ErrInvalidOp)
+	}
+	switch op.Type {
+	case OpInsert:
+		if op.Text == "" || !utf8.ValidString(op.Text) {
+			return fmt.Errorf("%w: insert needs valid UTF-8 text", ErrInvalidOp)
+		}
+		if !op.After.IsZero() && (op.After.Clock <= 0 || op.After.Site == "") {
+			return fmt.Errorf("%w: invalid after", ErrInvalidOp)
+		}
+	case OpDelete:
+		if op.Text != "" || !op.After.IsZero() {
+			return fmt.Errorf("%w: delete takes only an id", ErrInvalidOp)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidOp, op.Type)
+	}
+	return nil
+}
+
+type node struct {
+	id      ID
+	r       rune
+	deleted bool
+	next    *node
+}
+
+// Doc is one replica of a text. It is not safe for concurrent use.
+type Doc struct {
+	site  string
+	clock int
+	// head is a sentinel before the first character.
+	head    node
+	nodes   map[ID]*node
+	size    int
+	pending []Op
+}
+
+// New returns an empty text whose local edits are made as site.
+func New(site string) *Doc {
+	return &Doc{site: site, nodes: map[ID]*node{}}
+}
+
+func (d *Doc) Site() string {
+	return d.site
+}
+
+// Clock returns the highest clock the replica has seen.
+func (d *Doc) Clock() int {
+	return d.clock
+}
+
+// Len returns the number of characters in the text.
+func (d *Doc) Len() int {
+	return d.size
+}
+
+// Pending returns how many received operations wait for ones they depend on.
+func (d *Doc) Pending() int {
+	return len(d.pending)
+}
+
+func (d *Doc) Text() string {
+	var b strings.Builder
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.deleted {
+			b.WriteRune(n.r)
+		}
+	}
+	return b.String()
+}
+
+// IDs returns the IDs of the characters of the text, in order.
+func (d *Doc) IDs() []ID {
+	ids := make([]ID, 0, d.size)
+	for n := d.head.next; n != nil; n = n.next {
+		if !n.deleted {
+			ids = append(ids, n.id)
+		}
+	}
+	return ids
+}
+
+// Ready reports whether everything op depends on has been applied.
+func (d *Doc) Ready(op Op) bool {
+	switch op.Type {
+	case OpInsert:
+		if op.After.IsZero() {
+			return true
+		}
+		_, ok := d.nodes[op.After]
+		return ok
+	case OpDelete:
+		_, ok := d.nodes[op.ID]
+		return ok
+	}
+	return false
+}
+
+// Apply applies an operation from any replica. Applying an operation twice
+// has no effect. An operation that is not ready waits until the ones it
+// depends on arrive.
+func (d *Doc) Apply(op Op) error {
+	if err := op.Validate(); err != nil {
+		return err
+	}
+	if d.overlaps(op) {
+		return fmt.Errorf("%w: insert reuses ids", ErrInvalidOp)
+	}
+	if !d.Ready(op) {
+		if len(d.pending) >= maxPending {
+			return ErrMissingDependency
+		}
+		d.pending = append(d.pending, op)
+		return nil
+	}
+
+	d.apply(op)
+	d.drain()
+	return nil
+}
+
+// drain applies the pending operations that have become ready, until none
+// does.
+func (d *Doc) drain() {
+	for progress := true; progress; {
+		progress = false
+		rest := d.pending[:0]
+		for _, op := range d.pending {
+			if d.Ready(op) {
+				d.apply(op)
+				progress = true
+			} else {
+				rest = append(rest, op)
+			}
+		}
+		d.pending = rest
+	}
+}
+
+func (d *Doc) apply(op Op) {
+	switch op.Type {
+	case OpInsert:
+		d.integrate(op)
+	case OpDelete:
+		n := d.nodes[op.ID]
+		if !n.deleted {
+			n.deleted = true
+			d.size--
+		}
+	}
+}
+
+// overlaps reports whether an insert that has not been applied yet would
+// give its characters IDs that are already taken.
+func (d *Doc) overlaps(op Op) bool {
+	if op.Type != OpInsert {
+		return false
+	}
+	if _, ok := d.nodes[op.ID]; ok {
+		return false
+	}
+	id := op.ID
+	for range utf8.RuneCountInString(op.Text) - 1 {
+		id.Clock++
+		if _, ok := d.nodes[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Doc) integrate(op Op) {
+	if _, ok := d.nodes[op.ID]; ok || d.overlaps(op) {
+		return
+	}
+
+	prev := &d.head
+	if !op.After.IsZero() {
+		prev = d.nodes[op.After]
+	}
+
+	id := op.ID
+	for _, r := range op.Text {
+		for prev.next != nil && prev.next.id.greater(id) {
+			prev = prev.next
+		}
+		n := &node{id: id, r: r, next: prev.next}
+		prev.next = n
+		d.nodes[id] = n
+		d.size++
+		prev = n
+		id.Clock++
+	}
+	d.clock = max(d.clock, id.Clock-1)
+}
+
+// Insert inserts text before the character at pos and returns the operation
+// to send to other replicas.
+func (d *Doc) Insert(pos int, text string) (Op, error) {
+	if pos < 0 || pos > d.size {
+		return Op{}, ErrOutOfRange
+	}
+
+	var after ID
+	if pos > 0 {
+		after = d.visible(pos - 1).id
+	}
+	return d.InsertAfter(after, text)
+}
+
+// InsertAfter inserts text right after the character after, which may be
+// deleted, or at the start for the zero ID.
+func (d *Doc) InsertAfter(after ID, text string) (Op, error) {
+	op := Op{Type: OpInsert, ID: ID{Clock: d.clock + 1, Site: d.site}, After: after, Text: text}
+	if err := op.Validate(); err != nil {
+		return Op{}, err
+	}
+	if !d.Ready(op) {
+		return Op{}, ErrMissingDependency
+	}
+
+	d.apply(op)
+	return op, nil
+}
+
+// Delete deletes n characters starting at pos and returns the operations to
+// send to other replicas.
+func (d *Doc) Delete(pos int, n int) ([]Op, error) {
+	if pos < 0 || n < 0 || pos+n > d.size {
+		return nil, ErrOutOfRange
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	ops := make([]Op, 0, n)
+	for node := d.visible(pos); len(ops) < n; node = node.next {
+		if !node.deleted {
+			ops = append(ops, Op{Type: OpDelete, ID: node.id})
+		}
+	}
+	for _, op := range ops {
+		d.apply(op)
+	}
+	return ops, nil
+}
+
+// visible returns the character at pos, which must be in range.
+func (d *Doc) visible(pos int) *node {
+	i := 0
+	for n := d.head.next; n != nil; n = n.next {
+		if n.deleted {
+			continue
+		}
+		if i == pos {
+			return n
+		}
+		i++
+	}
+	return nil
+}
+
+// State is a snapshot of a replica, tombstones included. Runs group
+// characters inserted one after another by the same site.
+type State struct {
+	Clock int   `json:"clock"`
+	Runs  []Run `json:"runs"`
+}
+
+type Run struct {
+	ID      ID     `json:"id"`
+	Text    string `json:"text"`
+	Deleted bool   `json:"deleted,omitempty"`
+}
+
+// State returns a snapshot that FromState turns back into an equal replica.
+// Operations still waiting for their dependencies are not part of it.
+func (d *Doc) State() State {
+	s := State{Clock: d.clock, Runs: []Run{}}
+
+	var (
+		run  *Run
+		text strings.Builder
+		last ID
+	)
+	for n := d.head.next; n != nil; n = n.next {
+		if run != nil && n.id.Site == last.Site && n.id.Clock == last.Clock+1 && n.deleted == run.Deleted {
+			text.WriteRune(n.r)
+			last = n.id
+			continue
+		}
+		if run != nil {
+			run.Text = text.String()
+			s.Runs = append(s.Runs, *run)
+		}
+		run = &Run{ID: n.id, Deleted: n.deleted}
+		text.Reset()
+		text.WriteRune(n.r)
+		last = n.id
+	}
+	if run != nil {
+		run.Text = text.String()
+		s.Runs = append(s.Runs, *run)
+	}
+	return s
+}
+
+// FromState rebuilds a replica from a snapshot. Its local edits are made as
+// site.
+func FromState(site string, s State) (*Doc, error) {
+	d := New(site)
+	d.clock = s.Clock
+
+	prev := &d.head
+	for _, run := range s.Runs {
+		if run.ID.Clock <= 0 || run.ID.Site == "" || run.Text == "" || !utf8.ValidString(run.Text) {
+			return nil, ErrInvalidState
+		}
+		id := run.ID
+		for _, r := range run.Text {
+			if _, ok := d.nodes[id]; ok || id.Clock > s.Clock {
+				return nil, ErrInvalidState
+			}
+			n := &node{id: id, r: r, deleted: run.Deleted}
+			prev.next = n
+			prev = n
+			d.nodes[id] = n
+			if !run.Deleted {
+				d.size++
+			}
+			id.Clock++
+		}
+	}
+	return d, nil
+}
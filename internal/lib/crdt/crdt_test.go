@@ -0,0 +1,277 @@
+package crdt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LocalEdits(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	d := New("a")
+	model := []rune{}
+
+	for i := 0; i < 2000; i++ {
+		if len(model) > 0 && rnd.Intn(3) == 0 {
+			pos := rnd.Intn(len(model))
+			n := 1 + rnd.Intn(min(5, len(model)-pos))
+			ops, err := d.Delete(pos, n)
+			require.NoError(t, err)
+			assert.Len(t, ops, n)
+			model = append(model[:pos], model[pos+n:]...)
+		} else {
+			pos := rnd.Intn(len(model) + 1)
+			text := randomText(rnd)
+			_, err := d.Insert(pos, text)
+			require.NoError(t, err)
+			model = append(model[:pos], append([]rune(text), model[pos:]...)...)
+		}
+		require.Equal(t, string(model), d.Text())
+		require.Equal(t, len(model), d.Len())
+	}
+}
+
+func Test_ConcurrentInserts(t *testing.T) {
+	a, b := New("a"), New("b")
+	base, err := a.Insert(0, "[]")
+	require.NoError(t, err)
+	require.NoError(t, b.Apply(base))
+
+	opA, err := a.Insert(1, "abc")
+	require.NoError(t, err)
+	opB, err := b.Insert(1, "xyz")
+	require.NoError(t, err)
+	require.NoError(t, a.Apply(opB))
+	require.NoError(t, b.Apply(opA))
+
+	assert.Equal(t, a.Text(), b.Text())
+	assert.Contains(t, []string{"[abcxyz]", "[xyzabc]"}, a.Text(), "runs must not interleave")
+}
+
+func Test_ConcurrentDeleteAndInsert(t *testing.T) {
+	a, b := New("a"), New("b")
+	base, err := a.Insert(0, "hello world")
+	require.NoError(t, err)
+	require.NoError(t, b.Apply(base))
+
+	dels, err := a.Delete(5, 6)
+	require.NoError(t, err)
+	ins, err := b.Insert(11, "!")
+	require.NoError(t, err)
+
+	for _, op := range dels {
+		require.NoError(t, b.Apply(op))
+	}
+	require.NoError(t, a.Apply(ins))
+
+	assert.Equal(t, "hello!", a.Text())
+	assert.Equal(t, "hello!", b.Text())
+}
+
+func Test_OutOfOrderDelivery(t *testing.T) {
+	a, b := New("a"), New("b")
+	first, err := a.Insert(0, "ab")
+	require.NoError(t, err)
+	second, err := a.Insert(2, "cd")
+	require.NoError(t, err)
+	dels, err := a.Delete(1, 2)
+	require.NoError(t, err)
+
+	for _, op := range dels {
+		require.NoError(t, b.Apply(op))
+	}
+	require.NoError(t, b.Apply(second))
+	assert.Equal(t, "", b.Text())
+	assert.Equal(t, 3, b.Pending())
+
+	require.NoError(t, b.Apply(first))
+	require.NoError(t, b.Apply(first))
+	assert.Equal(t, 0, b.Pending())
+	assert.Equal(t, "ad", b.Text())
+	assert.Equal(t, a.Text(), b.Text())
+}
+
+// Test_Convergence edits random replicas concurrently and delivers every
+// operation to every other replica in a random order, some more than once.
+func Test_Convergence(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(seed))
+
+			docs := make([]*Doc, 2+rnd.Intn(4))
+			inbox := make([][]Op, len(docs))
+			for i := range docs {
+				docs[i] = New(fmt.Sprintf("site%d", i))
+			}
+
+			deliver := func(i int) {
+				k := rnd.Intn(len(inbox[i]))
+				op := inbox[i][k]
+				if rnd.Intn(10) != 0 {
+					inbox[i] = append(inbox[i][:k], inbox[i][k+1:]...)
+				}
+				require.NoError(t, docs[i].Apply(op))
+			}
+
+			for step := 0; step < 300; step++ {
+				i := rnd.Intn(len(docs))
+				if len(inbox[i]) > 0 && rnd.Intn(2) == 0 {
+					deliver(i)
+					continue
+				}
+
+				ops := randomEdit(t, rnd, docs[i])
+				for j := range docs {
+					if j != i {
+						inbox[j] = append(inbox[j], ops...)
+					}
+				}
+			}
+
+			for i := range docs {
+				for len(inbox[i]) > 0 {
+					deliver(i)
+				}
+				assert.Equal(t, 0, docs[i].Pending())
+			}
+			for _, d := range docs[1:] {
+				require.Equal(t, docs[0].Text(), d.Text())
+				require.Equal(t, docs[0].IDs(), d.IDs())
+			}
+		})
+	}
+}
+
+func Test_State(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	a, b := New("a"), New("b")
+	for i := 0; i < 200; i++ {
+		for _, op := range randomEdit(t, rnd, a) {
+			require.NoError(t, b.Apply(op))
+		}
+		for _, op := range randomEdit(t, rnd, b) {
+			require.NoError(t, a.Apply(op))
+		}
+	}
+
+	data, err := json.Marshal(a.State())
+	require.NoError(t, err)
+	var s State
+	require.NoError(t, json.Unmarshal(data, &s))
+
+	c, err := FromState("c", s)
+	require.NoError(t, err)
+	assert.Equal(t, a.Text(), c.Text())
+	assert.Equal(t, a.IDs(), c.IDs())
+	assert.Equal(t, a.Clock(), c.Clock())
+	assert.Equal(t, a.State(), c.State())
+
+	// The restored replica keeps converging with the others.
+	opC, err := c.Insert(c.Len()/2, "from c")
+	require.NoError(t, err)
+	opA, err := a.Insert(0, "from a")
+	require.NoError(t, err)
+	require.NoError(t, a.Apply(opC))
+	require.NoError(t, c.Apply(opA))
+	assert.Equal(t, a.Text(), c.Text())
+}
+
+func Test_FromStateInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+	}{
+		{name: "zero id", state: State{Clock: 1, Runs: []Run{{Text: "a"}}}},
+		{name: "empty run", state: State{Clock: 1, Runs: []Run{{ID: ID{1, "a"}}}}},
+		{name: "duplicate id", state: State{Clock: 2, Runs: []Run{{ID: ID{1, "a"}, Text: "ab"}, {ID: ID{2, "a"}, Text: "c"}}}},
+		{name: "clock behind", state: State{Clock: 1, Runs: []Run{{ID: ID{1, "a"}, Text: "ab"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromState("x", tt.state)
+			assert.ErrorIs(t, err, ErrInvalidState)
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    Op
+		valid bool
+	}{
+		{name: "insert", op: Op{Type: OpInsert, ID: ID{1, "a"}, Text: "x"}, valid: true},
+		{name: "insert after", op: Op{Type: OpInsert, ID: ID{2, "a"}, After: ID{1, "b"}, Text: "x"}, valid: true},
+		{name: "delete", op: Op{Type: OpDelete, ID: ID{1, "a"}}, valid: true},
+		{name: "no site", op: Op{Type: OpInsert, ID: ID{Clock: 1}, Text: "x"}},
+		{name: "no clock", op: Op{Type: OpDelete, ID: ID{Site: "a"}}},
+		{name: "empty insert", op: Op{Type: OpInsert, ID: ID{1, "a"}}},
+		{name: "invalid utf8", op: Op{Type: OpInsert, ID: ID{1, "a"}, Text: "\xff"}},
+		{name: "invalid after", op: Op{Type: OpInsert, ID: ID{1, "a"}, After: ID{Site: "b"}, Text: "x"}},
+		{name: "delete with text", op: Op{Type: OpDelete, ID: ID{1, "a"}, Text: "x"}},
+		{name: "unknown type", op: Op{Type: "move", ID: ID{1, "a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.op.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidOp)
+			}
+		})
+	}
+}
+
+func Test_OutOfRange(t *testing.T) {
+	d := New("a")
+	_, err := d.Insert(1, "x")
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	_, err = d.Insert(0, "ab")
+	require.NoError(t, err)
+	_, err = d.Delete(1, 2)
+	assert.ErrorIs(t, err, ErrOutOfRange)
+	_, err = d.InsertAfter(ID{5, "b"}, "x")
+	assert.ErrorIs(t, err, ErrMissingDependency)
+}
+
+func Test_OverlappingInsert(t *testing.T) {
+	d := New("a")
+	first, err := d.Insert(0, "abc")
+	require.NoError(t, err)
+
+	require.NoError(t, d.Apply(first), "a replayed insert is ignored")
+	require.NoError(t, d.Apply(Op{Type: OpInsert, ID: ID{5, "c"}, Text: "q"}))
+	err = d.Apply(Op{Type: OpInsert, ID: ID{4, "c"}, Text: "rs"})
+	assert.ErrorIs(t, err, ErrInvalidOp)
+	assert.Equal(t, "qabc", d.Text())
+}
+
+func randomEdit(t *testing.T, rnd *rand.Rand, d *Doc) []Op {
+	t.Helper()
+	if d.Len() > 0 && rnd.Intn(3) == 0 {
+		pos := rnd.Intn(d.Len())
+		ops, err := d.Delete(pos, 1+rnd.Intn(min(3, d.Len()-pos)))
+		require.NoError(t, err)
+		return ops
+	}
+	op, err := d.Insert(rnd.Intn(d.Len()+1), randomText(rnd))
+	require.NoError(t, err)
+	return []Op{op}
+}
+
+func randomText(rnd *rand.Rand) string {
+	const alphabet = "abcdefgh ñé\n"
+	runes := []rune(alphabet)
+	text := make([]rune, 1+rnd.Intn(4))
+	for i := range text {
+		text[i] = runes[rnd.Intn(len(runes))]
+	}
+	return string(text)
+}
@@ -0,0 +1,277 @@
+// Package diff computes line-based differences between two texts and formats
+// them as unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Op int
+
+const (
+	Equal Op = iota
+	Delete
+	Insert
+)
+
+// Edit is one line of an edit script turning a into b.
+type Edit struct {
+	Op   Op
+	Line string
+}
+
+// MaxLines is the most lines, both texts together, that Lines searches for a
+// shortest edit script once their common start and end are set aside. Larger
+// differences are replaced whole, which bounds the time a diff takes.
+const MaxLines = 10000
+
+// Lines returns a shortest edit script from a to b using the linear-space
+// variant of Myers' algorithm: it takes O((n+m)·d) time and O(n+m) memory for
+// texts of n and m lines that differ in d lines.
+func Lines(a, b []string) []Edit {
+	edits := make([]Edit, 0, max(len(a), len(b)))
+
+	prefix := commonPrefix(a, b)
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := commonSuffix(a, b)
+
+	middleA, middleB := a[:len(a)-suffix], b[:len(b)-suffix]
+	if len(middleA)+len(middleB) > MaxLines {
+		edits = replace(edits, middleA, middleB)
+	} else {
+		edits = compare(edits, middleA, middleB)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	return edits
+}
+
+// compare appends a shortest edit script from a to b to edits.
+func compare(edits []Edit, a, b []string) []Edit {
+	prefix := commonPrefix(a, b)
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := commonSuffix(a, b)
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if len(a) == 0 || len(b) == 0 {
+		edits = replace(edits, a, b)
+	} else {
+		x, y := middleSnake(a, b)
+		edits = compare(edits, a[:x], b[:y])
+		edits = compare(edits, a[x:], b[y:])
+	}
+
+	for _, line := range common {
+		edits = append(edits, Edit{Op: Equal, Line: line})
+	}
+	return edits
+}
+
+// middleSnake runs Myers' search from both ends of a and b at once and
+// returns a point on a shortest edit path where the two searches meet. a and
+// b must be non-empty and differ in their first and last lines.
+func middleSnake(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// vf[offset+k] is the furthest x reached on diagonal k from the start;
+	// vb[offset+k] the furthest distance reached from the end.
+	vf := make([]int, 2*maxD+2)
+	vb := make([]int, 2*maxD+2)
+	for i := range vf {
+		vf[i], vb[i] = -1, -1
+	}
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonals that ran off the edit graph are not searched again.
+	var fStart, fEnd, bStart, bEnd int
+
+	for d := 0; d < maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if kb := offset + delta - k; kb >= 0 && kb < len(vb) && vb[kb] != -1 && x >= n-vb[kb] {
+					return x, y
+				}
+			}
+		}
+
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1] == b[m-y-1] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if kf := offset + delta - k; kf >= 0 && kf < len(vf) && vf[kf] != -1 {
+					fx := vf[kf]
+					if fx >= n-x {
+						return fx, fx - (kf - offset)
+					}
+				}
+			}
+		}
+	}
+
+	// The searches always meet; this is only reached for inputs that
+	// break the preconditions.
+	return n, 0
+}
+
+// replace appends an edit script deleting all of a and inserting all of b.
+func replace(edits []Edit, a, b []string) []Edit {
+	for _, line := range a {
+		edits = append(edits, Edit{Op: Delete, Line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Op: Insert, Line: line})
+	}
+	return edits
+}
+
+func commonPrefix(a, b []string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func commonSuffix(a, b []string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[len(a)-1-i] == b[len(b)-1-i] {
+		i++
+	}
+	return i
+}
+
+// SplitLines splits text into lines without their terminating newlines. A
+// trailing newline does not start an extra empty line.
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Unified formats the differences between a and b as a unified diff with the
+// given number of context lines. It returns an empty string when the texts
+// have the same lines.
+func Unified(fromName, toName, a, b string, context int) string {
+	edits := Lines(SplitLines(a), SplitLines(b))
+
+	// Line positions in a and b before each edit.
+	posA := make([]int, len(edits)+1)
+	posB := make([]int, len(edits)+1)
+	var changes []int
+	for i, e := range edits {
+		posA[i+1], posB[i+1] = posA[i], posB[i]
+		if e.Op != Insert {
+			posA[i+1]++
+		}
+		if e.Op != Delete {
+			posB[i+1]++
+		}
+		if e.Op != Equal {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		// Extend the hunk while the next change is close enough for the
+		// context of both to overlap.
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*context+1 {
+			j++
+		}
+
+		start := changes[i] - context
+		if start < 0 {
+			start = 0
+		}
+		end := changes[j] + context + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(posA[start], posA[end]-posA[start]),
+			hunkRange(posB[start], posB[end]-posB[start]))
+		for _, e := range edits[start:end] {
+			switch e.Op {
+			case Equal:
+				sb.WriteString(" ")
+			case Delete:
+				sb.WriteString("-")
+			case Insert:
+				sb.WriteString("+")
+			}
+			sb.WriteString(e.Line)
+			sb.WriteString("\n")
+		}
+
+		i = j + 1
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats a hunk range the way GNU diff does: an empty range points
+// at the line before it and a single line omits the count.
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,133 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Unified(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name: "identical",
+			a:    "one\ntwo\n",
+			b:    "one\ntwo",
+		},
+		{
+			name:     "from empty",
+			a:        "",
+			b:        "one\ntwo\n",
+			expected: "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name:     "to empty",
+			a:        "one\n",
+			b:        "",
+			expected: "--- a\n+++ b\n@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name:     "changed line with context",
+			a:        "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:        "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			expected: "--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "distant changes make separate hunks",
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\n7\n8\nB\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n" +
+				"@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+B\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    "a\n1\n2\n3\n4\n5\n6\nb\n",
+			b:    "A\n1\n2\n3\n4\n5\n6\nB\n",
+			expected: "--- a\n+++ b\n" +
+				"@@ -1,8 +1,8 @@\n-a\n+A\n 1\n 2\n 3\n 4\n 5\n 6\n-b\n+B\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Unified("a", "b", tc.a, tc.b, 3))
+		})
+	}
+}
+
+func Test_LinesIsMinimalAndComplete(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rnd.Intn(12))
+		for i := range lines {
+			lines[i] = string(rune('a' + rnd.Intn(3)))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		edits := Lines(a, b)
+
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range edits {
+			if e.Op != Insert {
+				gotA = append(gotA, e.Line)
+			}
+			if e.Op != Delete {
+				gotB = append(gotB, e.Line)
+			}
+			if e.Op != Equal {
+				changes++
+			}
+		}
+
+		assert.Equal(t, strings.Join(a, ","), strings.Join(gotA, ","))
+		assert.Equal(t, strings.Join(b, ","), strings.Join(gotB, ","))
+		assert.Equal(t, len(a)+len(b)-2*lcs(a, b), changes, "a=%v b=%v", a, b)
+	}
+}
+
+func lcs(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return dp[0][0]
+}
+
+func Test_LinesLargeDifference(t *testing.T) {
+	a := []string{"head"}
+	b := []string{"head"}
+	for i := 0; i <= MaxLines/2; i++ {
+		a = append(a, fmt.Sprintf("a%d", i))
+		b = append(b, fmt.Sprintf("b%d", i))
+	}
+	a = append(a, "tail")
+	b = append(b, "tail")
+
+	edits := Lines(a, b)
+	if assert.Len(t, edits, len(a)+len(b)-2) {
+		assert.Equal(t, Edit{Op: Equal, Line: "head"}, edits[0])
+		assert.Equal(t, Edit{Op: Delete, Line: "a0"}, edits[1])
+		assert.Equal(t, Edit{Op: Insert, Line: "b0"}, edits[len(a)-1])
+		assert.Equal(t, Edit{Op: Equal, Line: "tail"}, edits[len(edits)-1])
+	}
+}
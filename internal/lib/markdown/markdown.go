@@ -0,0 +1,60 @@
+// Package markdown renders note content to HTML that is safe to embed in a
+// page.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(
+			extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+			extension.TaskList,
+			extension.Strikethrough,
+			extension.Linkify,
+		),
+	)
+
+	policy = newPolicy()
+)
+
+// newPolicy allows the markup goldmark produces for CommonMark and GFM and
+// nothing else: no scripts, styles, event handlers or javascript: URLs.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+
+	// Task list items render as disabled checkboxes.
+	p.AllowElements("input")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").Matching(regexp.MustCompile(`^(|checked|disabled)$`)).OnElements("input")
+
+	// Fenced code blocks keep their language for client-side highlighting.
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+
+	// Table column alignment.
+	p.AllowAttrs("align").Matching(regexp.MustCompile(`^(left|center|right)$`)).OnElements("th", "td")
+
+	return p
+}
+
+// Render converts CommonMark with GitHub tables, task lists, strikethrough and
+// autolinks to sanitized HTML. Raw HTML in the source is dropped.
+func Render(src string) (string, error) {
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(buf.String()), nil
+}
+
+// RenderPlain escapes plain text and keeps its line breaks.
+func RenderPlain(src string) string {
+	return "<pre>" + html.EscapeString(src) + "</pre>\n"
+}
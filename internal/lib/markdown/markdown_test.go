@@ -0,0 +1,76 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Render(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "commonmark",
+			src:      "# Title\n\nSome *emphasis* and `code`.\n\n```go\nx := 1\n```\n",
+			contains: []string{"<h1>Title</h1>", "<em>emphasis</em>", "<code>code</code>", `<code class="language-go">x := 1`},
+		},
+		{
+			name:     "table with alignment",
+			src:      "| a | b |\n|:-|-:|\n| 1 | 2 |\n",
+			contains: []string{"<table>", `<th align="left">a</th>`, `<td align="right">2</td>`},
+		},
+		{
+			name:     "task list",
+			src:      "- [x] done\n- [ ] todo\n",
+			contains: []string{`<input checked="" disabled="" type="checkbox"> done`, `<input disabled="" type="checkbox"> todo`},
+		},
+		{
+			name:     "strikethrough and autolink",
+			src:      "~~old~~ https://example.com\n",
+			contains: []string{"<del>old</del>", `<a href="https://example.com" rel="nofollow">`},
+		},
+		{
+			name:     "raw html is dropped",
+			src:      "<script>alert(1)</script>\n\n<div onclick=\"x()\">hi</div>\n",
+			excludes: []string{"<script", "alert", "onclick", "<div"},
+		},
+		{
+			name:     "inline html is dropped",
+			src:      "text <img src=x onerror=alert(1)> <b>bold</b>\n",
+			excludes: []string{"onerror", "<img", "<b>"},
+		},
+		{
+			name:     "javascript links are removed",
+			src:      "[click](javascript:alert(1)) ![img](javascript:alert(2))\n",
+			contains: []string{"click"},
+			excludes: []string{"javascript:", "href"},
+		},
+		{
+			name:     "unsafe code class is removed",
+			src:      "```x\" onmouseover=\"alert(1)\ncode\n```\n",
+			excludes: []string{"onmouseover"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := Render(tc.src)
+			assert.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, out, s)
+			}
+			for _, s := range tc.excludes {
+				assert.False(t, strings.Contains(out, s), "output %q must not contain %q", out, s)
+			}
+		})
+	}
+}
+
+func Test_RenderPlain(t *testing.T) {
+	assert.Equal(t, "<pre>a &lt;b&gt;\nc &amp; d</pre>\n", RenderPlain("a <b>\nc & d"))
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"gonotes/internal/links/entity"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type LinkResponse struct {
+	Title  string `json:"title"`
+	NoteID *int   `json:"note_id"`
+	Broken bool   `json:"broken"`
+}
+
+func (lr *LinkResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewLinkListResponse(links []entity.Link) []render.Renderer {
+	list := make([]render.Renderer, len(links))
+	for i, l := range links {
+		list[i] = &LinkResponse{
+			Title:  l.Title,
+			NoteID: l.NoteID,
+			Broken: l.Broken,
+		}
+	}
+	return list
+}
+
+type NoteRefResponse struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func (nr *NoteRefResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewNoteRefListResponse(refs []entity.NoteRef) []render.Renderer {
+	list := make([]render.Renderer, len(refs))
+	for i, ref := range refs {
+		list[i] = &NoteRefResponse{ID: ref.ID, Title: ref.Title}
+	}
+	return list
+}
+
+// GraphResponse lists the user's notes as nodes and the links between them as
+// edges from source to target note. Links to titles no note has are listed
+// under broken.
+type GraphResponse struct {
+	Nodes  []entity.NoteRef    `json:"nodes"`
+	Edges  []entity.Edge       `json:"edges"`
+	Broken []entity.BrokenLink `json:"broken"`
+}
+
+func (gr *GraphResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewGraphResponse(g *entity.Graph) *GraphResponse {
+	return &GraphResponse{
+		Nodes:  g.Nodes,
+		Edges:  g.Edges,
+		Broken: g.Broken,
+	}
+}
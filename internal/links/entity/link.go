@@ -0,0 +1,33 @@
+package entity
+
+// Link is a wiki link from a note. NoteID is the note the title resolves to,
+// when the user can see it. Broken links point to a title no note has.
+type Link struct {
+	Title  string `json:"title"`
+	NoteID *int   `json:"note_id"`
+	Broken bool   `json:"broken"`
+}
+
+// NoteRef names a note in link listings and the graph.
+type NoteRef struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type Edge struct {
+	Source int `json:"source"`
+	Target int `json:"target"`
+}
+
+// BrokenLink is a link from Source to a title no note has.
+type BrokenLink struct {
+	Source int    `json:"source"`
+	Title  string `json:"title"`
+}
+
+// Graph is a user's notes and the wiki links between them.
+type Graph struct {
+	Nodes  []NoteRef    `json:"nodes"`
+	Edges  []Edge       `json:"edges"`
+	Broken []BrokenLink `json:"broken"`
+}
@@ -0,0 +1,125 @@
+package linkshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/links/dto"
+	"gonotes/internal/links/storage"
+	"gonotes/internal/middleware"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.LinkRepository
+}
+
+func NewHandler(log *slog.Logger, storage storage.LinkRepository) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// Links lists the wiki links of a note, marking the ones no note matches as
+// broken.
+func (h *Handler) Links(w http.ResponseWriter, r *http.Request) {
+	const op = "links.handler.links"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	links, err := h.storage.Links(noteID, userID)
+	if err != nil {
+		h.renderError(w, r, log, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewLinkListResponse(links))
+
+	log.Info("links retrieved", slog.Int("note_id", noteID), slog.Int("count", len(links)), slog.Int("user_id", userID))
+}
+
+// Backlinks lists the notes that link to a note.
+func (h *Handler) Backlinks(w http.ResponseWriter, r *http.Request) {
+	const op = "links.handler.backlinks"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	refs, err := h.storage.Backlinks(noteID, userID)
+	if err != nil {
+		h.renderError(w, r, log, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewNoteRefListResponse(refs))
+
+	log.Info("backlinks retrieved", slog.Int("note_id", noteID), slog.Int("count", len(refs)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Graph(w http.ResponseWriter, r *http.Request) {
+	const op = "links.handler.graph"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	g, err := h.storage.Graph(userID)
+	if err != nil {
+		log.Error("failed to build graph", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewGraphResponse(g))
+
+	log.Info("graph retrieved", slog.Int("nodes", len(g.Nodes)), slog.Int("edges", len(g.Edges)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) renderError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrNoteNotFound) {
+		log.Error("note not found", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		return
+	}
+	log.Error("failed to get links", slog.Any("err", err))
+	render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+}
@@ -0,0 +1,136 @@
+package linkshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/links/dto"
+	"gonotes/internal/links/entity"
+	"gonotes/internal/links/linkshandler"
+	"gonotes/internal/links/storage"
+	"gonotes/internal/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockLinksRepo struct {
+	graphErr error
+}
+
+func (m *mockLinksRepo) Links(noteID, userID int) ([]entity.Link, error) {
+	if noteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	target := 2
+	return []entity.Link{{Title: "Target", NoteID: &target}, {Title: "Missing", Broken: true}}, nil
+}
+
+func (m *mockLinksRepo) Backlinks(noteID, userID int) ([]entity.NoteRef, error) {
+	if noteID != 2 {
+		return nil, storage.ErrNoteNotFound
+	}
+	return []entity.NoteRef{{ID: 1, Title: "Source"}}, nil
+}
+
+func (m *mockLinksRepo) Graph(userID int) (*entity.Graph, error) {
+	if m.graphErr != nil {
+		return nil, m.graphErr
+	}
+	return &entity.Graph{
+		Nodes:  []entity.NoteRef{{ID: 1, Title: "Source"}, {ID: 2, Title: "Target"}},
+		Edges:  []entity.Edge{{Source: 1, Target: 2}},
+		Broken: []entity.BrokenLink{{Source: 1, Title: "Missing"}},
+	}, nil
+}
+
+func newRequest(target, noteID string, withUser bool) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", noteID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_Links(t *testing.T) {
+	tests := []struct {
+		name           string
+		noteID         string
+		withUser       bool
+		expectedStatus int
+	}{
+		{name: "found", noteID: "1", withUser: true, expectedStatus: http.StatusOK},
+		{name: "note not found", noteID: "3", withUser: true, expectedStatus: http.StatusNotFound},
+		{name: "invalid id", noteID: "x", withUser: true, expectedStatus: http.StatusBadRequest},
+		{name: "unauthorized", noteID: "1", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := linkshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLinksRepo{})
+
+			rec := httptest.NewRecorder()
+			h.Links(rec, newRequest("/notes/"+tc.noteID+"/links", tc.noteID, tc.withUser))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusOK {
+				var resp []dto.LinkResponse
+				_ = json.NewDecoder(rec.Body).Decode(&resp)
+				if assert.Len(t, resp, 2) {
+					assert.Equal(t, 2, *resp[0].NoteID)
+					assert.False(t, resp[0].Broken)
+					assert.Nil(t, resp[1].NoteID)
+					assert.True(t, resp[1].Broken)
+				}
+			}
+		})
+	}
+}
+
+func Test_Backlinks(t *testing.T) {
+	h := linkshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLinksRepo{})
+
+	rec := httptest.NewRecorder()
+	h.Backlinks(rec, newRequest("/notes/2/backlinks", "2", true))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []dto.NoteRefResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	assert.Equal(t, []dto.NoteRefResponse{{ID: 1, Title: "Source"}}, resp)
+
+	rec = httptest.NewRecorder()
+	h.Backlinks(rec, newRequest("/notes/1/backlinks", "1", true))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_Graph(t *testing.T) {
+	h := linkshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLinksRepo{})
+
+	rec := httptest.NewRecorder()
+	h.Graph(rec, newRequest("/graph", "", true))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp dto.GraphResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	assert.Len(t, resp.Nodes, 2)
+	assert.Equal(t, []entity.Edge{{Source: 1, Target: 2}}, resp.Edges)
+	assert.Equal(t, []entity.BrokenLink{{Source: 1, Title: "Missing"}}, resp.Broken)
+
+	h = linkshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockLinksRepo{graphErr: errors.New("db error")})
+	rec = httptest.NewRecorder()
+	h.Graph(rec, newRequest("/graph", "", true))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.Graph(rec, newRequest("/graph", "", false))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,173 @@
+package linkssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/links/entity"
+	"gonotes/internal/links/storage"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+)
+
+// resolvedTarget is the note the link l from note n points to, or NULL.
+const resolvedTarget = "(SELECT MIN(t.id) FROM notes t WHERE t.user_id = n.user_id AND t.deleted_at IS NULL AND t.title = l.title COLLATE NOCASE)"
+
+type LinkRepository struct {
+	db *sql.DB
+}
+
+func NewLinkRepository(db *sql.DB) *LinkRepository {
+	return &LinkRepository{db: db}
+}
+
+// Links returns the links of a note in the order they appear in it.
+func (r *LinkRepository) Links(noteID int, userID int) ([]entity.Link, error) {
+	const op = "links.sqlite.Links"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT title, target, target IN (SELECT id FROM notes WHERE user_id = ?) OR target IN (SELECT note_id FROM note_shares WHERE user_id = ?)
+		FROM (
+			SELECT l.position, l.title, `+resolvedTarget+` AS target
+			FROM note_links l
+			JOIN notes n ON n.id = l.note_id
+			WHERE l.note_id = ?
+		)
+		ORDER BY position`, userID, userID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := []entity.Link{}
+	for rows.Next() {
+		var (
+			link    entity.Link
+			target  sql.NullInt64
+			visible sql.NullBool
+		)
+		if err := rows.Scan(&link.Title, &target, &visible); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		link.Broken = !target.Valid
+		if target.Valid && visible.Bool {
+			id := int(target.Int64)
+			link.NoteID = &id
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+// Backlinks returns the notes the user can see that link to a note, by
+// title.
+func (r *LinkRepository) Backlinks(noteID int, userID int) ([]entity.NoteRef, error) {
+	const op = "links.sqlite.Backlinks"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT n.id, n.title
+		FROM notes target
+		JOIN note_links l ON l.title = target.title
+		JOIN notes n ON n.id = l.note_id
+		WHERE target.id = ?
+			AND n.user_id = target.user_id
+			AND n.deleted_at IS NULL
+			AND (n.user_id = ? OR n.id IN (SELECT note_id FROM note_shares WHERE user_id = ?))
+			AND `+resolvedTarget+` = target.id
+		ORDER BY n.title COLLATE NOCASE, n.id`, noteID, userID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	refs := []entity.NoteRef{}
+	for rows.Next() {
+		var ref entity.NoteRef
+		if err := rows.Scan(&ref.ID, &ref.Title); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		refs = append(refs, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return refs, nil
+}
+
+// Graph returns the user's own notes outside the trash and the links between
+// them.
+func (r *LinkRepository) Graph(userID int) (*entity.Graph, error) {
+	const op = "links.sqlite.Graph"
+
+	g := &entity.Graph{Nodes: []entity.NoteRef{}, Edges: []entity.Edge{}, Broken: []entity.BrokenLink{}}
+
+	rows, err := r.db.Query("SELECT id, title FROM notes WHERE user_id = ? AND deleted_at IS NULL ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ref entity.NoteRef
+		if err := rows.Scan(&ref.ID, &ref.Title); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		g.Nodes = append(g.Nodes, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	links, err := r.db.Query(`
+		SELECT l.note_id, l.title, `+resolvedTarget+`
+		FROM note_links l
+		JOIN notes n ON n.id = l.note_id
+		WHERE n.user_id = ? AND n.deleted_at IS NULL
+		ORDER BY l.note_id, l.position`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer links.Close()
+
+	for links.Next() {
+		var (
+			source int
+			title  string
+			target sql.NullInt64
+		)
+		if err := links.Scan(&source, &title, &target); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if target.Valid {
+			g.Edges = append(g.Edges, entity.Edge{Source: source, Target: int(target.Int64)})
+		} else {
+			g.Broken = append(g.Broken, entity.BrokenLink{Source: source, Title: title})
+		}
+	}
+	if err := links.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return g, nil
+}
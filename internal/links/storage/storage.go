@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"gonotes/internal/links/entity"
+	notesstorage "gonotes/internal/notes/storage"
+)
+
+var ErrNoteNotFound = notesstorage.ErrNoteNotFound
+
+// LinkRepository reads the wiki links between notes. Links are written by the
+// note repository as notes change.
+//
+// A link resolves in the namespace of the note's owner, to the oldest of
+// their notes outside the trash whose title matches, ignoring case.
+type LinkRepository interface {
+	Links(noteID int, userID int) ([]entity.Link, error)
+	Backlinks(noteID int, userID int) ([]entity.NoteRef, error)
+	Graph(userID int) (*entity.Graph, error)
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"gonotes/internal/lib/crdt"
+)
+
+// MaxEditOps caps how many operations one edit.ops message may carry.
+const MaxEditOps = 500
+
+// Message types a client sends. TypeEditOps is sent by the server as well.
+const (
+	TypeSubscribe   = "subscribe"
+	TypeUnsubscribe = "unsubscribe"
+	TypeEditJoin    = "edit.join"
+	TypeEditOps     = "edit.ops"
+	TypeEditLeave   = "edit.leave"
+)
+
+// Message types the server sends.
+const (
+	TypeSubscribed   = "subscribed"
+	TypeUnsubscribed = "unsubscribed"
+	TypePresence     = "presence"
+	TypeEvent        = "event"
+	TypeEditState    = "edit.state"
+	TypeEditLeft     = "edit.left"
+	TypeError        = "error"
+)
+
+// ClientMessage subscribes to or unsubscribes from a note, or joins, edits
+// and leaves its editing session. Ops are set on edit.ops only.
+type ClientMessage struct {
+	Type   string    `json:"type"`
+	NoteID int       `json:"note_id"`
+	Ops    []crdt.Op `json:"ops"`
+}
+
+func (m *ClientMessage) Validate() error {
+	switch m.Type {
+	case TypeSubscribe, TypeUnsubscribe, TypeEditJoin, TypeEditOps, TypeEditLeave:
+	default:
+		return fmt.Errorf("unknown message type %q", m.Type)
+	}
+	if m.NoteID <= 0 {
+		return fmt.Errorf("note_id is required")
+	}
+
+	if m.Type != TypeEditOps {
+		if len(m.Ops) > 0 {
+			return fmt.Errorf("ops are only allowed on %s", TypeEditOps)
+		}
+		return nil
+	}
+	if len(m.Ops) == 0 {
+		return fmt.Errorf("ops are required")
+	}
+	if len(m.Ops) > MaxEditOps {
+		return fmt.Errorf("at most %d ops are allowed", MaxEditOps)
+	}
+	for i, op := range m.Ops {
+		if err := op.Validate(); err != nil {
+			return fmt.Errorf("ops[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ServerMessage is one message sent to a client. Viewers lists the users
+// currently subscribed to the note, the client's own user included. ID, Event
+// and Data are set on events: Data is the same JSON as a webhook body and ID
+// can be passed as Last-Event-ID to /events.
+//
+// Site, State and ReadOnly are set on edit.state: the client loads State and
+// makes its own edits as Site. edit.state is sent again with the same Site
+// when the user gains or loses edit access during the session. Ops and UserID are set on edit.ops; UserID is
+// zero for changes the server merged in from outside the session.
+type ServerMessage struct {
+	Type     string          `json:"type"`
+	NoteID   int             `json:"note_id,omitempty"`
+	Viewers  []int           `json:"viewers,omitempty"`
+	ID       int             `json:"id,omitempty"`
+	Event    string          `json:"event,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	Site     string          `json:"site,omitempty"`
+	State    *crdt.State     `json:"state,omitempty"`
+	ReadOnly bool            `json:"read_only,omitempty"`
+	UserID   int             `json:"user_id,omitempty"`
+	Ops      []crdt.Op       `json:"ops,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+func NewErrorMessage(noteID int, err error) ServerMessage {
+	return ServerMessage{Type: TypeError, NoteID: noteID, Error: err.Error()}
+}
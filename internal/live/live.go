@@ -0,0 +1,219 @@
+// Package live tracks which notes each WebSocket connection is subscribed to
+// and who is viewing every note, so editors can show each other's presence.
+package live
+
+import (
+	"errors"
+	"gonotes/internal/live/dto"
+	"sort"
+	"sync"
+)
+
+const (
+	// outboxSize is how many messages a connection may lag behind before it
+	// is dropped.
+	outboxSize = 64
+	// MaxSubscriptions caps how many notes one connection can subscribe to.
+	MaxSubscriptions = 100
+)
+
+var ErrTooManySubscriptions = errors.New("too many subscriptions")
+
+// Client is one connection of a user.
+type Client struct {
+	userID int
+	// notes is guarded by the hub's mutex.
+	notes map[int]struct{}
+	out   chan dto.ServerMessage
+}
+
+func (c *Client) UserID() int {
+	return c.userID
+}
+
+// Messages is closed when the hub drops the client, because it fell behind,
+// was unregistered or the server is shutting down.
+func (c *Client) Messages() <-chan dto.ServerMessage {
+	return c.out
+}
+
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+	notes   map[int]map[*Client]struct{}
+	closed  bool
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		clients: map[*Client]struct{}{},
+		notes:   map[int]map[*Client]struct{}{},
+	}
+}
+
+func (h *Hub) Register(userID int) *Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := &Client{userID: userID, notes: map[int]struct{}{}, out: make(chan dto.ServerMessage, outboxSize)}
+	if h.closed {
+		close(c.out)
+		return c
+	}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Unregister drops the client's subscriptions and tells the remaining
+// viewers of those notes.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(c)
+}
+
+// Join subscribes c to a note and sends it the note's viewers. When c's user
+// was not viewing the note yet, the other viewers are told as well. The caller
+// checks that the user can see the note.
+func (h *Hub) Join(c *Client, noteID int) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return nil
+	}
+	if _, ok := c.notes[noteID]; !ok {
+		if len(c.notes) >= MaxSubscriptions {
+			return ErrTooManySubscriptions
+		}
+		viewing := h.viewing(noteID, c.userID)
+		c.notes[noteID] = struct{}{}
+		if h.notes[noteID] == nil {
+			h.notes[noteID] = map[*Client]struct{}{}
+		}
+		h.notes[noteID][c] = struct{}{}
+		if !viewing {
+			h.announce(noteID, c)
+		}
+	}
+	h.send(c, dto.ServerMessage{Type: dto.TypeSubscribed, NoteID: noteID, Viewers: h.viewers(noteID)})
+	return nil
+}
+
+// Leave unsubscribes c from a note. When that was the user's last connection
+// viewing it, the other viewers are told.
+func (h *Hub) Leave(c *Client, noteID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	if _, ok := c.notes[noteID]; ok {
+		h.remove(c, noteID)
+	}
+	h.send(c, dto.ServerMessage{Type: dto.TypeUnsubscribed, NoteID: noteID})
+}
+
+// Watching reports whether c is subscribed to the note.
+func (h *Hub) Watching(c *Client, noteID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := c.notes[noteID]
+	return ok
+}
+
+// Send queues a message for c without blocking.
+func (h *Hub) Send(c *Client, msg dto.ServerMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; ok {
+		h.send(c, msg)
+	}
+}
+
+// Close drops every client and refuses new ones. It is meant to run when the
+// server shuts down, since open connections would otherwise hold it up.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for c := range h.clients {
+		h.drop(c)
+	}
+}
+
+// send queues msg for c, dropping c when it has fallen behind; h.mu must be
+// held.
+func (h *Hub) send(c *Client, msg dto.ServerMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		h.drop(c)
+	}
+}
+
+// drop removes and closes a client; h.mu must be held.
+func (h *Hub) drop(c *Client) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	for noteID := range c.notes {
+		h.remove(c, noteID)
+	}
+	close(c.out)
+}
+
+// remove unsubscribes c from a note and tells the other viewers if its user
+// has left; h.mu must be held.
+func (h *Hub) remove(c *Client, noteID int) {
+	delete(c.notes, noteID)
+	viewers := h.notes[noteID]
+	delete(viewers, c)
+	if len(viewers) == 0 {
+		delete(h.notes, noteID)
+		return
+	}
+	if !h.closed && !h.viewing(noteID, c.userID) {
+		h.announce(noteID, nil)
+	}
+}
+
+// announce sends the note's viewers to every client subscribed to it except
+// skip; h.mu must be held.
+func (h *Hub) announce(noteID int, skip *Client) {
+	viewers := h.viewers(noteID)
+	for c := range h.notes[noteID] {
+		if c != skip {
+			h.send(c, dto.ServerMessage{Type: dto.TypePresence, NoteID: noteID, Viewers: viewers})
+		}
+	}
+}
+
+// viewers returns the IDs of the users subscribed to a note; h.mu must be
+// held.
+func (h *Hub) viewers(noteID int) []int {
+	seen := map[int]bool{}
+	var ids []int
+	for c := range h.notes[noteID] {
+		if !seen[c.userID] {
+			seen[c.userID] = true
+			ids = append(ids, c.userID)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (h *Hub) viewing(noteID int, userID int) bool {
+	for c := range h.notes[noteID] {
+		if c.userID == userID {
+			return true
+		}
+	}
+	return false
+}
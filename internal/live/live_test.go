@@ -0,0 +1,129 @@
+package live
+
+import (
+	"gonotes/internal/live/dto"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// next returns the client's next queued message, failing when there is none.
+func next(t *testing.T, c *Client) dto.ServerMessage {
+	t.Helper()
+
+	select {
+	case msg, ok := <-c.Messages():
+		require.True(t, ok, "client dropped")
+		return msg
+	default:
+		t.Fatal("no message queued")
+		return dto.ServerMessage{}
+	}
+}
+
+func assertNone(t *testing.T, c *Client) {
+	t.Helper()
+	assert.Empty(t, c.out)
+}
+
+func Test_HubPresence(t *testing.T) {
+	h := NewHub()
+	alice := h.Register(1)
+	bob := h.Register(2)
+	bobTab := h.Register(2)
+
+	require.NoError(t, h.Join(alice, 10))
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeSubscribed, NoteID: 10, Viewers: []int{1}}, next(t, alice))
+
+	require.NoError(t, h.Join(bob, 10))
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeSubscribed, NoteID: 10, Viewers: []int{1, 2}}, next(t, bob))
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypePresence, NoteID: 10, Viewers: []int{1, 2}}, next(t, alice))
+
+	// A second connection of a viewing user does not change who is viewing.
+	require.NoError(t, h.Join(bobTab, 10))
+	assert.Equal(t, []int{1, 2}, next(t, bobTab).Viewers)
+	assertNone(t, alice)
+	assertNone(t, bob)
+
+	// Joining twice is answered but not announced.
+	require.NoError(t, h.Join(bob, 10))
+	assert.Equal(t, dto.TypeSubscribed, next(t, bob).Type)
+	assertNone(t, alice)
+
+	h.Leave(bob, 10)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeUnsubscribed, NoteID: 10}, next(t, bob))
+	assertNone(t, alice)
+	assert.False(t, h.Watching(bob, 10))
+	assert.True(t, h.Watching(bobTab, 10))
+
+	h.Unregister(bobTab)
+	_, ok := <-bobTab.Messages()
+	assert.False(t, ok)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypePresence, NoteID: 10, Viewers: []int{1}}, next(t, alice))
+
+	h.Leave(alice, 10)
+	next(t, alice)
+	assert.Empty(t, h.notes)
+}
+
+func Test_HubSubscriptionLimit(t *testing.T) {
+	h := NewHub()
+	c := h.Register(1)
+
+	for id := 1; id <= MaxSubscriptions; id++ {
+		require.NoError(t, h.Join(c, id))
+		next(t, c)
+	}
+	assert.ErrorIs(t, h.Join(c, MaxSubscriptions+1), ErrTooManySubscriptions)
+	require.NoError(t, h.Join(c, 1))
+}
+
+func Test_HubDropsSlowClients(t *testing.T) {
+	h := NewHub()
+	slow := h.Register(1)
+	other := h.Register(2)
+	require.NoError(t, h.Join(other, 10))
+	next(t, other)
+
+	for range outboxSize {
+		h.Send(slow, dto.ServerMessage{Type: dto.TypeError})
+	}
+	require.NoError(t, h.Join(slow, 10))
+
+	for range outboxSize {
+		<-slow.Messages()
+	}
+	_, ok := <-slow.Messages()
+	assert.False(t, ok)
+	assert.False(t, h.Watching(slow, 10))
+	assert.Equal(t, []int{2}, h.viewers(10))
+
+	// The slow client joined and was dropped; the other viewer saw both.
+	assert.Equal(t, []int{1, 2}, next(t, other).Viewers)
+	assert.Equal(t, []int{2}, next(t, other).Viewers)
+}
+
+func Test_HubClose(t *testing.T) {
+	h := NewHub()
+	a := h.Register(1)
+	b := h.Register(2)
+	require.NoError(t, h.Join(a, 10))
+	require.NoError(t, h.Join(b, 10))
+	next(t, a)
+	next(t, a)
+	next(t, b)
+
+	h.Close()
+
+	// Nobody is told about viewers leaving while everyone is.
+	for _, c := range []*Client{a, b} {
+		_, ok := <-c.Messages()
+		assert.False(t, ok)
+	}
+
+	late := h.Register(3)
+	_, ok := <-late.Messages()
+	assert.False(t, ok)
+	require.NoError(t, h.Join(late, 10))
+}
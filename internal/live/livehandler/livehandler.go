@@ -0,0 +1,224 @@
+package livehandler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/collab"
+	"gonotes/internal/feed"
+	"gonotes/internal/live"
+	"gonotes/internal/live/dto"
+	"gonotes/internal/middleware"
+	notesstorage "gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	maxMessageSize = 64 << 10
+)
+
+type Handler struct {
+	log          *slog.Logger
+	notes        notesstorage.NoteRepository
+	hub          *live.Hub
+	feed         *feed.Hub
+	sessions     *collab.Manager
+	pingInterval time.Duration
+	upgrader     websocket.Upgrader
+}
+
+// NewHandler returns a handler that pings every connection each pingInterval
+// and closes it when no pong comes back within two intervals. Note events are
+// taken from feed, so a user only hears about notes they could see when the
+// event was recorded. Editing sessions are run by sessions.
+func NewHandler(log *slog.Logger, notes notesstorage.NoteRepository, hub *live.Hub, feed *feed.Hub, sessions *collab.Manager, pingInterval time.Duration) *Handler {
+	return &Handler{
+		log:          log,
+		notes:        notes,
+		hub:          hub,
+		feed:         feed,
+		sessions:     sessions,
+		pingInterval: pingInterval,
+		upgrader: websocket.Upgrader{
+			// Requests are authenticated with a bearer token rather than a
+			// cookie, so another origin cannot connect on a user's behalf.
+			CheckOrigin: func(*http.Request) bool { return true },
+		},
+	}
+}
+
+// Serve upgrades the request to a WebSocket and runs it until either side
+// closes it. Clients subscribe to notes and are sent their events and who
+// else is viewing them, and edit subscribed notes together.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	const op = "live.handler.serve"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	// Upgrade replies with an error itself.
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error("failed to upgrade connection", slog.Any("err", err))
+		return
+	}
+	defer conn.Close()
+
+	client := h.hub.Register(userID)
+	defer h.hub.Unregister(client)
+	sub := h.feed.Subscribe(userID)
+	defer h.feed.Unsubscribe(sub)
+
+	log.Info("connection opened", slog.Int("user_id", userID))
+
+	go h.read(conn, client, userID, log)
+	h.write(conn, client, sub)
+
+	log.Info("connection closed", slog.Int("user_id", userID))
+}
+
+// read handles the client's messages until the connection fails, then
+// unregisters the client so write returns.
+func (h *Handler) read(conn *websocket.Conn, client *live.Client, userID int, log *slog.Logger) {
+	defer h.hub.Unregister(client)
+	defer h.sessions.Disconnect(client)
+
+	pongWait := 2 * h.pingInterval
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				log.Error("failed to read message", slog.Any("err", err))
+			}
+			return
+		}
+
+		var msg dto.ClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			h.hub.Send(client, dto.NewErrorMessage(0, fmt.Errorf("invalid message")))
+			continue
+		}
+		if err := msg.Validate(); err != nil {
+			h.hub.Send(client, dto.NewErrorMessage(msg.NoteID, err))
+			continue
+		}
+
+		switch msg.Type {
+		case dto.TypeSubscribe:
+			h.subscribe(client, userID, msg.NoteID, log)
+		case dto.TypeUnsubscribe:
+			h.sessions.Leave(client, msg.NoteID)
+			h.hub.Leave(client, msg.NoteID)
+		case dto.TypeEditJoin:
+			h.joinEdit(client, msg.NoteID, log)
+		case dto.TypeEditOps:
+			if err := h.sessions.Apply(client, msg.NoteID, msg.Ops); err != nil {
+				h.hub.Send(client, dto.NewErrorMessage(msg.NoteID, err))
+			}
+		case dto.TypeEditLeave:
+			h.sessions.Leave(client, msg.NoteID)
+			h.hub.Send(client, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: msg.NoteID})
+		}
+	}
+}
+
+func (h *Handler) subscribe(client *live.Client, userID int, noteID int, log *slog.Logger) {
+	if _, err := h.notes.Get(noteID, userID); err != nil {
+		if errors.Is(err, notesstorage.ErrNoteNotFound) {
+			h.hub.Send(client, dto.NewErrorMessage(noteID, err))
+			return
+		}
+		log.Error("failed to get note", slog.Int("note_id", noteID), slog.Any("err", err))
+		h.hub.Send(client, dto.NewErrorMessage(noteID, fmt.Errorf("internal error")))
+		return
+	}
+
+	if err := h.hub.Join(client, noteID); err != nil {
+		h.hub.Send(client, dto.NewErrorMessage(noteID, err))
+	}
+}
+
+func (h *Handler) joinEdit(client *live.Client, noteID int, log *slog.Logger) {
+	if !h.hub.Watching(client, noteID) {
+		h.hub.Send(client, dto.NewErrorMessage(noteID, fmt.Errorf("subscribe to the note before editing it")))
+		return
+	}
+
+	if err := h.sessions.Join(client, noteID); err != nil {
+		if errors.Is(err, notesstorage.ErrNoteNotFound) {
+			h.hub.Send(client, dto.NewErrorMessage(noteID, err))
+			return
+		}
+		log.Error("failed to join editing session", slog.Int("note_id", noteID), slog.Any("err", err))
+		h.hub.Send(client, dto.NewErrorMessage(noteID, fmt.Errorf("internal error")))
+	}
+}
+
+// write sends queued messages, events for subscribed notes and pings until
+// the hub drops the client or the feed drops its subscription.
+func (h *Handler) write(conn *websocket.Conn, client *live.Client, sub *feed.Subscription) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case msg, ok := <-client.Messages():
+			if !ok {
+				closeConn(conn)
+				return
+			}
+			err = writeJSON(conn, msg)
+		case ev, ok := <-sub.Events():
+			if !ok {
+				closeConn(conn)
+				return
+			}
+			if !h.hub.Watching(client, ev.NoteID) {
+				continue
+			}
+			err = writeJSON(conn, dto.ServerMessage{
+				Type:   dto.TypeEvent,
+				NoteID: ev.NoteID,
+				ID:     ev.ID,
+				Event:  string(ev.Type),
+				Data:   ev.Payload,
+			})
+		case <-ticker.C:
+			err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func writeJSON(conn *websocket.Conn, v any) error {
+	conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(v)
+}
+
+// closeConn tells the client the server is going away, which covers both a
+// shutdown and a client that fell behind; either way it should reconnect.
+func closeConn(conn *websocket.Conn) {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+	conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+}
@@ -0,0 +1,264 @@
+package livehandler_test
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/collab"
+	collabentity "gonotes/internal/collab/entity"
+	collabstorage "gonotes/internal/collab/storage"
+	"gonotes/internal/events"
+	"gonotes/internal/feed"
+	feedentity "gonotes/internal/feed/entity"
+	"gonotes/internal/lib/crdt"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/live"
+	"gonotes/internal/live/dto"
+	"gonotes/internal/live/livehandler"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// getNote lets user 1 see notes 1 and 2 and user 2 see note 1 only.
+func getNote(id int, userID int) (*entity.Note, error) {
+	switch {
+	case id == 1, id == 2 && userID == 1:
+		return &entity.Note{ID: id}, nil
+	case id == 3:
+		return nil, errors.New("database is locked")
+	}
+	return nil, storage.ErrNoteNotFound
+}
+
+// mockStore has no snapshots and lets only user 1 edit.
+type mockStore struct{}
+
+func (mockStore) Load(noteID int) (*collabentity.Snapshot, error) {
+	return nil, collabstorage.ErrSnapshotNotFound
+}
+
+func (mockStore) Save(s *collabentity.Snapshot) error {
+	return nil
+}
+
+func (mockStore) CanEdit(noteID int, userID int) (bool, error) {
+	return userID == 1, nil
+}
+
+type server struct {
+	url  string
+	hub  *live.Hub
+	feed *feed.Hub
+}
+
+// newServer serves the handler with the user ID taken from the user query
+// parameter.
+func newServer(t *testing.T, pingInterval time.Duration) *server {
+	t.Helper()
+
+	log := slogdiscard.NewDiscardLogger()
+	s := &server{hub: live.NewHub(), feed: feed.NewHub()}
+	sessions := collab.New(log, &storagetest.NoteRepository{GetFunc: getNote}, mockStore{}, s.hub, events.NewBus(), time.Hour)
+	h := livehandler.NewHandler(log, &storagetest.NoteRepository{GetFunc: getNote}, s.hub, s.feed, sessions, pingInterval)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, err := strconv.Atoi(r.URL.Query().Get("user")); err == nil {
+			r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+		}
+		h.Serve(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	s.url = "ws" + strings.TrimPrefix(srv.URL, "http")
+	return s
+}
+
+func (s *server) dial(t *testing.T, userID int) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(s.url+"?user="+strconv.Itoa(userID), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func send(t *testing.T, conn *websocket.Conn, typ string, noteID int) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(dto.ClientMessage{Type: typ, NoteID: noteID}))
+}
+
+func receive(t *testing.T, conn *websocket.Conn) dto.ServerMessage {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg dto.ServerMessage
+	require.NoError(t, conn.ReadJSON(&msg))
+	return msg
+}
+
+func Test_SubscribeAndPresence(t *testing.T) {
+	s := newServer(t, time.Hour)
+	alice := s.dial(t, 1)
+	bob := s.dial(t, 2)
+
+	send(t, alice, dto.TypeSubscribe, 1)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeSubscribed, NoteID: 1, Viewers: []int{1}}, receive(t, alice))
+
+	send(t, bob, dto.TypeSubscribe, 1)
+	assert.Equal(t, []int{1, 2}, receive(t, bob).Viewers)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypePresence, NoteID: 1, Viewers: []int{1, 2}}, receive(t, alice))
+
+	send(t, bob, dto.TypeUnsubscribe, 1)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeUnsubscribed, NoteID: 1}, receive(t, bob))
+	assert.Equal(t, []int{1}, receive(t, alice).Viewers)
+
+	send(t, bob, dto.TypeSubscribe, 1)
+	receive(t, bob)
+	receive(t, alice)
+
+	// Closing the connection leaves every note.
+	bob.Close()
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypePresence, NoteID: 1, Viewers: []int{1}}, receive(t, alice))
+}
+
+func Test_SubscribeErrors(t *testing.T) {
+	s := newServer(t, time.Hour)
+	conn := s.dial(t, 2)
+
+	send(t, conn, dto.TypeSubscribe, 2)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeError, NoteID: 2, Error: storage.ErrNoteNotFound.Error()}, receive(t, conn))
+
+	send(t, conn, dto.TypeSubscribe, 3)
+	assert.Equal(t, "internal error", receive(t, conn).Error)
+
+	send(t, conn, "publish", 1)
+	assert.Equal(t, `unknown message type "publish"`, receive(t, conn).Error)
+
+	send(t, conn, dto.TypeSubscribe, 0)
+	assert.Equal(t, "note_id is required", receive(t, conn).Error)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+	assert.Equal(t, "invalid message", receive(t, conn).Error)
+
+	// The connection is still usable.
+	send(t, conn, dto.TypeSubscribe, 1)
+	assert.Equal(t, dto.TypeSubscribed, receive(t, conn).Type)
+}
+
+func Test_Events(t *testing.T) {
+	s := newServer(t, time.Hour)
+	conn := s.dial(t, 1)
+
+	send(t, conn, dto.TypeSubscribe, 1)
+	receive(t, conn)
+
+	s.feed.Broadcast([]feedentity.Event{
+		{ID: 7, UserID: 1, Type: events.NoteUpdated, NoteID: 2, Payload: []byte(`{}`)},
+		{ID: 8, UserID: 2, Type: events.NoteUpdated, NoteID: 1, Payload: []byte(`{}`)},
+		{ID: 9, UserID: 1, Type: events.NoteUpdated, NoteID: 1, Payload: []byte(`{"event":"note.updated"}`)},
+	})
+
+	assert.Equal(t, dto.ServerMessage{
+		Type:   dto.TypeEvent,
+		NoteID: 1,
+		ID:     9,
+		Event:  string(events.NoteUpdated),
+		Data:   []byte(`{"event":"note.updated"}`),
+	}, receive(t, conn))
+}
+
+func Test_Edit(t *testing.T) {
+	s := newServer(t, time.Hour)
+	alice := s.dial(t, 1)
+	bob := s.dial(t, 2)
+
+	send(t, alice, dto.TypeEditJoin, 1)
+	assert.Equal(t, "subscribe to the note before editing it", receive(t, alice).Error)
+
+	send(t, alice, dto.TypeSubscribe, 1)
+	receive(t, alice)
+	send(t, alice, dto.TypeEditJoin, 1)
+	state := receive(t, alice)
+	require.Equal(t, dto.TypeEditState, state.Type)
+	assert.False(t, state.ReadOnly)
+	require.NotNil(t, state.State)
+
+	send(t, bob, dto.TypeSubscribe, 1)
+	receive(t, bob)
+	receive(t, alice)
+	send(t, bob, dto.TypeEditJoin, 1)
+	assert.True(t, receive(t, bob).ReadOnly)
+
+	doc, err := crdt.FromState(state.Site, *state.State)
+	require.NoError(t, err)
+	op, err := doc.Insert(0, "hi")
+	require.NoError(t, err)
+	require.NoError(t, alice.WriteJSON(dto.ClientMessage{Type: dto.TypeEditOps, NoteID: 1, Ops: []crdt.Op{op}}))
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeEditOps, NoteID: 1, UserID: 1, Ops: []crdt.Op{op}}, receive(t, bob))
+
+	require.NoError(t, bob.WriteJSON(dto.ClientMessage{Type: dto.TypeEditOps, NoteID: 1, Ops: []crdt.Op{op}}))
+	assert.Equal(t, storage.ErrForbidden.Error(), receive(t, bob).Error)
+
+	send(t, alice, dto.TypeEditLeave, 1)
+	assert.Equal(t, dto.ServerMessage{Type: dto.TypeEditLeft, NoteID: 1}, receive(t, alice))
+	require.NoError(t, alice.WriteJSON(dto.ClientMessage{Type: dto.TypeEditOps, NoteID: 1, Ops: []crdt.Op{op}}))
+	assert.Equal(t, collab.ErrNotEditing.Error(), receive(t, alice).Error)
+
+	require.NoError(t, alice.WriteJSON(dto.ClientMessage{Type: dto.TypeEditOps, NoteID: 1}))
+	assert.Equal(t, "ops are required", receive(t, alice).Error)
+}
+
+func Test_Ping(t *testing.T) {
+	s := newServer(t, 50*time.Millisecond)
+	conn := s.dial(t, 1)
+
+	pinged := make(chan struct{}, 1)
+	conn.SetPingHandler(func(string) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	go conn.ReadMessage()
+
+	select {
+	case <-pinged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ping")
+	}
+}
+
+func Test_Shutdown(t *testing.T) {
+	s := newServer(t, time.Hour)
+	conn := s.dial(t, 1)
+
+	send(t, conn, dto.TypeSubscribe, 1)
+	receive(t, conn)
+
+	s.hub.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}
+
+func Test_Unauthorized(t *testing.T) {
+	s := newServer(t, time.Hour)
+
+	_, resp, err := websocket.DefaultDialer.Dial(s.url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
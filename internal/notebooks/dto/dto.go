@@ -0,0 +1,143 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"gonotes/internal/notebooks/entity"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const maxNameLength = 255
+
+type NotebookRequest struct {
+	Name     string `json:"name"`
+	ParentID *int   `json:"parent_id"`
+}
+
+func (n *NotebookRequest) Bind(r *http.Request) error {
+	name, err := normalizeName(n.Name)
+	if err != nil {
+		return err
+	}
+	n.Name = name
+	return nil
+}
+
+// NotebookPatchRequest renames and/or moves a notebook. A "parent_id" of null
+// moves the notebook to the top level, while omitting it keeps the parent.
+type NotebookPatchRequest struct {
+	Name      *string         `json:"name"`
+	RawParent json.RawMessage `json:"parent_id"`
+
+	MoveParent bool `json:"-"`
+	ParentID   *int `json:"-"`
+}
+
+func (n *NotebookPatchRequest) Bind(r *http.Request) error {
+	if n.Name != nil {
+		name, err := normalizeName(*n.Name)
+		if err != nil {
+			return err
+		}
+		n.Name = &name
+	}
+
+	if len(n.RawParent) > 0 {
+		n.MoveParent = true
+		if err := json.Unmarshal(n.RawParent, &n.ParentID); err != nil {
+			return fmt.Errorf("parent_id must be a notebook id or null")
+		}
+	}
+
+	if n.Name == nil && !n.MoveParent {
+		return fmt.Errorf("nothing to update")
+	}
+	return nil
+}
+
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("name is required")
+	}
+	if len(name) > maxNameLength {
+		return "", fmt.Errorf("name must be at most %d bytes", maxNameLength)
+	}
+	return name, nil
+}
+
+type NotebookResponse struct {
+	ID        int       `json:"id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (n *NotebookResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewNotebookResponse(nb *entity.Notebook) *NotebookResponse {
+	return &NotebookResponse{
+		ID:        nb.ID,
+		ParentID:  nb.ParentID,
+		Name:      nb.Name,
+		CreatedAt: nb.CreatedAt,
+	}
+}
+
+func NewNotebookListResponse(notebooks []entity.Notebook) []render.Renderer {
+	list := make([]render.Renderer, len(notebooks))
+	for i, nb := range notebooks {
+		list[i] = NewNotebookResponse(&nb)
+	}
+	return list
+}
+
+type NoteSummaryResponse struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+type TreeResponse struct {
+	*NotebookResponse
+	Notes    []NoteSummaryResponse `json:"notes"`
+	Children []*TreeResponse       `json:"children"`
+}
+
+func (t *TreeResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+// NewTreeResponse nests a flat subtree under its root notebook.
+func NewTreeResponse(rootID int, notebooks []entity.Notebook, notes []entity.NoteSummary) *TreeResponse {
+	nodes := make(map[int]*TreeResponse, len(notebooks))
+	for i := range notebooks {
+		nodes[notebooks[i].ID] = &TreeResponse{
+			NotebookResponse: NewNotebookResponse(&notebooks[i]),
+			Notes:            []NoteSummaryResponse{},
+			Children:         []*TreeResponse{},
+		}
+	}
+
+	for _, nb := range notebooks {
+		if nb.ID == rootID || nb.ParentID == nil {
+			continue
+		}
+		if parent, ok := nodes[*nb.ParentID]; ok {
+			parent.Children = append(parent.Children, nodes[nb.ID])
+		}
+	}
+
+	for _, n := range notes {
+		if node, ok := nodes[n.NotebookID]; ok {
+			node.Notes = append(node.Notes, NoteSummaryResponse{ID: n.ID, Title: n.Title})
+		}
+	}
+
+	return nodes[rootID]
+}
@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+type Notebook struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	ParentID  *int      `json:"parent_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NoteSummary identifies a note filed in a notebook.
+type NoteSummary struct {
+	ID         int    `json:"id"`
+	NotebookID int    `json:"notebook_id"`
+	Title      string `json:"title"`
+}
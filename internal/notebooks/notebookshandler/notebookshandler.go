@@ -0,0 +1,291 @@
+package notebookshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notebooks/dto"
+	"gonotes/internal/notebooks/entity"
+	"gonotes/internal/notebooks/storage"
+	notesstorage "gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.NotebookRepository
+	notes   notesstorage.NoteRepository
+	events  events.Publisher
+}
+
+func NewHandler(log *slog.Logger, storage storage.NotebookRepository, notes notesstorage.NoteRepository, pub events.Publisher) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		notes:   notes,
+		events:  pub,
+	}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.create"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	var req dto.NotebookRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	created, err := h.storage.Create(&entity.Notebook{UserID: userID, ParentID: req.ParentID, Name: req.Name})
+	if err != nil {
+		if errors.Is(err, storage.ErrParentNotFound) {
+			log.Error("parent notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+			return
+		}
+		log.Error("failed to create notebook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewNotebookResponse(created))
+
+	log.Info("notebook created", slog.Int("id", created.ID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.get"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notebookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	nb, err := h.storage.Get(notebookID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get notebook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNotebookResponse(nb))
+
+	log.Info("notebook retrieved", slog.Int("id", notebookID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.List(userID)
+	if err != nil {
+		log.Error("failed to list notebooks", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewNotebookListResponse(list))
+
+	log.Info("notebooks retrieved", slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.update"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notebookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.NotebookPatchRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	nb, err := h.storage.Get(notebookID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get notebook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	if req.Name != nil {
+		nb.Name = *req.Name
+	}
+	if req.MoveParent {
+		nb.ParentID = req.ParentID
+	}
+
+	updated, err := h.storage.Update(notebookID, userID, nb.Name, nb.ParentID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotebookNotFound):
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, storage.ErrParentNotFound), errors.Is(err, storage.ErrCycle):
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		default:
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		log.Error("failed to update notebook", slog.Any("err", err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNotebookResponse(updated))
+
+	log.Info("notebook updated", slog.Int("id", notebookID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Tree(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.tree"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notebookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	notebooks, notes, err := h.storage.Subtree(notebookID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get notebook tree", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewTreeResponse(notebookID, notebooks, notes))
+
+	log.Info("notebook tree retrieved", slog.Int("id", notebookID), slog.Int("notebooks", len(notebooks)), slog.Int("notes", len(notes)))
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "notebooks.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notebookID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	mode := storage.DeleteMode(r.URL.Query().Get("mode"))
+	switch mode {
+	case "":
+		mode = storage.DeleteReparent
+	case storage.DeleteReparent, storage.DeleteCascade:
+	default:
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("mode must be %s or %s", storage.DeleteReparent, storage.DeleteCascade)))
+		return
+	}
+
+	noteIDs, err := h.storage.Delete(notebookID, userID, mode)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to delete notebook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("notebook deleted", slog.Int("id", notebookID), slog.String("mode", string(mode)), slog.Int("notes", len(noteIDs)), slog.Int("user_id", userID))
+
+	// Cascading sends the notebook's notes to the trash; otherwise they only
+	// move to the parent notebook.
+	t := events.NoteUpdated
+	if mode == storage.DeleteCascade {
+		t = events.NoteDeleted
+	}
+	notes, err := h.notes.Lookup(userID, noteIDs)
+	if err != nil {
+		log.Error("failed to load notes for events", slog.Any("err", err))
+		return
+	}
+	for i := range notes {
+		h.events.Publish(events.NewNoteEvent(t, userID, &notes[i]))
+	}
+}
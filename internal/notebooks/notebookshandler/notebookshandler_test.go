@@ -0,0 +1,356 @@
+package notebookshandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notebooks/dto"
+	"gonotes/internal/notebooks/entity"
+	"gonotes/internal/notebooks/notebookshandler"
+	"gonotes/internal/notebooks/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockNotebooksRepo struct {
+	createFunc  func(nb *entity.Notebook) (*entity.Notebook, error)
+	getFunc     func(id, userID int) (*entity.Notebook, error)
+	listFunc    func(userID int) ([]entity.Notebook, error)
+	updateFunc  func(id, userID int, name string, parentID *int) (*entity.Notebook, error)
+	subtreeFunc func(id, userID int) ([]entity.Notebook, []entity.NoteSummary, error)
+	deleteFunc  func(id, userID int, mode storage.DeleteMode) ([]int, error)
+}
+
+func (m *mockNotebooksRepo) Create(nb *entity.Notebook) (*entity.Notebook, error) {
+	return m.createFunc(nb)
+}
+func (m *mockNotebooksRepo) Get(id, userID int) (*entity.Notebook, error) {
+	return m.getFunc(id, userID)
+}
+func (m *mockNotebooksRepo) List(userID int) ([]entity.Notebook, error) {
+	return m.listFunc(userID)
+}
+func (m *mockNotebooksRepo) Update(id, userID int, name string, parentID *int) (*entity.Notebook, error) {
+	return m.updateFunc(id, userID, name, parentID)
+}
+func (m *mockNotebooksRepo) Subtree(id, userID int) ([]entity.Notebook, []entity.NoteSummary, error) {
+	return m.subtreeFunc(id, userID)
+}
+func (m *mockNotebooksRepo) Delete(id, userID int, mode storage.DeleteMode) ([]int, error) {
+	return m.deleteFunc(id, userID, mode)
+}
+
+func newHandler(repo storage.NotebookRepository) *notebookshandler.Handler {
+	return notebookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.NoteRepository{LookupFunc: storagetest.LookupOwned}, events.NewBus())
+}
+
+func newRequest(method, target, urlID, body string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", urlID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupCtx       bool
+		body           string
+		mockCreate     func(nb *entity.Notebook) (*entity.Notebook, error)
+		expectedStatus int
+		expectedParent *int
+	}{
+		{
+			name:           "unauthorized",
+			body:           `{"name":"work"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing name",
+			setupCtx:       true,
+			body:           `{"name":"  "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "parent not found",
+			setupCtx: true,
+			body:     `{"name":"work","parent_id":9}`,
+			mockCreate: func(nb *entity.Notebook) (*entity.Notebook, error) {
+				return nil, storage.ErrParentNotFound
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "notebook created",
+			setupCtx: true,
+			body:     `{"name":" projects ","parent_id":2}`,
+			mockCreate: func(nb *entity.Notebook) (*entity.Notebook, error) {
+				nb.ID = 5
+				return nb, nil
+			},
+			expectedStatus: http.StatusCreated,
+			expectedParent: intPtr(2),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newHandler(&mockNotebooksRepo{createFunc: tc.mockCreate})
+
+			rec := httptest.NewRecorder()
+			h.Create(rec, newRequest(http.MethodPost, "/notebooks", "", tc.body, tc.setupCtx))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusCreated {
+				var resp dto.NotebookResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, 5, resp.ID)
+				assert.Equal(t, "projects", resp.Name)
+				assert.Equal(t, tc.expectedParent, resp.ParentID)
+			}
+		})
+	}
+}
+
+func Test_Update(t *testing.T) {
+	stored := func(id, userID int) (*entity.Notebook, error) {
+		return &entity.Notebook{ID: id, UserID: userID, ParentID: intPtr(2), Name: "old"}, nil
+	}
+
+	tests := []struct {
+		name           string
+		urlID          string
+		body           string
+		mockGet        func(id, userID int) (*entity.Notebook, error)
+		mockUpdateErr  error
+		expectedStatus int
+		expectedName   string
+		expectedParent *int
+	}{
+		{
+			name:           "invalid id",
+			urlID:          "abc",
+			body:           `{"name":"new"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "notebook not found",
+			urlID: "1",
+			body:  `{"name":"new"}`,
+			mockGet: func(id, userID int) (*entity.Notebook, error) {
+				return nil, storage.ErrNotebookNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "rename keeps parent",
+			urlID:          "1",
+			body:           `{"name":"new"}`,
+			mockGet:        stored,
+			expectedStatus: http.StatusOK,
+			expectedName:   "new",
+			expectedParent: intPtr(2),
+		},
+		{
+			name:           "null parent moves to top level",
+			urlID:          "1",
+			body:           `{"parent_id":null}`,
+			mockGet:        stored,
+			expectedStatus: http.StatusOK,
+			expectedName:   "old",
+		},
+		{
+			name:           "move below itself",
+			urlID:          "1",
+			body:           `{"parent_id":4}`,
+			mockGet:        stored,
+			mockUpdateErr:  storage.ErrCycle,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty name",
+			urlID:          "1",
+			body:           `{"name":""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &mockNotebooksRepo{
+				getFunc: tc.mockGet,
+				updateFunc: func(id, userID int, name string, parentID *int) (*entity.Notebook, error) {
+					if tc.mockUpdateErr != nil {
+						return nil, tc.mockUpdateErr
+					}
+					return &entity.Notebook{ID: id, UserID: userID, ParentID: parentID, Name: name}, nil
+				},
+			}
+			h := newHandler(repo)
+
+			rec := httptest.NewRecorder()
+			h.Update(rec, newRequest(http.MethodPatch, "/notebooks/"+tc.urlID, tc.urlID, tc.body, true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.NotebookResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, tc.expectedName, resp.Name)
+				assert.Equal(t, tc.expectedParent, resp.ParentID)
+			}
+		})
+	}
+}
+
+func Test_Tree(t *testing.T) {
+	repo := &mockNotebooksRepo{
+		subtreeFunc: func(id, userID int) ([]entity.Notebook, []entity.NoteSummary, error) {
+			if id != 1 {
+				return nil, nil, storage.ErrNotebookNotFound
+			}
+			return []entity.Notebook{
+				{ID: 1, Name: "root", ParentID: intPtr(7)},
+				{ID: 2, Name: "child", ParentID: intPtr(1)},
+				{ID: 3, Name: "grandchild", ParentID: intPtr(2)},
+			}, []entity.NoteSummary{
+				{ID: 10, NotebookID: 1, Title: "top"},
+				{ID: 11, NotebookID: 3, Title: "deep"},
+			}, nil
+		},
+	}
+	h := newHandler(repo)
+
+	rec := httptest.NewRecorder()
+	h.Tree(rec, newRequest(http.MethodGet, "/notebooks/1/tree", "1", "", true))
+	res := rec.Result()
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var tree dto.TreeResponse
+	_ = json.NewDecoder(res.Body).Decode(&tree)
+	assert.Equal(t, "root", tree.Name)
+	assert.Len(t, tree.Notes, 1)
+	if assert.Len(t, tree.Children, 1) && assert.Len(t, tree.Children[0].Children, 1) {
+		grandchild := tree.Children[0].Children[0]
+		assert.Equal(t, "grandchild", grandchild.Name)
+		assert.Equal(t, "deep", grandchild.Notes[0].Title)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Tree(rec, newRequest(http.MethodGet, "/notebooks/2/tree", "2", "", true))
+	assert.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+}
+
+func Test_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		mockErr        error
+		expectedStatus int
+		expectedMode   storage.DeleteMode
+		expectedEvent  events.Type
+	}{
+		{
+			name:           "defaults to reparent",
+			expectedStatus: http.StatusNoContent,
+			expectedMode:   storage.DeleteReparent,
+			expectedEvent:  events.NoteUpdated,
+		},
+		{
+			name:           "cascade",
+			query:          "?mode=cascade",
+			expectedStatus: http.StatusNoContent,
+			expectedMode:   storage.DeleteCascade,
+			expectedEvent:  events.NoteDeleted,
+		},
+		{
+			name:           "invalid mode",
+			query:          "?mode=shred",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "notebook not found",
+			mockErr:        storage.ErrNotebookNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "repo error",
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotMode storage.DeleteMode
+			repo := &mockNotebooksRepo{
+				deleteFunc: func(id, userID int, mode storage.DeleteMode) ([]int, error) {
+					gotMode = mode
+					if tc.mockErr != nil {
+						return nil, tc.mockErr
+					}
+					return []int{4, 7}, nil
+				},
+			}
+			pub := &storagetest.EventRecorder{}
+			h := notebookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.NoteRepository{LookupFunc: storagetest.LookupOwned}, pub)
+
+			rec := httptest.NewRecorder()
+			h.Delete(rec, newRequest(http.MethodDelete, "/notebooks/1"+tc.query, "1", "", true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+			if tc.expectedMode != "" {
+				assert.Equal(t, tc.expectedMode, gotMode)
+			}
+			published := pub.Events()
+			if tc.expectedEvent != "" {
+				if assert.Len(t, published, 2) {
+					for i, id := range []int{4, 7} {
+						assert.Equal(t, tc.expectedEvent, published[i].Type)
+						assert.Equal(t, id, published[i].Note.ID)
+					}
+				}
+			} else {
+				assert.Empty(t, published)
+			}
+			if res.StatusCode >= 400 {
+				var resp api.ErrResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NotEmpty(t, resp.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package notebookssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/notebooks/entity"
+	"gonotes/internal/notebooks/storage"
+	"strings"
+	"time"
+)
+
+const notebookColumns = "id, user_id, parent_id, name, created_at"
+
+// subtreeCTE selects the IDs of a notebook and all of its descendants.
+const subtreeCTE = `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM notebooks WHERE id = ? AND user_id = ?
+		UNION ALL
+		SELECT nb.id FROM notebooks nb JOIN subtree ON nb.parent_id = subtree.id
+	)`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func scanNotebook(s scanner) (*entity.Notebook, error) {
+	var (
+		nb       entity.Notebook
+		parentID sql.NullInt64
+	)
+	if err := s.Scan(&nb.ID, &nb.UserID, &parentID, &nb.Name, &nb.CreatedAt); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		nb.ParentID = &id
+	}
+	return &nb, nil
+}
+
+type NotebookRepository struct {
+	db *sql.DB
+}
+
+func NewNotebookRepository(db *sql.DB) *NotebookRepository {
+	return &NotebookRepository{db: db}
+}
+
+func (r *NotebookRepository) Create(nb *entity.Notebook) (*entity.Notebook, error) {
+	const op = "notebooks.sqlite.Create"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err = checkParent(tx, nb.UserID, nb.ParentID); err != nil {
+		if errors.Is(err, storage.ErrParentNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UTC()
+	res, err := tx.Exec("INSERT INTO notebooks(user_id, parent_id, name, created_at) VALUES (?, ?, ?, ?)", nb.UserID, nb.ParentID, nb.Name, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entity.Notebook{
+		ID:        int(id),
+		UserID:    nb.UserID,
+		ParentID:  nb.ParentID,
+		Name:      nb.Name,
+		CreatedAt: now,
+	}, nil
+}
+
+func (r *NotebookRepository) Get(id int, userID int) (*entity.Notebook, error) {
+	const op = "notebooks.sqlite.Get"
+
+	nb, err := scanNotebook(r.db.QueryRow("SELECT "+notebookColumns+" FROM notebooks WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotebookNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nb, nil
+}
+
+func (r *NotebookRepository) List(userID int) ([]entity.Notebook, error) {
+	const op = "notebooks.sqlite.List"
+
+	rows, err := r.db.Query("SELECT "+notebookColumns+" FROM notebooks WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notebooks := []entity.Notebook{}
+	for rows.Next() {
+		nb, err := scanNotebook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notebooks = append(notebooks, *nb)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notebooks, nil
+}
+
+// Update renames a notebook and moves it under parentID, or to the top level
+// when parentID is nil. Moving a notebook below itself returns ErrCycle.
+func (r *NotebookRepository) Update(id int, userID int, name string, parentID *int) (*entity.Notebook, error) {
+	const op = "notebooks.sqlite.Update"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := scanNotebook(tx.QueryRow("SELECT "+notebookColumns+" FROM notebooks WHERE id = ? AND user_id = ?", id, userID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotebookNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if parentID != nil {
+		if err = checkParent(tx, userID, parentID); err != nil {
+			if errors.Is(err, storage.ErrParentNotFound) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		var inside int
+		err = tx.QueryRow(subtreeCTE+" SELECT COUNT(*) FROM subtree WHERE id = ?", id, userID, *parentID).Scan(&inside)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if inside > 0 {
+			return nil, storage.ErrCycle
+		}
+	}
+
+	_, err = tx.Exec("UPDATE notebooks SET name = ?, parent_id = ? WHERE id = ? AND user_id = ?", name, parentID, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	nb, err := scanNotebook(tx.QueryRow("SELECT "+notebookColumns+" FROM notebooks WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nb, nil
+}
+
+// Subtree returns a notebook with all of its descendants and the notes filed
+// in any of them.
+func (r *NotebookRepository) Subtree(id int, userID int) ([]entity.Notebook, []entity.NoteSummary, error) {
+	const op = "notebooks.sqlite.Subtree"
+
+	rows, err := r.db.Query(subtreeCTE+`
+		SELECT `+notebookColumns+` FROM notebooks
+		WHERE id IN (SELECT id FROM subtree)
+		ORDER BY name, id`, id, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var notebooks []entity.Notebook
+	for rows.Next() {
+		nb, err := scanNotebook(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notebooks = append(notebooks, *nb)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(notebooks) == 0 {
+		return nil, nil, storage.ErrNotebookNotFound
+	}
+
+	noteRows, err := r.db.Query(subtreeCTE+`
+		SELECT id, notebook_id, title FROM notes
+		WHERE notebook_id IN (SELECT id FROM subtree) AND user_id = ? AND deleted_at IS NULL
+		ORDER BY title, id`, id, userID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer noteRows.Close()
+
+	notes := []entity.NoteSummary{}
+	for noteRows.Next() {
+		var n entity.NoteSummary
+		if err := noteRows.Scan(&n.ID, &n.NotebookID, &n.Title); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, n)
+	}
+	if err := noteRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notebooks, notes, nil
+}
+
+// Delete removes a notebook and returns the IDs of the notes it moved, to the
+// parent notebook or, in cascade mode, to the trash. Notes already in the
+// trash are moved along but not returned.
+func (r *NotebookRepository) Delete(id int, userID int, mode storage.DeleteMode) ([]int, error) {
+	const op = "notebooks.sqlite.Delete"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	nb, err := scanNotebook(tx.QueryRow("SELECT "+notebookColumns+" FROM notebooks WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNotebookNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var noteIDs []int
+	switch mode {
+	case storage.DeleteCascade:
+		noteIDs, err = deleteSubtree(tx, id, userID)
+	default:
+		noteIDs, err = reparent(tx, nb)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return noteIDs, nil
+}
+
+func reparent(tx *sql.Tx, nb *entity.Notebook) ([]int, error) {
+	noteIDs, err := queryIDs(tx, "SELECT id FROM notes WHERE notebook_id = ? AND user_id = ? AND deleted_at IS NULL ORDER BY id", nb.ID, nb.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec("UPDATE notebooks SET parent_id = ? WHERE parent_id = ? AND user_id = ?", nb.ParentID, nb.ID, nb.UserID); err != nil {
+		return nil, err
+	}
+	// Moving a note changes it like NoteRepository.Move does, so its ETag
+	// changes too.
+	if _, err := tx.Exec("UPDATE notes SET notebook_id = ?, updated_at = ?, version = version + 1 WHERE notebook_id = ? AND user_id = ?", nb.ParentID, time.Now().UTC(), nb.ID, nb.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM notebooks WHERE id = ? AND user_id = ?", nb.ID, nb.UserID); err != nil {
+		return nil, err
+	}
+	return noteIDs, nil
+}
+
+func deleteSubtree(tx *sql.Tx, id int, userID int) ([]int, error) {
+	nbIDs, err := queryIDs(tx, subtreeCTE+" SELECT id FROM subtree", id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	in := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(nbIDs)), ", ") + ")"
+	args := []any{}
+	for _, nbID := range nbIDs {
+		args = append(args, nbID)
+	}
+	args = append(args, userID)
+
+	noteIDs, err := queryIDs(tx, "SELECT id FROM notes WHERE deleted_at IS NULL AND notebook_id IN "+in+" AND user_id = ? ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Notes go to the trash at the top level, so restoring one does not
+	// point it at a notebook that no longer exists. Notes already in the
+	// trash move to the top level as well.
+	now := time.Now().UTC()
+	_, err = tx.Exec("UPDATE notes SET deleted_at = COALESCE(deleted_at, ?), notebook_id = NULL, updated_at = ?, version = version + 1 WHERE notebook_id IN "+in+" AND user_id = ?",
+		append([]any{now, now}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec("DELETE FROM notebooks WHERE id IN "+in+" AND user_id = ?", args...); err != nil {
+		return nil, err
+	}
+	return noteIDs, nil
+}
+
+func queryIDs(tx *sql.Tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func checkParent(q querier, userID int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+
+	var exists int
+	err := q.QueryRow("SELECT 1 FROM notebooks WHERE id = ? AND user_id = ?", *parentID, userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrParentNotFound
+	}
+	return err
+}
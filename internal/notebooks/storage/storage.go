@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/notebooks/entity"
+)
+
+var (
+	ErrNotebookNotFound = errors.New("notebook not found")
+	ErrParentNotFound   = errors.New("parent notebook not found")
+	ErrCycle            = errors.New("notebook cannot be moved into itself or its descendants")
+)
+
+// DeleteMode decides what happens to the contents of a deleted notebook.
+type DeleteMode string
+
+const (
+	// DeleteReparent hands child notebooks and notes to the parent notebook.
+	DeleteReparent DeleteMode = "reparent"
+	// DeleteCascade deletes the whole subtree and moves its notes to the trash.
+	DeleteCascade DeleteMode = "cascade"
+)
+
+type NotebookRepository interface {
+	Create(nb *entity.Notebook) (*entity.Notebook, error)
+	Get(id int, userID int) (*entity.Notebook, error)
+	List(userID int) ([]entity.Notebook, error)
+	Update(id int, userID int, name string, parentID *int) (*entity.Notebook, error)
+	Subtree(id int, userID int) ([]entity.Notebook, []entity.NoteSummary, error)
+	// Delete removes a notebook and returns the IDs of the notes that were
+	// moved out of it, to the parent notebook or to the trash.
+	Delete(id int, userID int, mode DeleteMode) ([]int, error)
+}
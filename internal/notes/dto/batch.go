@@ -0,0 +1,126 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+	tagentity "gonotes/internal/tags/entity"
+	"net/http"
+)
+
+const MaxBatchSize = 100
+
+const (
+	BatchModeAtomic  = "atomic"
+	BatchModePerItem = "per_item"
+)
+
+// BatchRequest is the body of POST /notes/batch. Mode is atomic (default),
+// where any failure rolls back every operation, or per_item, where each
+// operation stands on its own.
+type BatchRequest struct {
+	Mode       string                  `json:"mode"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+type BatchOperationRequest struct {
+	Op      string `json:"op"`
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+
+	Title      *string        `json:"title"`
+	Content    *string        `json:"content"`
+	Format     *entity.Format `json:"format"`
+	Tags       *[]string      `json:"tags"`
+	NotebookID *int           `json:"notebook_id"`
+
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+func (b *BatchRequest) Bind(r *http.Request) error {
+	switch b.Mode {
+	case "":
+		b.Mode = BatchModeAtomic
+	case BatchModeAtomic, BatchModePerItem:
+	default:
+		return fmt.Errorf("mode must be %s or %s", BatchModeAtomic, BatchModePerItem)
+	}
+
+	if len(b.Operations) == 0 {
+		return fmt.Errorf("operations are required")
+	}
+	if len(b.Operations) > MaxBatchSize {
+		return fmt.Errorf("at most %d operations are allowed", MaxBatchSize)
+	}
+
+	for i := range b.Operations {
+		if err := b.Operations[i].validate(); err != nil {
+			return fmt.Errorf("operations[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (o *BatchOperationRequest) validate() error {
+	if o.Format != nil && !o.Format.Valid() {
+		return errInvalidFormat
+	}
+	if o.Content != nil && *o.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if o.Tags != nil {
+		tags, err := tagentity.NormalizeNames(*o.Tags)
+		if err != nil {
+			return err
+		}
+		o.Tags = &tags
+	}
+
+	if o.Op != "create" && o.ID == 0 {
+		return fmt.Errorf("id is required")
+	}
+
+	switch o.Op {
+	case "create":
+		if o.Content == nil {
+			return fmt.Errorf("content is required")
+		}
+	case "update":
+		if o.Title == nil && o.Content == nil && o.Tags == nil && o.Format == nil {
+			return fmt.Errorf("nothing to update")
+		}
+	case "delete":
+	case "tag":
+		if len(o.Add) == 0 && len(o.Remove) == 0 {
+			return fmt.Errorf("add or remove is required")
+		}
+		var err error
+		if o.Add, err = tagentity.NormalizeNames(o.Add); err != nil {
+			return err
+		}
+		if o.Remove, err = tagentity.NormalizeNames(o.Remove); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("op must be create, update, delete or tag")
+	}
+	return nil
+}
+
+type BatchResultResponse struct {
+	Index  int           `json:"index"`
+	Op     string        `json:"op"`
+	Status int           `json:"status"`
+	Note   *NoteResponse `json:"note,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	// Committed reports whether any operation was saved.
+	Committed bool                  `json:"committed"`
+	Results   []BatchResultResponse `json:"results"`
+}
+
+func (br *BatchResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
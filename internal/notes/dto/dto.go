@@ -3,29 +3,93 @@ package dto
 import (
 	"fmt"
 	"gonotes/internal/notes/entity"
+	tagentity "gonotes/internal/tags/entity"
+	"html"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/render"
 )
 
+var errInvalidFormat = fmt.Errorf("format must be %s or %s", entity.FormatPlain, entity.FormatMarkdown)
+
 type NoteRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string   `json:"title"`
+	Content string   `json:"content"`
+	Tags    []string `json:"tags"`
+	// Format is plain or markdown. New notes default to plain and updates
+	// keep the current format when it is omitted.
+	Format entity.Format `json:"format"`
+	// NotebookID files a new note in a notebook; use MoveRequest to move
+	// existing notes.
+	NotebookID *int `json:"notebook_id"`
 }
 
 func (n *NoteRequest) Bind(r *http.Request) error {
 	if n.Content == "" {
 		return fmt.Errorf("content is required")
 	}
+	if n.Format != "" && !n.Format.Valid() {
+		return errInvalidFormat
+	}
+	tags, err := tagentity.NormalizeNames(n.Tags)
+	if err != nil {
+		return err
+	}
+	n.Tags = tags
+	return nil
+}
+
+type NotePatchRequest struct {
+	Title   *string        `json:"title"`
+	Content *string        `json:"content"`
+	Tags    *[]string      `json:"tags"`
+	Format  *entity.Format `json:"format"`
+}
+
+func (n *NotePatchRequest) Bind(r *http.Request) error {
+	if n.Title == nil && n.Content == nil && n.Tags == nil && n.Format == nil {
+		return fmt.Errorf("nothing to update")
+	}
+	if n.Format != nil && !n.Format.Valid() {
+		return errInvalidFormat
+	}
+	if n.Content != nil && *n.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if n.Tags != nil {
+		tags, err := tagentity.NormalizeNames(*n.Tags)
+		if err != nil {
+			return err
+		}
+		n.Tags = &tags
+	}
+	return nil
+}
+
+type MoveRequest struct {
+	NotebookID *int `json:"notebook_id"`
+}
+
+func (m *MoveRequest) Bind(r *http.Request) error {
 	return nil
 }
 
 type NoteResponse struct {
-	ID        int       `json:"id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int           `json:"id"`
+	NotebookID *int          `json:"notebook_id"`
+	Title      string        `json:"title"`
+	Content    string        `json:"content"`
+	Format     entity.Format `json:"format"`
+	Tags       []string      `json:"tags"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	Version    int           `json:"version"`
+	Pinned     bool          `json:"pinned"`
+	Archived   bool          `json:"archived"`
+	Favourite  bool          `json:"favourite"`
+	DeletedAt  *time.Time    `json:"deleted_at,omitempty"`
 }
 
 func (n *NoteResponse) Render(w http.ResponseWriter, r *http.Request) error {
@@ -33,11 +97,24 @@ func (n *NoteResponse) Render(w http.ResponseWriter, r *http.Request) error {
 }
 
 func NewNoteResponse(n *entity.Note) *NoteResponse {
+	tags := n.Tags
+	if tags == nil {
+		tags = []string{}
+	}
 	return &NoteResponse{
-		ID:        n.ID,
-		Title:     n.Title,
-		Content:   n.Content,
-		CreatedAt: n.CreatedAt,
+		ID:         n.ID,
+		NotebookID: n.NotebookID,
+		Title:      n.Title,
+		Content:    n.Content,
+		Format:     n.Format,
+		Tags:       tags,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+		Version:    n.Version,
+		Pinned:     n.Pinned,
+		Archived:   n.Archived,
+		Favourite:  n.Favourite,
+		DeletedAt:  n.DeletedAt,
 	}
 }
 
@@ -48,3 +125,35 @@ func NewNoteListResponse(notes []entity.Note) []render.Renderer {
 	}
 	return list
 }
+
+type SearchResultResponse struct {
+	*NoteResponse
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+	Rank           float64 `json:"rank"`
+}
+
+func (s *SearchResultResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewSearchResultListResponse(results []entity.SearchResult) []render.Renderer {
+	list := make([]render.Renderer, len(results))
+	for i, res := range results {
+		list[i] = &SearchResultResponse{
+			NoteResponse:   NewNoteResponse(&res.Note),
+			TitleHighlight: highlightHTML(res.TitleHighlight),
+			Snippet:        highlightHTML(res.Snippet),
+			Rank:           res.Rank,
+		}
+	}
+	return list
+}
+
+// highlightHTML escapes s and turns the highlight markers into <mark> tags,
+// so snippets can be embedded as HTML without trusting note content.
+func highlightHTML(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, entity.HighlightStart, "<mark>")
+	return strings.ReplaceAll(s, entity.HighlightEnd, "</mark>")
+}
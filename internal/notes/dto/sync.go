@@ -0,0 +1,164 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	tagentity "gonotes/internal/tags/entity"
+	"net/http"
+)
+
+const MaxSyncChanges = 500
+
+// Statuses of a pushed change.
+const (
+	SyncApplied  = "applied"
+	SyncConflict = "conflict"
+	SyncRejected = "rejected"
+)
+
+// SyncPushRequest is the body of POST /sync: changes made on the client since
+// it last synced, applied in order.
+type SyncPushRequest struct {
+	Changes []SyncChangeRequest `json:"changes"`
+}
+
+// SyncChangeRequest creates a note when ID is zero; ClientID is then echoed
+// back so the client can match the new note to its local copy. Otherwise it
+// updates the fields that are set, or trashes the note when Deleted is set,
+// and Version must be the version the client last synced.
+type SyncChangeRequest struct {
+	ClientID string `json:"client_id"`
+	ID       int    `json:"id"`
+	Version  int    `json:"version"`
+	Deleted  bool   `json:"deleted"`
+
+	Title      *string        `json:"title"`
+	Content    *string        `json:"content"`
+	Format     *entity.Format `json:"format"`
+	Tags       *[]string      `json:"tags"`
+	NotebookID *int           `json:"notebook_id"`
+}
+
+func (s *SyncPushRequest) Bind(r *http.Request) error {
+	if len(s.Changes) == 0 {
+		return fmt.Errorf("changes are required")
+	}
+	if len(s.Changes) > MaxSyncChanges {
+		return fmt.Errorf("at most %d changes are allowed", MaxSyncChanges)
+	}
+
+	for i := range s.Changes {
+		if err := s.Changes[i].validate(); err != nil {
+			return fmt.Errorf("changes[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (c *SyncChangeRequest) validate() error {
+	if c.Format != nil && !c.Format.Valid() {
+		return errInvalidFormat
+	}
+	if c.Content != nil && *c.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if c.Tags != nil {
+		tags, err := tagentity.NormalizeNames(*c.Tags)
+		if err != nil {
+			return err
+		}
+		c.Tags = &tags
+	}
+
+	switch {
+	case c.ID == 0:
+		if c.Deleted {
+			return fmt.Errorf("id is required")
+		}
+		if c.Content == nil {
+			return fmt.Errorf("content is required")
+		}
+	case c.Version <= 0:
+		return fmt.Errorf("version is required")
+	case c.NotebookID != nil:
+		return fmt.Errorf("notebook_id can only be set on new notes")
+	case !c.Deleted && c.Title == nil && c.Content == nil && c.Tags == nil && c.Format == nil:
+		return fmt.Errorf("nothing to update")
+	}
+	return nil
+}
+
+// Op returns the batch operation that applies the change.
+func (c *SyncChangeRequest) Op() storage.BatchOp {
+	o := storage.BatchOp{
+		Type:       storage.BatchUpdate,
+		ID:         c.ID,
+		Version:    c.Version,
+		Title:      c.Title,
+		Content:    c.Content,
+		Format:     c.Format,
+		Tags:       c.Tags,
+		NotebookID: c.NotebookID,
+	}
+	switch {
+	case c.ID == 0:
+		o.Type = storage.BatchCreate
+	case c.Deleted:
+		o.Type = storage.BatchDelete
+	}
+	return o
+}
+
+// SyncResultResponse is the outcome of one pushed change. Note is the note as
+// stored after an applied change, and the server's copy after a conflict; it
+// is missing when the note has since been deleted.
+type SyncResultResponse struct {
+	Index    int           `json:"index"`
+	ClientID string        `json:"client_id,omitempty"`
+	ID       int           `json:"id"`
+	Status   string        `json:"status"`
+	Note     *NoteResponse `json:"note,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+type SyncPushResponse struct {
+	Results []SyncResultResponse `json:"results"`
+}
+
+func (s *SyncPushResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+type SyncChangeResponse struct {
+	ID      int           `json:"id"`
+	Deleted bool          `json:"deleted"`
+	Note    *NoteResponse `json:"note,omitempty"`
+}
+
+// SyncResponse is a page of changes. Token is passed as since on the next
+// pull, straight away when HasMore is set.
+type SyncResponse struct {
+	Changes []SyncChangeResponse `json:"changes"`
+	Token   string               `json:"token"`
+	HasMore bool                 `json:"has_more"`
+}
+
+func (s *SyncResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewSyncResponse(set *storage.ChangeSet) *SyncResponse {
+	resp := &SyncResponse{
+		Changes: make([]SyncChangeResponse, len(set.Changes)),
+		Token:   set.Next.Encode(),
+		HasMore: set.HasMore,
+	}
+	for i, c := range set.Changes {
+		resp.Changes[i] = SyncChangeResponse{ID: c.NoteID, Deleted: c.Deleted}
+		if c.Note != nil {
+			resp.Changes[i].Note = NewNoteResponse(c.Note)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxLinkTitle is the longest title, in bytes, a wiki link may point to.
+const MaxLinkTitle = 255
+
+var wikiLink = regexp.MustCompile(`\[\[([^\[\]\n]+)\]\]`)
+
+// WikiLinks returns the titles of the notes content links to with
+// [[Title]], in the order they first appear. [[Title|label]] and
+// [[Title#section]] link to Title as well. Links inside code spans and fenced
+// code blocks are ignored, since shell tests like [[ -f x ]] look the same.
+func WikiLinks(content string) []string {
+	var (
+		links  []string
+		seen   = map[string]bool{}
+		fenced bool
+	)
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+
+		for _, m := range wikiLink.FindAllStringSubmatch(stripCodeSpans(line), -1) {
+			title := m[1]
+			if i := strings.IndexAny(title, "|#"); i >= 0 {
+				title = title[:i]
+			}
+			title = strings.TrimSpace(title)
+			if title == "" || len(title) > MaxLinkTitle || seen[title] {
+				continue
+			}
+			seen[title] = true
+			links = append(links, title)
+		}
+	}
+	return links
+}
+
+// stripCodeSpans drops code spans from line. As in Markdown, a span opens
+// with a run of backticks and closes at the next run of the same length; a
+// run without a partner is kept as text.
+func stripCodeSpans(line string) string {
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(line, '`')
+		if open < 0 {
+			b.WriteString(line)
+			return b.String()
+		}
+		n := backticks(line[open:])
+		rest := line[open+n:]
+
+		closeAt := -1
+		for i := 0; i < len(rest); {
+			if rest[i] != '`' {
+				i++
+				continue
+			}
+			m := backticks(rest[i:])
+			if m == n {
+				closeAt = i
+				break
+			}
+			i += m
+		}
+
+		if closeAt < 0 {
+			b.WriteString(line[:open+n])
+			line = rest
+			continue
+		}
+		b.WriteString(line[:open])
+		b.WriteString(" ")
+		line = rest[closeAt+n:]
+	}
+}
+
+// backticks returns the length of the run of backticks s starts with.
+func backticks(s string) int {
+	n := 0
+	for n < len(s) && s[n] == '`' {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WikiLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "none", content: "no links [here] or [[]]"},
+		{name: "simple", content: "see [[Groceries]] and [[ Travel plans ]]", expected: []string{"Groceries", "Travel plans"}},
+		{name: "label and section", content: "[[Recipes|my recipes]], [[Recipes#Soup]], [[Soup#]]", expected: []string{"Recipes", "Soup"}},
+		{name: "duplicates keep first", content: "[[B]] [[A]] [[B]]", expected: []string{"B", "A"}},
+		{name: "not across lines", content: "[[Half\nway]]"},
+		{name: "nested brackets", content: "[[[Inner]]]", expected: []string{"Inner"}},
+		{name: "inline code", content: "`[[ -f x ]]` but [[Real]] and ``[[Also code]]``", expected: []string{"Real"}},
+		{name: "unmatched backtick", content: "it` is [[Linked]]", expected: []string{"Linked"}},
+		{name: "fenced code", content: "```sh\nif [[ -f x ]]; then\n```\n~~~\n[[Skip]]\n~~~\n[[After]]", expected: []string{"After"}},
+		{name: "too long", content: "[[" + strings.Repeat("a", MaxLinkTitle+1) + "]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, WikiLinks(tt.content))
+		})
+	}
+}
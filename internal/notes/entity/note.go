@@ -3,9 +3,44 @@ package entity
 import "time"
 
 type Note struct {
-	ID        int       `json:"id"`
-	UserID    int       `json:"user_id"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	NotebookID *int       `json:"notebook_id"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Format     Format     `json:"format"`
+	Tags       []string   `json:"tags"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Version    int        `json:"version"`
+	Pinned     bool       `json:"pinned"`
+	Archived   bool       `json:"archived"`
+	Favourite  bool       `json:"favourite"`
+	DeletedAt  *time.Time `json:"deleted_at"`
 }
+
+// Format tells how the content of a note is meant to be rendered.
+type Format string
+
+const (
+	FormatPlain    Format = "plain"
+	FormatMarkdown Format = "markdown"
+)
+
+func (f Format) Valid() bool {
+	return f == FormatPlain || f == FormatMarkdown
+}
+
+// SearchResult is a note matched by a full-text query. The snippets keep the
+// matched terms wrapped in HighlightStart and HighlightEnd markers.
+type SearchResult struct {
+	Note           Note
+	TitleHighlight string
+	Snippet        string
+	Rank           float64
+}
+
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
@@ -0,0 +1,128 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+// Batch applies a list of create, update, delete and tag operations in one
+// transaction. The response lists the outcome of every operation; the status
+// is 200 when all of them succeeded and 207 otherwise.
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.batch"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	var req dto.BatchRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	ops := make([]storage.BatchOp, len(req.Operations))
+	for i, o := range req.Operations {
+		ops[i] = storage.BatchOp{
+			Type:       storage.BatchOpType(o.Op),
+			ID:         o.ID,
+			Version:    o.Version,
+			Title:      o.Title,
+			Content:    o.Content,
+			Format:     o.Format,
+			Tags:       o.Tags,
+			NotebookID: o.NotebookID,
+			AddTags:    o.Add,
+			RemoveTags: o.Remove,
+		}
+	}
+
+	atomic := req.Mode == dto.BatchModeAtomic
+	results, err := h.storage.Batch(userID, ops, atomic)
+	if err != nil {
+		log.Error("failed to run batch", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	resp := &dto.BatchResponse{Results: make([]dto.BatchResultResponse, len(results))}
+	failed := 0
+	for i, res := range results {
+		item := dto.BatchResultResponse{Index: i, Op: req.Operations[i].Op}
+		if res.Err != nil {
+			failed++
+			item.Status = batchErrorStatus(res.Err)
+			item.Error = res.Err.Error()
+			if item.Status == http.StatusInternalServerError {
+				log.Error("batch operation failed", slog.Int("index", i), slog.Any("err", res.Err))
+			}
+		} else {
+			item.Status = http.StatusOK
+			if ops[i].Type == storage.BatchCreate {
+				item.Status = http.StatusCreated
+			}
+			item.Note = dto.NewNoteResponse(res.Note)
+			resp.Committed = true
+		}
+		resp.Results[i] = item
+	}
+	if atomic && failed > 0 {
+		resp.Committed = false
+	}
+	if resp.Committed {
+		for i, res := range results {
+			if res.Err == nil {
+				h.events.Publish(events.NewNoteEvent(batchEventType(ops[i].Type), userID, res.Note))
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	render.Status(r, status)
+	render.Render(w, r, resp)
+
+	log.Info("batch applied", slog.Int("operations", len(ops)), slog.Int("failed", failed), slog.Bool("atomic", atomic), slog.Int("user_id", userID))
+}
+
+func batchEventType(t storage.BatchOpType) events.Type {
+	switch t {
+	case storage.BatchCreate:
+		return events.NoteCreated
+	case storage.BatchDelete:
+		return events.NoteDeleted
+	}
+	return events.NoteUpdated
+}
+
+func batchErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case errors.Is(err, storage.ErrNoteNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrNotebookNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrVersionMismatch):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, storage.ErrForbidden):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
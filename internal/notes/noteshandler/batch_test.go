@@ -0,0 +1,140 @@
+package noteshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Batch(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		mockBatch        func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error)
+		expectedStatus   int
+		expectedStatuses []int
+		expectedCommit   bool
+	}{
+		{
+			name: "all succeed",
+			body: `{"operations":[{"op":"create","title":"a","content":"b","tags":["Work"]},{"op":"tag","id":2,"add":["x"]},{"op":"delete","id":3}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				if !atomic || ops[0].Type != storage.BatchCreate || (*ops[0].Tags)[0] != "work" || ops[1].AddTags[0] != "x" {
+					return nil, errors.New("unexpected ops")
+				}
+				return []storage.BatchResult{{Note: &entity.Note{ID: 1}}, {Note: &entity.Note{ID: 2}}, {Note: &entity.Note{ID: 3}}}, nil
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []int{http.StatusCreated, http.StatusOK, http.StatusOK},
+			expectedCommit:   true,
+		},
+		{
+			name: "atomic failure",
+			body: `{"operations":[{"op":"update","id":1,"content":"x","version":3},{"op":"delete","id":2}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				return []storage.BatchResult{{Err: storage.ErrVersionMismatch}, {Err: storage.ErrBatchAborted}}, nil
+			},
+			expectedStatus:   http.StatusMultiStatus,
+			expectedStatuses: []int{http.StatusPreconditionFailed, http.StatusFailedDependency},
+		},
+		{
+			name: "per item partial",
+			body: `{"mode":"per_item","operations":[{"op":"delete","id":1},{"op":"delete","id":2}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				if atomic {
+					return nil, errors.New("expected per-item mode")
+				}
+				return []storage.BatchResult{{Note: &entity.Note{ID: 1}}, {Err: storage.ErrNoteNotFound}}, nil
+			},
+			expectedStatus:   http.StatusMultiStatus,
+			expectedStatuses: []int{http.StatusOK, http.StatusNotFound},
+			expectedCommit:   true,
+		},
+		{
+			name:           "empty",
+			body:           `{"operations":[]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown op",
+			body:           `{"operations":[{"op":"move","id":1}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "create without content",
+			body:           `{"operations":[{"op":"create","title":"a"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "update without id",
+			body:           `{"operations":[{"op":"update","content":"a"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid mode",
+			body:           `{"mode":"maybe","operations":[{"op":"delete","id":1}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "repo error",
+			body: `{"operations":[{"op":"delete","id":1}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &storagetest.EventRecorder{}
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), &storagetest.NoteRepository{BatchFunc: tt.mockBatch}, pub)
+
+			req := httptest.NewRequest(http.MethodPost, "/notes/batch", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			rr := httptest.NewRecorder()
+
+			h.Batch(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatuses == nil {
+				return
+			}
+
+			var resp dto.BatchResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedCommit, resp.Committed)
+			statuses := make([]int, len(resp.Results))
+			for i, res := range resp.Results {
+				statuses[i] = res.Status
+				assert.Equal(t, i, res.Index)
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+
+			published := 0
+			if resp.Committed {
+				for _, status := range statuses {
+					if status < http.StatusMultipleChoices {
+						published++
+					}
+				}
+			}
+			assert.Len(t, pub.Events(), published, "only committed operations publish events")
+		})
+	}
+}
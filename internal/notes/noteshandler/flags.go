@@ -0,0 +1,80 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+func (h *Handler) Pin(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagPinned, true)
+}
+
+func (h *Handler) Unpin(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagPinned, false)
+}
+
+func (h *Handler) Archive(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagArchived, true)
+}
+
+func (h *Handler) Unarchive(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagArchived, false)
+}
+
+func (h *Handler) Favourite(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagFavourite, true)
+}
+
+func (h *Handler) Unfavourite(w http.ResponseWriter, r *http.Request) {
+	h.setFlag(w, r, storage.FlagFavourite, false)
+}
+
+func (h *Handler) setFlag(w http.ResponseWriter, r *http.Request, flag storage.Flag, value bool) {
+	const op = "notes.handler.setFlag"
+	log := h.log.With(slog.String("op", op), slog.String("flag", string(flag)))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	n, err := h.storage.SetFlag(noteID, userID, flag, value)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to set flag", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, n))
+
+	w.Header().Set("ETag", api.ETag(n.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNoteResponse(n))
+
+	log.Info("note flag set", slog.Int("id", noteID), slog.Bool("value", value), slog.Int("user_id", userID))
+}
@@ -0,0 +1,89 @@
+package noteshandler_test
+
+import (
+	"encoding/json"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Flags(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       func(h *noteshandler.Handler) http.HandlerFunc
+		method        string
+		expectedFlag  storage.Flag
+		expectedValue bool
+	}{
+		{"pin", func(h *noteshandler.Handler) http.HandlerFunc { return h.Pin }, http.MethodPut, storage.FlagPinned, true},
+		{"unpin", func(h *noteshandler.Handler) http.HandlerFunc { return h.Unpin }, http.MethodDelete, storage.FlagPinned, false},
+		{"archive", func(h *noteshandler.Handler) http.HandlerFunc { return h.Archive }, http.MethodPut, storage.FlagArchived, true},
+		{"unarchive", func(h *noteshandler.Handler) http.HandlerFunc { return h.Unarchive }, http.MethodDelete, storage.FlagArchived, false},
+		{"favourite", func(h *noteshandler.Handler) http.HandlerFunc { return h.Favourite }, http.MethodPut, storage.FlagFavourite, true},
+		{"unfavourite", func(h *noteshandler.Handler) http.HandlerFunc { return h.Unfavourite }, http.MethodDelete, storage.FlagFavourite, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{
+				FlagFunc: func(id, userID int, flag storage.Flag, value bool) (*entity.Note, error) {
+					n := &entity.Note{ID: id, Version: 2}
+					switch flag {
+					case storage.FlagPinned:
+						n.Pinned = value
+					case storage.FlagArchived:
+						n.Archived = value
+					case storage.FlagFavourite:
+						n.Favourite = value
+					}
+					return n, nil
+				},
+			}
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.EventRecorder{})
+
+			rec := httptest.NewRecorder()
+			tc.handler(h)(rec, newIDRequest(tc.method, "/notes/1/"+tc.name, "1", true))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+			var resp dto.NoteResponse
+			_ = json.NewDecoder(rec.Body).Decode(&resp)
+			got := map[storage.Flag]bool{
+				storage.FlagPinned:    resp.Pinned,
+				storage.FlagArchived:  resp.Archived,
+				storage.FlagFavourite: resp.Favourite,
+			}
+			assert.Equal(t, tc.expectedValue, got[tc.expectedFlag])
+		})
+	}
+}
+
+func Test_FlagErrors(t *testing.T) {
+	repo := &storagetest.NoteRepository{
+		FlagFunc: func(id, userID int, flag storage.Flag, value bool) (*entity.Note, error) {
+			return nil, storage.ErrNoteNotFound
+		},
+	}
+	h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.EventRecorder{})
+
+	rec := httptest.NewRecorder()
+	h.Pin(rec, newIDRequest(http.MethodPut, "/notes/1/pin", "1", true))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.Pin(rec, newIDRequest(http.MethodPut, "/notes/x/pin", "x", true))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.Pin(rec, newIDRequest(http.MethodPut, "/notes/1/pin", "1", false))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,73 @@
+package noteshandler
+
+import (
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/lib/markdown"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/entity"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// HTML renders the content of a note as a sanitized HTML fragment.
+func (h *Handler) HTML(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.html"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	n, err := h.storage.Get(noteID, userID)
+	if err != nil {
+		log.Error("note not found", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		return
+	}
+
+	h.renderHTML(w, r, log, n)
+}
+
+// wantsHTML reports whether the client prefers HTML over JSON.
+func wantsHTML(r *http.Request) bool {
+	return render.GetAcceptedContentType(r) == render.ContentTypeHTML
+}
+
+func (h *Handler) renderHTML(w http.ResponseWriter, r *http.Request, log *slog.Logger, n *entity.Note) {
+	var (
+		out string
+		err error
+	)
+	switch n.Format {
+	case entity.FormatMarkdown:
+		out, err = markdown.Render(n.Content)
+	default:
+		out = markdown.RenderPlain(n.Content)
+	}
+	if err != nil {
+		log.Error("failed to render note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	w.Header().Set("ETag", api.ETag(n.Version))
+	render.Status(r, http.StatusOK)
+	render.HTML(w, r, out)
+
+	log.Info("note rendered", slog.Int("id", n.ID), slog.String("format", string(n.Format)))
+}
@@ -0,0 +1,103 @@
+package noteshandler_test
+
+import (
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HTML(t *testing.T) {
+	notes := map[int]*entity.Note{
+		1: {ID: 1, Content: "# Plan\n\n- [x] done\n\n<script>alert(1)</script>", Format: entity.FormatMarkdown, Version: 3},
+		2: {ID: 2, Content: "a <b> & c", Format: entity.FormatPlain, Version: 1},
+	}
+	repo := &storagetest.NoteRepository{
+		GetFunc: func(id, userID int) (*entity.Note, error) {
+			n, ok := notes[id]
+			if !ok {
+				return nil, storage.ErrNoteNotFound
+			}
+			return n, nil
+		},
+	}
+	h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.EventRecorder{})
+
+	tests := []struct {
+		name           string
+		urlID          string
+		accept         string
+		handler        func(w http.ResponseWriter, r *http.Request)
+		expectedStatus int
+		expectedBody   []string
+		expectedType   string
+	}{
+		{
+			name:           "markdown rendered",
+			urlID:          "1",
+			handler:        h.HTML,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"<h1>Plan</h1>", `type="checkbox"`},
+			expectedType:   "text/html; charset=utf-8",
+		},
+		{
+			name:           "plain text escaped",
+			urlID:          "2",
+			handler:        h.HTML,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"<pre>a &lt;b&gt; &amp; c</pre>"},
+			expectedType:   "text/html; charset=utf-8",
+		},
+		{
+			name:           "note not found",
+			urlID:          "9",
+			handler:        h.HTML,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "get negotiates html",
+			urlID:          "1",
+			accept:         "text/html,application/xhtml+xml",
+			handler:        h.Get,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{"<h1>Plan</h1>"},
+			expectedType:   "text/html; charset=utf-8",
+		},
+		{
+			name:           "get defaults to json",
+			urlID:          "1",
+			accept:         "application/json",
+			handler:        h.Get,
+			expectedStatus: http.StatusOK,
+			expectedBody:   []string{`"format":"markdown"`},
+			expectedType:   "application/json",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := newIDRequest(http.MethodGet, "/notes/"+tc.urlID+"/html", tc.urlID, true)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rec := httptest.NewRecorder()
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedType != "" {
+				assert.Contains(t, rec.Header().Get("Content-Type"), tc.expectedType)
+			}
+			for _, s := range tc.expectedBody {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+			assert.NotContains(t, rec.Body.String(), "<script>")
+		})
+	}
+}
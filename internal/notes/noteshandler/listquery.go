@@ -0,0 +1,164 @@
+package noteshandler
+
+import (
+	"fmt"
+	"gonotes/internal/notes/query"
+	"gonotes/internal/notes/storage"
+	tagentity "gonotes/internal/tags/entity"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize    = 50
+	defaultSearchLimit = 20
+	maxPageSize        = 200
+)
+
+// parseListQuery reads paging, sorting and date filters for GET /notes:
+// limit, cursor, sort, order, created_after, created_before, updated_after
+// and updated_before, notebook_id, tag filters in tag and tag_mode, plus a
+// search query in q.
+func parseListQuery(r *http.Request) (storage.ListQuery, error) {
+	params := r.URL.Query()
+	q := storage.ListQuery{
+		Limit: defaultPageSize,
+		Sort:  storage.SortCreatedAt,
+		Desc:  true,
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+		q.Limit = limit
+	}
+
+	if v := params.Get("sort"); v != "" {
+		q.Sort = storage.SortField(v)
+		if !q.Sort.Valid() {
+			return q, fmt.Errorf("sort must be one of created_at, updated_at, title")
+		}
+		if q.Sort == storage.SortTitle {
+			q.Desc = false
+		}
+	}
+
+	switch strings.ToLower(params.Get("order")) {
+	case "":
+	case "asc":
+		q.Desc = false
+	case "desc":
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("order must be asc or desc")
+	}
+
+	if v := params.Get("cursor"); v != "" {
+		c, err := storage.DecodeCursor(v)
+		if err != nil {
+			return q, err
+		}
+		if c.Sort != q.Sort || c.Desc != q.Desc {
+			return q, fmt.Errorf("cursor does not match the requested sort order")
+		}
+		q.Cursor = c
+	}
+
+	filters := []struct {
+		name string
+		dst  *time.Time
+	}{
+		{"created_after", &q.CreatedAfter},
+		{"created_before", &q.CreatedBefore},
+		{"updated_after", &q.UpdatedAfter},
+		{"updated_before", &q.UpdatedBefore},
+	}
+	for _, f := range filters {
+		v := params.Get(f.name)
+		if v == "" {
+			continue
+		}
+		t, err := parseTime(v)
+		if err != nil {
+			return q, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", f.name)
+		}
+		*f.dst = t
+	}
+
+	if v := params.Get("notebook_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return q, fmt.Errorf("invalid notebook_id")
+		}
+		q.NotebookID = &id
+	}
+
+	if v := params.Get("archived"); v != "" {
+		archived, err := strconv.ParseBool(v)
+		if err != nil {
+			return q, fmt.Errorf("archived must be true or false")
+		}
+		q.IncludeArchived = archived
+	}
+
+	var tags []string
+	for _, v := range params["tag"] {
+		tags = append(tags, strings.Split(v, ",")...)
+	}
+	if len(tags) > 0 {
+		normalized, err := tagentity.NormalizeNames(tags)
+		if err != nil {
+			return q, err
+		}
+		q.Tags = normalized
+	}
+
+	switch strings.ToLower(params.Get("tag_mode")) {
+	case "", "all", "and":
+	case "any", "or":
+		q.MatchAnyTag = true
+	default:
+		return q, fmt.Errorf("tag_mode must be all or any")
+	}
+
+	filter, err := query.Parse(params.Get("q"))
+	if err != nil {
+		return q, err
+	}
+	q.Filter = filter
+
+	return q, nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// pageLinks builds an RFC 8288 Link header pointing at the neighbouring pages.
+func pageLinks(r *http.Request, page *storage.NotePage) string {
+	var links []string
+
+	link := func(c *storage.Cursor, rel string) {
+		params := r.URL.Query()
+		params.Set("cursor", c.Encode())
+		u := url.URL{Path: r.URL.Path, RawQuery: params.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+
+	if page.Next != nil {
+		link(page.Next, "next")
+	}
+	if page.Prev != nil {
+		link(page.Prev, "prev")
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -1,8 +1,10 @@
 package noteshandler
 
 import (
+	"errors"
 	"fmt"
 	"gonotes/internal/api"
+	"gonotes/internal/events"
 	"gonotes/internal/middleware"
 	"gonotes/internal/notes/dto"
 	"gonotes/internal/notes/entity"
@@ -10,6 +12,7 @@ import (
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
@@ -18,12 +21,16 @@ import (
 type Handler struct {
 	log     *slog.Logger
 	storage storage.NoteRepository
+	events  events.Publisher
 }
 
-func NewHandler(log *slog.Logger, storage storage.NoteRepository) *Handler {
+// NewHandler returns a handler that publishes an event to pub for every note
+// it creates, changes or moves to the trash.
+func NewHandler(log *slog.Logger, storage storage.NoteRepository, pub events.Publisher) *Handler {
 	return &Handler{
 		log:     log,
 		storage: storage,
+		events:  pub,
 	}
 }
 
@@ -45,13 +52,21 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	created, err := h.storage.Create(&entity.Note{UserID: userID, Title: req.Title, Content: req.Content})
+	created, err := h.storage.Create(&entity.Note{UserID: userID, NotebookID: req.NotebookID, Title: req.Title, Content: req.Content, Format: req.Format, Tags: req.Tags})
 	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+			return
+		}
 		log.Error("failed to create note", slog.Any("err", err))
 		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
 		return
 	}
 
+	h.events.Publish(events.NewNoteEvent(events.NoteCreated, userID, created))
+
+	w.Header().Set("ETag", api.ETag(created.Version))
 	render.Status(r, http.StatusCreated)
 	render.Render(w, r, dto.NewNoteResponse(created))
 
@@ -83,12 +98,231 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
 		return
 	}
+
+	w.Header().Set("Vary", "Accept")
+	if wantsHTML(r) {
+		h.renderHTML(w, r, log, n)
+		return
+	}
+
+	w.Header().Set("ETag", api.ETag(n.Version))
 	render.Status(r, http.StatusOK)
 	render.Render(w, r, dto.NewNoteResponse(n))
 
 	log.Info("note retrieved", slog.Int("id", noteID), slog.Int("user_id", userID))
 }
 
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.update"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	idParam := chi.URLParam(r, "id")
+	noteID, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.NoteRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := api.IfMatch(r)
+	if err != nil {
+		log.Error("invalid If-Match header", slog.Any("err", err))
+		status := http.StatusBadRequest
+		if errors.Is(err, api.ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		render.Render(w, r, api.NewErrResponse(status, err))
+		return
+	}
+
+	updated, err := h.storage.Update(noteID, userID, &entity.Note{Title: req.Title, Content: req.Content, Format: req.Format, Tags: req.Tags, Version: version})
+	if err != nil {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.Error("note version mismatch", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusPreconditionFailed, err))
+			return
+		}
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		if errors.Is(err, storage.ErrForbidden) {
+			log.Error("note is read-only for user", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusForbidden, err))
+			return
+		}
+		log.Error("failed to update note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, updated))
+
+	w.Header().Set("ETag", api.ETag(updated.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNoteResponse(updated))
+
+	log.Info("note updated", slog.Int("id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Patch(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.patch"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	idParam := chi.URLParam(r, "id")
+	noteID, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.NotePatchRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := api.IfMatch(r)
+	if err != nil {
+		log.Error("invalid If-Match header", slog.Any("err", err))
+		status := http.StatusBadRequest
+		if errors.Is(err, api.ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		render.Render(w, r, api.NewErrResponse(status, err))
+		return
+	}
+
+	n, err := h.storage.Get(noteID, userID)
+	if err != nil {
+		log.Error("note not found", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		return
+	}
+	if version != 0 && n.Version != version {
+		log.Error("note version mismatch", slog.Int("expected", version), slog.Int("actual", n.Version))
+		render.Render(w, r, api.NewErrResponse(http.StatusPreconditionFailed, storage.ErrVersionMismatch))
+		return
+	}
+	n.Version = version
+
+	if req.Title != nil {
+		n.Title = *req.Title
+	}
+	if req.Content != nil {
+		n.Content = *req.Content
+	}
+	if req.Format != nil {
+		n.Format = *req.Format
+	}
+	if req.Tags != nil {
+		n.Tags = *req.Tags
+	}
+
+	updated, err := h.storage.Update(noteID, userID, n)
+	if err != nil {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.Error("note version mismatch", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusPreconditionFailed, err))
+			return
+		}
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		if errors.Is(err, storage.ErrForbidden) {
+			log.Error("note is read-only for user", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusForbidden, err))
+			return
+		}
+		log.Error("failed to update note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, updated))
+
+	w.Header().Set("ETag", api.ETag(updated.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNoteResponse(updated))
+
+	log.Info("note patched", slog.Int("id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Move(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.move"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	idParam := chi.URLParam(r, "id")
+	noteID, err := strconv.Atoi(idParam)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.MoveRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	moved, err := h.storage.Move(noteID, userID, req.NotebookID)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoteNotFound):
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, storage.ErrNotebookNotFound):
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		default:
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		log.Error("failed to move note", slog.Any("err", err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, moved))
+
+	w.Header().Set("ETag", api.ETag(moved.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNoteResponse(moved))
+
+	log.Info("note moved", slog.Int("id", noteID), slog.Int("user_id", userID))
+}
+
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 	const op = "notes.handler.delete"
 	log := h.log.With(slog.String("op", op))
@@ -108,13 +342,31 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	n, err := h.storage.Delete(noteID, userID)
+	version, err := api.IfMatch(r)
+	if err != nil {
+		log.Error("invalid If-Match header", slog.Any("err", err))
+		status := http.StatusBadRequest
+		if errors.Is(err, api.ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		render.Render(w, r, api.NewErrResponse(status, err))
+		return
+	}
+
+	n, err := h.storage.Delete(noteID, userID, version)
 	if err != nil {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			log.Error("note version mismatch", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusPreconditionFailed, err))
+			return
+		}
 		log.Error("note not found", slog.Any("err", err))
 		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
 		return
 	}
 
+	h.events.Publish(events.NewNoteEvent(events.NoteDeleted, userID, n))
+
 	render.Status(r, http.StatusOK)
 	render.Render(w, r, dto.NewNoteResponse(n))
 
@@ -133,15 +385,70 @@ func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := h.storage.GetAll(userID)
+	q, err := parseListQuery(r)
+	if err != nil {
+		log.Error("invalid list query", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	page, err := h.storage.List(userID, q)
 	if err != nil {
+		if errors.Is(err, storage.ErrInvalidQuery) {
+			log.Error("invalid search query", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+			return
+		}
 		log.Error("failed to get all notes", slog.Any("err", err))
 		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
 		return
 	}
 
+	if links := pageLinks(r, page); links != "" {
+		w.Header().Set("Link", links)
+	}
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewNoteListResponse(page.Notes))
+
+	log.Info("notes retrieved", slog.Int("count", len(page.Notes)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.search"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("q is required")))
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageSize)))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.storage.Search(userID, query, limit)
+	if err != nil {
+		log.Error("failed to search notes", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
 	render.Status(r, http.StatusOK)
-	render.RenderList(w, r, dto.NewNoteListResponse(list))
+	render.RenderList(w, r, dto.NewSearchResultListResponse(results))
 
-	log.Info("notes retrieved", slog.Int("count", len(list)), slog.Int("user_id", userID))
+	log.Info("notes searched", slog.Int("count", len(results)), slog.Int("user_id", userID))
 }
@@ -5,12 +5,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"gonotes/internal/api"
+	"gonotes/internal/events"
 	"gonotes/internal/lib/logger/slogdiscard"
 	"gonotes/internal/middleware"
 	"gonotes/internal/notes/dto"
 	"gonotes/internal/notes/entity"
 	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -19,24 +23,17 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-type mockNotesRepo struct {
-	createFunc func(note *entity.Note) (*entity.Note, error)
-	getFunc    func(id int, userID int) (*entity.Note, error)
-	deleteFunc func(id int, userID int) (*entity.Note, error)
-	getAllFunc func(userID int) ([]entity.Note, error)
-}
+func newIDRequest(method, target, urlID string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
 
-func (m *mockNotesRepo) Create(n *entity.Note) (*entity.Note, error) {
-	return m.createFunc(n)
-}
-func (m *mockNotesRepo) Get(id int, userID int) (*entity.Note, error) {
-	return m.getFunc(id, userID)
-}
-func (m *mockNotesRepo) Delete(id, userID int) (*entity.Note, error) {
-	return m.deleteFunc(id, userID)
-}
-func (m *mockNotesRepo) GetAll(userID int) ([]entity.Note, error) {
-	return m.getAllFunc(userID)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", urlID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
 }
 
 func Test_Create(t *testing.T) {
@@ -44,9 +41,12 @@ func Test_Create(t *testing.T) {
 		name           string
 		title          string
 		content        string
+		tags           []string
+		format         entity.Format
 		mockError      error
 		expectedStatus int
 		expectedError  string
+		expectedTags   []string
 		setupContext   bool
 	}{
 		{
@@ -63,6 +63,39 @@ func Test_Create(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			setupContext:   true,
 		},
+		{
+			name:           "invalid tag",
+			title:          "test",
+			content:        "tagged",
+			tags:           []string{"a,b"},
+			expectedStatus: http.StatusBadRequest,
+			setupContext:   true,
+		},
+		{
+			name:           "note created with tags",
+			title:          "test",
+			content:        "tagged",
+			tags:           []string{" Work ", "work", "home"},
+			expectedTags:   []string{"work", "home"},
+			expectedStatus: http.StatusCreated,
+			setupContext:   true,
+		},
+		{
+			name:           "markdown note created",
+			title:          "test",
+			content:        "# heading",
+			format:         entity.FormatMarkdown,
+			expectedStatus: http.StatusCreated,
+			setupContext:   true,
+		},
+		{
+			name:           "invalid format",
+			title:          "test",
+			content:        "text",
+			format:         "rtf",
+			expectedStatus: http.StatusBadRequest,
+			setupContext:   true,
+		},
 		{
 			name:           "repo error",
 			title:          "fail",
@@ -76,8 +109,8 @@ func Test_Create(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			repo := &mockNotesRepo{
-				createFunc: func(n *entity.Note) (*entity.Note, error) {
+			repo := &storagetest.NoteRepository{
+				CreateFunc: func(n *entity.Note) (*entity.Note, error) {
 					if tc.mockError != nil {
 						return n, tc.mockError
 					}
@@ -86,11 +119,14 @@ func Test_Create(t *testing.T) {
 			}
 
 			log := slogdiscard.NewDiscardLogger()
-			h := noteshandler.NewHandler(log, repo)
+			pub := &storagetest.EventRecorder{}
+			h := noteshandler.NewHandler(log, repo, pub)
 
 			body, _ := json.Marshal(dto.NoteRequest{
 				Title:   tc.title,
 				Content: tc.content,
+				Tags:    tc.tags,
+				Format:  tc.format,
 			})
 
 			req := httptest.NewRequest(http.MethodPost, "/create", bytes.NewReader(body))
@@ -115,6 +151,20 @@ func Test_Create(t *testing.T) {
 				_ = json.NewDecoder(res.Body).Decode(&resp)
 				assert.Equal(t, tc.expectedError, resp.Err)
 			}
+			if tc.expectedTags != nil {
+				var resp dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, tc.expectedTags, resp.Tags)
+			}
+			published := pub.Events()
+			if tc.expectedStatus == http.StatusCreated {
+				if assert.Len(t, published, 1) {
+					assert.Equal(t, events.NoteCreated, published[0].Type)
+					assert.Equal(t, 1, published[0].UserID)
+				}
+			} else {
+				assert.Empty(t, published)
+			}
 		})
 	}
 }
@@ -153,7 +203,7 @@ func Test_Get(t *testing.T) {
 			setupCtx: true,
 			urlID:    "1",
 			mockGet: func(id int, userID int) (*entity.Note, error) {
-				return &entity.Note{ID: 1, Title: "test", Content: "content"}, nil
+				return &entity.Note{ID: 1, Title: "test", Content: "content", Version: 3}, nil
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -161,9 +211,9 @@ func Test_Get(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			repo := &mockNotesRepo{getFunc: tc.mockGet}
+			repo := &storagetest.NoteRepository{GetFunc: tc.mockGet}
 			log := slogdiscard.NewDiscardLogger()
-			h := noteshandler.NewHandler(log, repo)
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", tc.urlID)
@@ -188,6 +238,7 @@ func Test_Get(t *testing.T) {
 				_ = json.NewDecoder(res.Body).Decode(&resp)
 				assert.Equal(t, 1, resp.ID)
 				assert.Equal(t, "test", resp.Title)
+				assert.Equal(t, `"3"`, res.Header.Get("ETag"))
 			}
 			if res.StatusCode >= 400 {
 				var resp api.ErrResponse
@@ -198,12 +249,260 @@ func Test_Get(t *testing.T) {
 	}
 }
 
+func Test_Update(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupCtx       bool
+		urlID          string
+		body           string
+		ifMatch        string
+		mockUpdate     func(id, userID int, n *entity.Note) (*entity.Note, error)
+		expectedStatus int
+	}{
+		{
+			name:           "unauthorized",
+			setupCtx:       false,
+			urlID:          "1",
+			body:           `{"title":"new","content":"new content"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid id format",
+			setupCtx:       true,
+			urlID:          "abc",
+			body:           `{"title":"new","content":"new content"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid request body",
+			setupCtx:       true,
+			urlID:          "1",
+			body:           `{"title":"new"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "note not found",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"title":"new","content":"new content"}`,
+			mockUpdate: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "shared read-only",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"title":"new","content":"new content"}`,
+			mockUpdate: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+				return nil, storage.ErrForbidden
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:     "repo error",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"title":"new","content":"new content"}`,
+			mockUpdate: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "malformed If-Match",
+			setupCtx:       true,
+			urlID:          "1",
+			body:           `{"title":"new","content":"new content"}`,
+			ifMatch:        `"1", "2"`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "version mismatch",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"title":"new","content":"new content"}`,
+			ifMatch:  `"1"`,
+			mockUpdate: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+				return nil, storage.ErrVersionMismatch
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:     "note updated",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"title":"new","content":"new content"}`,
+			ifMatch:  `"4"`,
+			mockUpdate: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+				if n.Version != 4 {
+					return nil, storage.ErrVersionMismatch
+				}
+				return &entity.Note{ID: id, UserID: userID, Title: n.Title, Content: n.Content, Version: 5}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{UpdateFunc: tc.mockUpdate}
+			log := slogdiscard.NewDiscardLogger()
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.urlID)
+
+			req := httptest.NewRequest(http.MethodPut, "/notes/"+tc.urlID, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			if tc.setupCtx {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			}
+
+			rec := httptest.NewRecorder()
+			h.Update(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, 1, resp.ID)
+				assert.Equal(t, "new", resp.Title)
+				assert.Equal(t, "new content", resp.Content)
+				assert.Equal(t, `"5"`, res.Header.Get("ETag"))
+			}
+			if res.StatusCode >= 400 {
+				var resp api.ErrResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NotEmpty(t, resp.Err)
+			}
+		})
+	}
+}
+
+func Test_Patch(t *testing.T) {
+	tests := []struct {
+		name            string
+		urlID           string
+		body            string
+		ifMatch         string
+		mockGet         func(id int, userID int) (*entity.Note, error)
+		expectedStatus  int
+		expectedTitle   string
+		expectedContent string
+	}{
+		{
+			name:           "empty patch",
+			urlID:          "1",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty content",
+			urlID:          "1",
+			body:           `{"content":""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "note not found",
+			urlID: "1",
+			body:  `{"title":"new"}`,
+			mockGet: func(id int, userID int) (*entity.Note, error) {
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:    "stale version",
+			urlID:   "1",
+			body:    `{"title":"new"}`,
+			ifMatch: `"1"`,
+			mockGet: func(id int, userID int) (*entity.Note, error) {
+				return &entity.Note{ID: 1, Title: "old", Content: "old content", Version: 2}, nil
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:  "title patched",
+			urlID: "1",
+			body:  `{"title":"new"}`,
+			mockGet: func(id int, userID int) (*entity.Note, error) {
+				return &entity.Note{ID: 1, Title: "old", Content: "old content"}, nil
+			},
+			expectedStatus:  http.StatusOK,
+			expectedTitle:   "new",
+			expectedContent: "old content",
+		},
+		{
+			name:  "content patched",
+			urlID: "1",
+			body:  `{"content":"new content"}`,
+			mockGet: func(id int, userID int) (*entity.Note, error) {
+				return &entity.Note{ID: 1, Title: "old", Content: "old content"}, nil
+			},
+			expectedStatus:  http.StatusOK,
+			expectedTitle:   "old",
+			expectedContent: "new content",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{
+				GetFunc: tc.mockGet,
+				UpdateFunc: func(id, userID int, n *entity.Note) (*entity.Note, error) {
+					return &entity.Note{ID: id, UserID: userID, Title: n.Title, Content: n.Content}, nil
+				},
+			}
+			log := slogdiscard.NewDiscardLogger()
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.urlID)
+
+			req := httptest.NewRequest(http.MethodPatch, "/notes/"+tc.urlID, bytes.NewReader([]byte(tc.body)))
+			req.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+
+			rec := httptest.NewRecorder()
+			h.Patch(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, tc.expectedTitle, resp.Title)
+				assert.Equal(t, tc.expectedContent, resp.Content)
+			}
+		})
+	}
+}
+
 func Test_Delete(t *testing.T) {
 	tests := []struct {
 		name           string
 		setupCtx       bool
 		urlID          string
-		mockDelete     func(id, userID int) (*entity.Note, error)
+		ifMatch        string
+		mockDelete     func(id, userID, version int) (*entity.Note, error)
 		expectedStatus int
 	}{
 		{
@@ -222,16 +521,37 @@ func Test_Delete(t *testing.T) {
 			name:     "note not found",
 			setupCtx: true,
 			urlID:    "1",
-			mockDelete: func(id, userID int) (*entity.Note, error) {
+			mockDelete: func(id, userID, version int) (*entity.Note, error) {
 				return nil, errors.New("not found")
 			},
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:     "version mismatch",
+			setupCtx: true,
+			urlID:    "1",
+			ifMatch:  `"1"`,
+			mockDelete: func(id, userID, version int) (*entity.Note, error) {
+				return nil, storage.ErrVersionMismatch
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "weak etag never matches",
+			setupCtx:       true,
+			urlID:          "1",
+			ifMatch:        `W/"1"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 		{
 			name:     "note deleted",
 			setupCtx: true,
 			urlID:    "1",
-			mockDelete: func(id, userID int) (*entity.Note, error) {
+			ifMatch:  `"2"`,
+			mockDelete: func(id, userID, version int) (*entity.Note, error) {
+				if version != 2 {
+					return nil, storage.ErrVersionMismatch
+				}
 				return &entity.Note{ID: 1, Title: "test", Content: "deleted"}, nil
 			},
 			expectedStatus: http.StatusOK,
@@ -240,15 +560,18 @@ func Test_Delete(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			repo := &mockNotesRepo{deleteFunc: tc.mockDelete}
+			repo := &storagetest.NoteRepository{DeleteFunc: tc.mockDelete}
 			log := slogdiscard.NewDiscardLogger()
-			h := noteshandler.NewHandler(log, repo)
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("id", tc.urlID)
 
 			req := httptest.NewRequest(http.MethodDelete, "/notes/"+tc.urlID, nil)
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			if tc.setupCtx {
 				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
@@ -277,13 +600,120 @@ func Test_Delete(t *testing.T) {
 	}
 }
 
+func Test_Move(t *testing.T) {
+	notebookID := 3
+
+	tests := []struct {
+		name             string
+		setupCtx         bool
+		urlID            string
+		body             string
+		mockMove         func(id, userID int, notebookID *int) (*entity.Note, error)
+		expectedStatus   int
+		expectedNotebook *int
+	}{
+		{
+			name:           "unauthorized",
+			urlID:          "1",
+			body:           `{"notebook_id":3}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "invalid id",
+			setupCtx:       true,
+			urlID:          "abc",
+			body:           `{"notebook_id":3}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "note not found",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"notebook_id":3}`,
+			mockMove: func(id, userID int, notebookID *int) (*entity.Note, error) {
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "notebook not found",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"notebook_id":3}`,
+			mockMove: func(id, userID int, notebookID *int) (*entity.Note, error) {
+				return nil, storage.ErrNotebookNotFound
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "moved into notebook",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"notebook_id":3}`,
+			mockMove: func(id, userID int, notebookID *int) (*entity.Note, error) {
+				return &entity.Note{ID: id, NotebookID: notebookID, Version: 2}, nil
+			},
+			expectedStatus:   http.StatusOK,
+			expectedNotebook: &notebookID,
+		},
+		{
+			name:     "moved to top level",
+			setupCtx: true,
+			urlID:    "1",
+			body:     `{"notebook_id":null}`,
+			mockMove: func(id, userID int, notebookID *int) (*entity.Note, error) {
+				return &entity.Note{ID: id, NotebookID: notebookID, Version: 2}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{MoveFunc: tc.mockMove}
+			log := slogdiscard.NewDiscardLogger()
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.urlID)
+
+			req := httptest.NewRequest(http.MethodPost, "/notes/"+tc.urlID+"/move", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			if tc.setupCtx {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			}
+
+			rec := httptest.NewRecorder()
+			h.Move(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, tc.expectedNotebook, resp.NotebookID)
+				assert.Equal(t, `"2"`, res.Header.Get("ETag"))
+			}
+		})
+	}
+}
+
 func Test_GetAll(t *testing.T) {
+	next := &storage.Cursor{Sort: storage.SortCreatedAt, Desc: true, Value: "2026-01-01T00:00:00Z", ID: 2}
+
 	tests := []struct {
 		name           string
 		setupCtx       bool
-		mockGetAll     func(userID int) ([]entity.Note, error)
+		query          string
+		mockList       func(userID int, q storage.ListQuery) (*storage.NotePage, error)
 		expectedStatus int
 		expectedLen    int
+		expectedLink   string
 	}{
 		{
 			name:           "unauthorized",
@@ -293,28 +723,143 @@ func Test_GetAll(t *testing.T) {
 		{
 			name:     "repo error",
 			setupCtx: true,
-			mockGetAll: func(userID int) ([]entity.Note, error) {
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
 				return nil, errors.New("db error")
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:           "invalid limit",
+			setupCtx:       true,
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid sort",
+			setupCtx:       true,
+			query:          "?sort=content",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			setupCtx:       true,
+			query:          "?cursor=garbage",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "cursor from another ordering",
+			setupCtx:       true,
+			query:          "?sort=title&cursor=" + next.Encode(),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed search query",
+			setupCtx:       true,
+			query:          "?q=%22unterminated",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "repo rejects search query",
+			setupCtx: true,
+			query:    "?q=draft",
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				return nil, fmt.Errorf("list: %w", storage.ErrInvalidQuery)
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "search query passed to repo",
+			setupCtx: true,
+			query:    "?q=title:meeting+-draft",
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				if q.Filter == nil || q.Filter.String() != `(and (text:title "meeting") (not (text "draft")))` {
+					return nil, errors.New("unexpected filter")
+				}
+				return &storage.NotePage{Notes: []entity.Note{{ID: 1, Title: "meeting"}}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name:     "tag filters",
+			setupCtx: true,
+			query:    "?tag=Work,home&tag=urgent&tag_mode=any",
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				if !q.MatchAnyTag || len(q.Tags) != 3 || q.Tags[0] != "work" {
+					return nil, errors.New("unexpected tags")
+				}
+				return &storage.NotePage{Notes: []entity.Note{{ID: 1, Tags: []string{"work"}}}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name:     "archived hidden by default",
+			setupCtx: true,
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				if q.IncludeArchived {
+					return nil, errors.New("archived notes must be hidden")
+				}
+				return &storage.NotePage{Notes: []entity.Note{}}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "archived included",
+			setupCtx: true,
+			query:    "?archived=true",
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				if !q.IncludeArchived {
+					return nil, errors.New("archived notes must be included")
+				}
+				return &storage.NotePage{Notes: []entity.Note{{ID: 1, Archived: true}}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name:           "invalid archived",
+			setupCtx:       true,
+			query:          "?archived=maybe",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid tag mode",
+			setupCtx:       true,
+			query:          "?tag=work&tag_mode=some",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date filter",
+			setupCtx:       true,
+			query:          "?created_after=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name:     "notes retrieved",
 			setupCtx: true,
-			mockGetAll: func(userID int) ([]entity.Note, error) {
-				return []entity.Note{
-					{ID: 1, Title: "note1", Content: "content1"},
-					{ID: 2, Title: "note2", Content: "content2"},
+			query:    "?limit=2&sort=created_at&order=desc&created_after=2025-01-01",
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				if q.Limit != 2 || q.Sort != storage.SortCreatedAt || !q.Desc || q.CreatedAfter.IsZero() {
+					return nil, errors.New("unexpected query")
+				}
+				return &storage.NotePage{
+					Notes: []entity.Note{
+						{ID: 1, Title: "note1", Content: "content1"},
+						{ID: 2, Title: "note2", Content: "content2"},
+					},
+					Next: next,
 				}, nil
 			},
 			expectedStatus: http.StatusOK,
 			expectedLen:    2,
+			expectedLink:   `rel="next"`,
 		},
 		{
 			name:     "empty list",
 			setupCtx: true,
-			mockGetAll: func(userID int) ([]entity.Note, error) {
-				return []entity.Note{}, nil
+			mockList: func(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+				return &storage.NotePage{Notes: []entity.Note{}}, nil
 			},
 			expectedStatus: http.StatusOK,
 			expectedLen:    0,
@@ -323,11 +868,11 @@ func Test_GetAll(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			repo := &mockNotesRepo{getAllFunc: tc.mockGetAll}
+			repo := &storagetest.NoteRepository{ListFunc: tc.mockList}
 			log := slogdiscard.NewDiscardLogger()
-			h := noteshandler.NewHandler(log, repo)
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
 
-			req := httptest.NewRequest(http.MethodGet, "/notes", nil)
+			req := httptest.NewRequest(http.MethodGet, "/notes"+tc.query, nil)
 			if tc.setupCtx {
 				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
 			}
@@ -344,6 +889,12 @@ func Test_GetAll(t *testing.T) {
 				var resp []dto.NoteResponse
 				_ = json.NewDecoder(res.Body).Decode(&resp)
 				assert.Len(t, resp, tc.expectedLen)
+				if tc.expectedLink != "" {
+					assert.Contains(t, res.Header.Get("Link"), tc.expectedLink)
+					assert.Contains(t, res.Header.Get("Link"), "cursor="+next.Encode())
+				} else {
+					assert.Empty(t, res.Header.Get("Link"))
+				}
 			}
 			if res.StatusCode >= 400 {
 				var resp api.ErrResponse
@@ -353,3 +904,88 @@ func Test_GetAll(t *testing.T) {
 		})
 	}
 }
+
+func Test_Search(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupCtx       bool
+		query          string
+		mockSearch     func(userID int, query string, limit int) ([]entity.SearchResult, error)
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name:           "unauthorized",
+			setupCtx:       false,
+			query:          "?q=go",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "missing query",
+			setupCtx:       true,
+			query:          "?q=%20",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid limit",
+			setupCtx:       true,
+			query:          "?q=go&limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "repo error",
+			setupCtx: true,
+			query:    "?q=go",
+			mockSearch: func(userID int, query string, limit int) ([]entity.SearchResult, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:     "notes found",
+			setupCtx: true,
+			query:    "?q=go&limit=5",
+			mockSearch: func(userID int, query string, limit int) ([]entity.SearchResult, error) {
+				if query != "go" || limit != 5 {
+					return nil, errors.New("unexpected query")
+				}
+				return []entity.SearchResult{
+					{
+						Note:    entity.Note{ID: 1, Title: "go", Content: "<b>go</b> notes"},
+						Snippet: "<b>" + entity.HighlightStart + "go" + entity.HighlightEnd + "</b> notes",
+					},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{SearchFunc: tc.mockSearch}
+			log := slogdiscard.NewDiscardLogger()
+			h := noteshandler.NewHandler(log, repo, &storagetest.EventRecorder{})
+
+			req := httptest.NewRequest(http.MethodGet, "/notes/search"+tc.query, nil)
+			if tc.setupCtx {
+				req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			}
+			rec := httptest.NewRecorder()
+
+			h.Search(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp []dto.SearchResultResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Len(t, resp, tc.expectedLen)
+				assert.Equal(t, "&lt;b&gt;<mark>go</mark>&lt;/b&gt; notes", resp[0].Snippet)
+			}
+		})
+	}
+}
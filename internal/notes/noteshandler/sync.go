@@ -0,0 +1,133 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultSyncPage = 500
+	maxSyncPage     = 1000
+)
+
+// Pull returns the changes to the user's notes, and notes shared with them,
+// made after the since token, oldest first. Without since every note is
+// returned.
+func (h *Handler) Pull(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.pull"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	since, err := storage.DecodeSyncToken(r.URL.Query().Get("since"))
+	if err != nil {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	limit := defaultSyncPage
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSyncPage {
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxSyncPage)))
+			return
+		}
+	}
+
+	set, err := h.storage.Changes(userID, since, limit)
+	if err != nil {
+		log.Error("failed to list changes", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewSyncResponse(set))
+
+	log.Info("changes retrieved", slog.Int("count", len(set.Changes)), slog.Bool("has_more", set.HasMore), slog.Int("user_id", userID))
+}
+
+// Push applies changes made offline. Each change stands on its own: it is
+// applied, conflicts because the note moved on since the client synced it,
+// or is rejected. Conflicts carry the server's copy for the client to merge
+// and push again with its version.
+func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.push"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	var req dto.SyncPushRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	ops := make([]storage.BatchOp, len(req.Changes))
+	for i := range req.Changes {
+		ops[i] = req.Changes[i].Op()
+	}
+
+	results, err := h.storage.Batch(userID, ops, false)
+	if err != nil {
+		log.Error("failed to apply changes", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	resp := &dto.SyncPushResponse{Results: make([]dto.SyncResultResponse, len(results))}
+	conflicts := 0
+	for i, res := range results {
+		item := dto.SyncResultResponse{Index: i, ClientID: req.Changes[i].ClientID, ID: ops[i].ID}
+		switch {
+		case res.Err == nil:
+			item.Status = dto.SyncApplied
+			item.ID = res.Note.ID
+			item.Note = dto.NewNoteResponse(res.Note)
+			h.events.Publish(events.NewNoteEvent(batchEventType(ops[i].Type), userID, res.Note))
+		case errors.Is(res.Err, storage.ErrVersionMismatch):
+			conflicts++
+			item.Status = dto.SyncConflict
+			item.Error = res.Err.Error()
+			current, err := h.storage.Get(ops[i].ID, userID)
+			if err == nil {
+				item.Note = dto.NewNoteResponse(current)
+			} else if !errors.Is(err, storage.ErrNoteNotFound) {
+				log.Error("failed to get conflicting note", slog.Int("id", ops[i].ID), slog.Any("err", err))
+			}
+		default:
+			item.Status = dto.SyncRejected
+			item.Error = res.Err.Error()
+			if batchErrorStatus(res.Err) == http.StatusInternalServerError {
+				log.Error("change failed", slog.Int("index", i), slog.Any("err", res.Err))
+			}
+		}
+		resp.Results[i] = item
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, resp)
+
+	log.Info("changes pushed", slog.Int("changes", len(ops)), slog.Int("conflicts", conflicts), slog.Int("user_id", userID))
+}
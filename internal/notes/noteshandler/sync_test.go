@@ -0,0 +1,234 @@
+package noteshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Pull(t *testing.T) {
+	since := storage.SyncToken{Seq: 7}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockChanges    func(userID int, since storage.SyncToken, limit int) (*storage.ChangeSet, error)
+		expectedStatus int
+		expected       *dto.SyncResponse
+	}{
+		{
+			name:  "first sync",
+			query: "",
+			mockChanges: func(userID int, s storage.SyncToken, limit int) (*storage.ChangeSet, error) {
+				if s.Seq != 0 || limit != 500 {
+					return nil, errors.New("unexpected arguments")
+				}
+				return &storage.ChangeSet{
+					Changes: []storage.Change{{Seq: 3, NoteID: 1, Note: &entity.Note{ID: 1, Title: "a"}}},
+					Next:    storage.SyncToken{Seq: 9},
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expected: &dto.SyncResponse{
+				Changes: []dto.SyncChangeResponse{{ID: 1, Note: &dto.NoteResponse{ID: 1, Title: "a"}}},
+				Token:   storage.SyncToken{Seq: 9}.Encode(),
+			},
+		},
+		{
+			name:  "since token",
+			query: "?since=" + since.Encode() + "&limit=2",
+			mockChanges: func(userID int, s storage.SyncToken, limit int) (*storage.ChangeSet, error) {
+				if s != since || limit != 2 {
+					return nil, errors.New("unexpected arguments")
+				}
+				return &storage.ChangeSet{
+					Changes: []storage.Change{{Seq: 8, NoteID: 4, Deleted: true}, {Seq: 9, NoteID: 5, Deleted: true}},
+					Next:    storage.SyncToken{Seq: 9},
+					HasMore: true,
+				}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expected: &dto.SyncResponse{
+				Changes: []dto.SyncChangeResponse{{ID: 4, Deleted: true}, {ID: 5, Deleted: true}},
+				Token:   storage.SyncToken{Seq: 9}.Encode(),
+				HasMore: true,
+			},
+		},
+		{name: "invalid token", query: "?since=!!", expectedStatus: http.StatusBadRequest},
+		{name: "negative token", query: "?since=" + storage.SyncToken{Seq: -1}.Encode(), expectedStatus: http.StatusBadRequest},
+		{name: "invalid limit", query: "?limit=1001", expectedStatus: http.StatusBadRequest},
+		{
+			name:  "repo error",
+			query: "",
+			mockChanges: func(userID int, s storage.SyncToken, limit int) (*storage.ChangeSet, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), &storagetest.NoteRepository{ChangesFunc: tt.mockChanges}, events.NewBus())
+
+			req := httptest.NewRequest(http.MethodGet, "/sync"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			rr := httptest.NewRecorder()
+
+			h.Pull(rr, req)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expected == nil {
+				return
+			}
+
+			var resp dto.SyncResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expected.Token, resp.Token)
+			assert.Equal(t, tt.expected.HasMore, resp.HasMore)
+			require.Len(t, resp.Changes, len(tt.expected.Changes))
+			for i, c := range tt.expected.Changes {
+				assert.Equal(t, c.ID, resp.Changes[i].ID)
+				assert.Equal(t, c.Deleted, resp.Changes[i].Deleted)
+				if c.Note == nil {
+					assert.Nil(t, resp.Changes[i].Note)
+				} else {
+					assert.Equal(t, c.Note.Title, resp.Changes[i].Note.Title)
+				}
+			}
+		})
+	}
+}
+
+func Test_Push(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		mockBatch        func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error)
+		mockGet          func(id int, userID int) (*entity.Note, error)
+		expectedStatus   int
+		expectedStatuses []string
+		expectedIDs      []int
+		expectedEvents   []events.Type
+	}{
+		{
+			name: "applied",
+			body: `{"changes":[{"client_id":"tmp-1","content":"new","notebook_id":4},{"id":2,"version":3,"title":"t"},{"id":3,"version":1,"deleted":true}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				if atomic || ops[0].Type != storage.BatchCreate || *ops[0].NotebookID != 4 ||
+					ops[1].Type != storage.BatchUpdate || ops[1].Version != 3 ||
+					ops[2].Type != storage.BatchDelete || ops[2].Version != 1 {
+					return nil, errors.New("unexpected ops")
+				}
+				return []storage.BatchResult{{Note: &entity.Note{ID: 9}}, {Note: &entity.Note{ID: 2}}, {Note: &entity.Note{ID: 3}}}, nil
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{dto.SyncApplied, dto.SyncApplied, dto.SyncApplied},
+			expectedIDs:      []int{9, 2, 3},
+			expectedEvents:   []events.Type{events.NoteCreated, events.NoteUpdated, events.NoteDeleted},
+		},
+		{
+			name: "conflicts and rejections",
+			body: `{"changes":[{"id":2,"version":3,"content":"mine"},{"id":3,"version":1,"content":"mine"},{"id":4,"version":1,"deleted":true},{"id":5,"version":2,"tags":["a"]}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				return []storage.BatchResult{
+					{Err: storage.ErrVersionMismatch},
+					{Err: storage.ErrVersionMismatch},
+					{Err: storage.ErrNoteNotFound},
+					{Note: &entity.Note{ID: 5}},
+				}, nil
+			},
+			mockGet: func(id int, userID int) (*entity.Note, error) {
+				if id == 2 {
+					return &entity.Note{ID: 2, Content: "theirs", Version: 4}, nil
+				}
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus:   http.StatusOK,
+			expectedStatuses: []string{dto.SyncConflict, dto.SyncConflict, dto.SyncRejected, dto.SyncApplied},
+			expectedIDs:      []int{2, 3, 4, 5},
+			expectedEvents:   []events.Type{events.NoteUpdated},
+		},
+		{name: "empty", body: `{"changes":[]}`, expectedStatus: http.StatusBadRequest},
+		{name: "update without version", body: `{"changes":[{"id":2,"content":"x"}]}`, expectedStatus: http.StatusBadRequest},
+		{name: "nothing to update", body: `{"changes":[{"id":2,"version":1}]}`, expectedStatus: http.StatusBadRequest},
+		{name: "create without content", body: `{"changes":[{"title":"x"}]}`, expectedStatus: http.StatusBadRequest},
+		{name: "delete without id", body: `{"changes":[{"deleted":true}]}`, expectedStatus: http.StatusBadRequest},
+		{name: "move existing note", body: `{"changes":[{"id":2,"version":1,"notebook_id":3}]}`, expectedStatus: http.StatusBadRequest},
+		{
+			name: "repo error",
+			body: `{"changes":[{"id":2,"version":1,"deleted":true}]}`,
+			mockBatch: func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &storagetest.EventRecorder{}
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), &storagetest.NoteRepository{BatchFunc: tt.mockBatch, GetFunc: tt.mockGet}, pub)
+
+			req := httptest.NewRequest(http.MethodPost, "/sync", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+			rr := httptest.NewRecorder()
+
+			h.Push(rr, req)
+
+			require.Equal(t, tt.expectedStatus, rr.Code, rr.Body.String())
+			if tt.expectedStatuses == nil {
+				return
+			}
+
+			var resp dto.SyncPushResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			statuses := make([]string, len(resp.Results))
+			ids := make([]int, len(resp.Results))
+			for i, res := range resp.Results {
+				statuses[i] = res.Status
+				ids[i] = res.ID
+			}
+			assert.Equal(t, tt.expectedStatuses, statuses)
+			assert.Equal(t, tt.expectedIDs, ids)
+
+			var published []events.Type
+			for _, ev := range pub.Events() {
+				published = append(published, ev.Type)
+			}
+			assert.Equal(t, tt.expectedEvents, published)
+
+			for _, res := range resp.Results {
+				switch {
+				case res.Status == dto.SyncApplied:
+					assert.NotNil(t, res.Note)
+				case res.ID == 2:
+					require.NotNil(t, res.Note, "conflicts carry the server copy")
+					assert.Equal(t, "theirs", res.Note.Content)
+				default:
+					assert.Nil(t, res.Note)
+					assert.NotEmpty(t, res.Error)
+				}
+			}
+			if tt.name == "applied" {
+				assert.Equal(t, "tmp-1", resp.Results[0].ClientID)
+			}
+		})
+	}
+}
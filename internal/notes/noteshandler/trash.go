@@ -0,0 +1,114 @@
+package noteshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+func (h *Handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.getTrash"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	notes, err := h.storage.ListTrash(userID)
+	if err != nil {
+		log.Error("failed to list trash", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewNoteListResponse(notes))
+
+	log.Info("trash retrieved", slog.Int("count", len(notes)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Restore(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.restore"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	n, err := h.storage.Restore(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not in trash", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to restore note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, n))
+
+	w.Header().Set("ETag", api.ETag(n.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewNoteResponse(n))
+
+	log.Info("note restored", slog.Int("id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Purge(w http.ResponseWriter, r *http.Request) {
+	const op = "notes.handler.purge"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	if err := h.storage.Purge(noteID, userID); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not in trash", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to purge note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("note purged", slog.Int("id", noteID), slog.Int("user_id", userID))
+}
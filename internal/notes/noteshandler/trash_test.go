@@ -0,0 +1,173 @@
+package noteshandler_test
+
+import (
+	"encoding/json"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/notes/dto"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/noteshandler"
+	"gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetTrash(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		setupCtx       bool
+		mockList       func(userID int) ([]entity.Note, error)
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name:           "unauthorized",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "repo error",
+			setupCtx: true,
+			mockList: func(userID int) ([]entity.Note, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:     "trash listed",
+			setupCtx: true,
+			mockList: func(userID int) ([]entity.Note, error) {
+				return []entity.Note{{ID: 1, Content: "gone", DeletedAt: &deletedAt}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), &storagetest.NoteRepository{ListTrashFunc: tc.mockList}, &storagetest.EventRecorder{})
+
+			rec := httptest.NewRecorder()
+			h.GetTrash(rec, newIDRequest(http.MethodGet, "/trash", "", tc.setupCtx))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp []dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Len(t, resp, tc.expectedLen)
+				assert.Equal(t, &deletedAt, resp[0].DeletedAt)
+			}
+		})
+	}
+}
+
+func Test_Restore(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		mockRestore    func(id, userID int) (*entity.Note, error)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			urlID:          "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "not in trash",
+			urlID: "1",
+			mockRestore: func(id, userID int) (*entity.Note, error) {
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "restored",
+			urlID: "1",
+			mockRestore: func(id, userID int) (*entity.Note, error) {
+				return &entity.Note{ID: id, Content: "back", Version: 3}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), &storagetest.NoteRepository{RestoreFunc: tc.mockRestore}, &storagetest.EventRecorder{})
+
+			rec := httptest.NewRecorder()
+			h.Restore(rec, newIDRequest(http.MethodPost, "/trash/"+tc.urlID+"/restore", tc.urlID, true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.NoteResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Nil(t, resp.DeletedAt)
+				assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+			}
+		})
+	}
+}
+
+func Test_Purge(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		mockErr        error
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id",
+			urlID:          "abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "not in trash",
+			urlID:          "1",
+			mockErr:        storage.ErrNoteNotFound,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "repo error",
+			urlID:          "1",
+			mockErr:        errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "purged",
+			urlID:          "1",
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &storagetest.NoteRepository{
+				PurgeFunc: func(id, userID int) error {
+					return tc.mockErr
+				},
+			}
+			h := noteshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.EventRecorder{})
+
+			rec := httptest.NewRecorder()
+			h.Purge(rec, newIDRequest(http.MethodDelete, "/trash/"+tc.urlID, tc.urlID, true))
+
+			assert.Equal(t, tc.expectedStatus, rec.Result().StatusCode)
+		})
+	}
+}
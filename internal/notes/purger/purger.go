@@ -0,0 +1,82 @@
+// Package purger empties the trash of notes that have been deleted for longer
+// than the configured retention and then collects the files they left behind.
+package purger
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type TrashPurger interface {
+	PurgeTrash(before time.Time) (int64, error)
+}
+
+// Collector removes data orphaned by purged notes, such as attachment blobs.
+type Collector interface {
+	Collect() (int, error)
+}
+
+type Purger struct {
+	log       *slog.Logger
+	storage   TrashPurger
+	collector Collector
+	retention time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// New returns a purger; collector may be nil.
+func New(log *slog.Logger, storage TrashPurger, collector Collector, retention, interval time.Duration) *Purger {
+	return &Purger{
+		log:       log,
+		storage:   storage,
+		collector: collector,
+		retention: retention,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Run purges once straight away and then every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge() {
+	const op = "notes.purger.purge"
+	log := p.log.With(slog.String("op", op))
+
+	before := p.now().Add(-p.retention)
+	n, err := p.storage.PurgeTrash(before)
+	if err != nil {
+		log.Error("failed to purge trash", slog.Any("err", err))
+		return
+	}
+	if n > 0 {
+		log.Info("trash purged", slog.Int64("count", n), slog.Time("before", before))
+	}
+
+	if p.collector == nil {
+		return
+	}
+	removed, err := p.collector.Collect()
+	if err != nil {
+		log.Error("failed to collect orphans", slog.Any("err", err))
+		return
+	}
+	if removed > 0 {
+		log.Info("orphans collected", slog.Int("count", removed))
+	}
+}
@@ -0,0 +1,81 @@
+package purger
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTrash struct {
+	mu    sync.Mutex
+	calls []time.Time
+	err   error
+}
+
+func (m *mockTrash) PurgeTrash(before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, before)
+	return 1, m.err
+}
+
+func (m *mockTrash) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.calls)
+}
+
+func Test_purgeUsesRetention(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	repo := &mockTrash{}
+	p := New(slogdiscard.NewDiscardLogger(), repo, nil, 30*24*time.Hour, time.Hour)
+	p.now = func() time.Time { return now }
+
+	p.purge()
+
+	assert.Equal(t, []time.Time{time.Date(2026, 2, 8, 12, 0, 0, 0, time.UTC)}, repo.calls)
+}
+
+type mockCollector struct {
+	calls int
+}
+
+func (m *mockCollector) Collect() (int, error) {
+	m.calls++
+	return 0, nil
+}
+
+func Test_purgeRunsCollector(t *testing.T) {
+	collector := &mockCollector{}
+	p := New(slogdiscard.NewDiscardLogger(), &mockTrash{}, collector, time.Hour, time.Hour)
+
+	p.purge()
+
+	assert.Equal(t, 1, collector.calls)
+}
+
+func Test_RunRepeatsUntilCancelled(t *testing.T) {
+	repo := &mockTrash{err: errors.New("db error")}
+	p := New(slogdiscard.NewDiscardLogger(), repo, nil, time.Hour, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return repo.count() >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
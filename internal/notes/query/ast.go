@@ -0,0 +1,91 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Field string
+
+const (
+	FieldAny     Field = ""
+	FieldTitle   Field = "title"
+	FieldContent Field = "content"
+	FieldTag     Field = "tag"
+	FieldBefore  Field = "before"
+	FieldAfter   Field = "after"
+)
+
+// Node is an element of a parsed query. String renders it as an
+// s-expression, which is handy in logs and tests.
+type Node interface {
+	String() string
+}
+
+// And matches notes matching every child.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches notes matching at least one child.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches notes that do not match Node.
+type Not struct {
+	Node Node
+}
+
+// Text matches a substring of the title, the content or either of them when
+// Field is FieldAny. Exact is set for quoted phrases, which match
+// case-sensitively.
+type Text struct {
+	Field Field
+	Value string
+	Exact bool
+}
+
+// Tag matches notes carrying the named tag.
+type Tag struct {
+	Name string
+}
+
+// Date bounds the creation time: FieldBefore is exclusive, FieldAfter is
+// inclusive.
+type Date struct {
+	Field Field
+	Time  time.Time
+}
+
+func (n *And) String() string { return list("and", n.Nodes) }
+func (n *Or) String() string  { return list("or", n.Nodes) }
+func (n *Not) String() string { return "(not " + n.Node.String() + ")" }
+
+func (n *Text) String() string {
+	kind := "text"
+	if n.Exact {
+		kind = "phrase"
+	}
+	if n.Field != FieldAny {
+		kind += ":" + string(n.Field)
+	}
+	return fmt.Sprintf("(%s %q)", kind, n.Value)
+}
+
+func (n *Tag) String() string {
+	return fmt.Sprintf("(tag %q)", n.Name)
+}
+
+func (n *Date) String() string {
+	return fmt.Sprintf("(%s %s)", n.Field, n.Time.Format(time.RFC3339))
+}
+
+func list(op string, nodes []Node) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
@@ -0,0 +1,313 @@
+// Package query parses the note search language used by GET /notes?q=.
+//
+// A query is a list of terms that must all match. Terms are bare words,
+// "quoted phrases", field filters (title:, content:, tag:, before:, after:)
+// and parenthesised groups. A leading minus negates a term and OR between
+// terms matches either side:
+//
+//	tag:work before:2026-01-01 "exact phrase" -draft (title:meeting OR title:standup)
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxQueryLength = 1024
+	maxDepth       = 32
+)
+
+// Error describes a malformed query. Pos is the byte offset of the problem.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid query at position %d: %s", e.Pos, e.Msg)
+}
+
+// Parse turns a query string into an AST. An empty query yields a nil node.
+func Parse(s string) (Node, error) {
+	if len(s) > maxQueryLength {
+		return nil, &Error{Pos: maxQueryLength, Msg: fmt.Sprintf("query is longer than %d bytes", maxQueryLength)}
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokMinus
+	tokOr
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	field Field
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokMinus:
+		return `"-"`
+	case tokOr:
+		return "OR"
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+var fields = map[string]Field{
+	"title":   FieldTitle,
+	"content": FieldContent,
+	"tag":     FieldTag,
+	"before":  FieldBefore,
+	"after":   FieldAfter,
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, pos: i})
+			i++
+		case r == '-' && startsTerm(s[i+1:]):
+			tokens = append(tokens, token{kind: tokMinus, pos: i})
+			i++
+		case r == '"':
+			value, next, err := lexPhrase(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokPhrase, value: value, pos: i})
+			i = next
+		default:
+			tok, next, err := lexWord(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+// startsTerm reports whether a minus sign followed by rest negates a term
+// rather than being part of a word such as "well-known".
+func startsTerm(rest string) bool {
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest != "" && !unicode.IsSpace(r) && r != ')'
+}
+
+func lexPhrase(s string, start int) (string, int, error) {
+	end := strings.IndexByte(s[start+1:], '"')
+	if end < 0 {
+		return "", 0, &Error{Pos: start, Msg: "unterminated quoted phrase"}
+	}
+	value := s[start+1 : start+1+end]
+	if strings.TrimSpace(value) == "" {
+		return "", 0, &Error{Pos: start, Msg: "empty quoted phrase"}
+	}
+	return value, start + end + 2, nil
+}
+
+func lexWord(s string, start int) (token, int, error) {
+	end := start
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if unicode.IsSpace(r) || r == '(' || r == ')' || r == '"' {
+			break
+		}
+		end += size
+	}
+	word := s[start:end]
+
+	if word == "OR" {
+		return token{kind: tokOr, pos: start}, end, nil
+	}
+
+	// Only known field names are treated as fields, so times such as 10:30,
+	// URLs and words such as todo:call stay plain words.
+	name, value, ok := strings.Cut(word, ":")
+	field, known := fields[strings.ToLower(name)]
+	if !ok || !known || strings.HasPrefix(value, "//") {
+		return token{kind: tokWord, value: word, pos: start}, end, nil
+	}
+
+	if value == "" && end < len(s) && s[end] == '"' {
+		phrase, next, err := lexPhrase(s, end)
+		if err != nil {
+			return token{}, 0, err
+		}
+		return token{kind: tokPhrase, field: field, value: phrase, pos: start}, next, nil
+	}
+	if value == "" {
+		return token{}, 0, &Error{Pos: start, Msg: fmt.Sprintf("missing value for %s:", name)}
+	}
+
+	return token{kind: tokWord, field: field, value: value, pos: start}, end, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	if depth > maxDepth {
+		return nil, &Error{Pos: p.peek().pos, Msg: "query is nested too deeply"}
+	}
+
+	first, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []Node{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		node, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	var nodes []Node
+	for {
+		switch tok := p.peek(); tok.kind {
+		case tokEOF, tokRParen, tokOr:
+			if len(nodes) == 0 {
+				return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a search term, got %s", tok)}
+			}
+			if len(nodes) == 1 {
+				return nodes[0], nil
+			}
+			return &And{Nodes: nodes}, nil
+		}
+
+		node, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if p.peek().kind == tokMinus {
+		if depth > maxDepth {
+			return nil, &Error{Pos: p.peek().pos, Msg: "query is nested too deeply"}
+		}
+		p.next()
+		node, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: node}, nil
+	}
+	return p.parsePrimary(depth)
+}
+
+func (p *parser) parsePrimary(depth int) (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		node, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, &Error{Pos: closing.pos, Msg: fmt.Sprintf("expected \")\", got %s", closing)}
+		}
+		return node, nil
+	case tokWord, tokPhrase:
+		return termNode(tok)
+	}
+	return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a search term, got %s", tok)}
+}
+
+func termNode(tok token) (Node, error) {
+	switch tok.field {
+	case FieldTag:
+		return &Tag{Name: strings.ToLower(tok.value)}, nil
+	case FieldBefore, FieldAfter:
+		t, err := parseDate(tok.value)
+		if err != nil {
+			return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("%s: expects a YYYY-MM-DD date or an RFC 3339 timestamp", tok.field)}
+		}
+		return &Date{Field: tok.field, Time: t}, nil
+	}
+	return &Text{Field: tok.field, Value: tok.value, Exact: tok.kind == tokPhrase}, nil
+}
+
+func parseDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
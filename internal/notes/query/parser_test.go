@@ -0,0 +1,107 @@
+package query_test
+
+import (
+	"errors"
+	"gonotes/internal/notes/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "single word",
+			input:    "draft",
+			expected: `(text "draft")`,
+		},
+		{
+			name:     "implicit and",
+			input:    "go notes",
+			expected: `(and (text "go") (text "notes"))`,
+		},
+		{
+			name:     "full example",
+			input:    `tag:work before:2026-01-01 "exact phrase" -draft title:meeting`,
+			expected: `(and (tag "work") (before 2026-01-01T00:00:00Z) (phrase "exact phrase") (not (text "draft")) (text:title "meeting"))`,
+		},
+		{
+			name:     "quoted field value",
+			input:    `content:"weekly sync"`,
+			expected: `(phrase:content "weekly sync")`,
+		},
+		{
+			name:     "or binds looser than and",
+			input:    "a b OR c",
+			expected: `(or (and (text "a") (text "b")) (text "c"))`,
+		},
+		{
+			name:     "negated group",
+			input:    "-(tag:a OR tag:b) after:2025-06-01T10:00:00Z",
+			expected: `(and (not (or (tag "a") (tag "b"))) (after 2025-06-01T10:00:00Z))`,
+		},
+		{
+			name:     "hyphenated word and url",
+			input:    "well-known http://example.com 10:30",
+			expected: `(and (text "well-known") (text "http://example.com") (text "10:30"))`,
+		},
+		{
+			name:     "unknown field is a word",
+			input:    "todo:call note: buy",
+			expected: `(and (text "todo:call") (text "note:") (text "buy"))`,
+		},
+		{
+			name:     "field names are case insensitive",
+			input:    "Tag:Work",
+			expected: `(tag "work")`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := query.Parse(tc.input)
+			assert.NoError(t, err)
+			if assert.NotNil(t, node) {
+				assert.Equal(t, tc.expected, node.String())
+			}
+		})
+	}
+}
+
+func Test_ParseEmpty(t *testing.T) {
+	node, err := query.Parse("   ")
+	assert.NoError(t, err)
+	assert.Nil(t, node)
+}
+
+func Test_ParseErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{name: "unterminated phrase", input: `notes "open`, pos: 6},
+		{name: "empty phrase", input: `""`, pos: 0},
+		{name: "missing value", input: "tag: work", pos: 0},
+		{name: "bad date", input: "before:tomorrow", pos: 0},
+		{name: "dangling or", input: "a OR", pos: 4},
+		{name: "leading or", input: "OR a", pos: 0},
+		{name: "unbalanced open", input: "(a b", pos: 4},
+		{name: "unbalanced close", input: "a)", pos: 1},
+		{name: "empty group", input: "()", pos: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := query.Parse(tc.input)
+			var qerr *query.Error
+			if assert.True(t, errors.As(err, &qerr), "expected *query.Error, got %v", err) {
+				assert.Equal(t, tc.pos, qerr.Pos)
+			}
+		})
+	}
+}
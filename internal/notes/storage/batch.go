@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/notes/entity"
+)
+
+// ErrBatchAborted marks operations of an all-or-nothing batch that were not
+// applied because another operation in the batch failed.
+var ErrBatchAborted = errors.New("batch aborted by a failed operation")
+
+type BatchOpType string
+
+const (
+	BatchCreate BatchOpType = "create"
+	BatchUpdate BatchOpType = "update"
+	BatchDelete BatchOpType = "delete"
+	BatchTag    BatchOpType = "tag"
+)
+
+func (t BatchOpType) Valid() bool {
+	switch t {
+	case BatchCreate, BatchUpdate, BatchDelete, BatchTag:
+		return true
+	}
+	return false
+}
+
+// BatchOp is one operation of a batch. Create uses Title, Content, Format,
+// Tags and NotebookID. Update changes the fields that are set, like a PATCH.
+// Delete moves the note to the trash. Tag adds AddTags and removes
+// RemoveTags. Version, when non-zero, must match the stored note for update,
+// delete and tag.
+type BatchOp struct {
+	Type    BatchOpType
+	ID      int
+	Version int
+
+	Title      *string
+	Content    *string
+	Format     *entity.Format
+	Tags       *[]string
+	NotebookID *int
+
+	AddTags    []string
+	RemoveTags []string
+}
+
+// BatchResult is the outcome of one operation: the note it produced or the
+// error that stopped it.
+type BatchResult struct {
+	Note *entity.Note
+	Err  error
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/query"
+	"time"
+)
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidQuery  = errors.New("invalid query")
+)
+
+type SortField string
+
+const (
+	SortCreatedAt SortField = "created_at"
+	SortUpdatedAt SortField = "updated_at"
+	SortTitle     SortField = "title"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortCreatedAt, SortUpdatedAt, SortTitle:
+		return true
+	}
+	return false
+}
+
+// ListQuery describes one page of a user's notes.
+type ListQuery struct {
+	Limit  int
+	Sort   SortField
+	Desc   bool
+	Cursor *Cursor
+
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	// NotebookID restricts the page to notes filed directly in a notebook.
+	NotebookID *int
+
+	// Tags restricts the page to notes carrying all of the tags, or any of
+	// them when MatchAnyTag is set.
+	Tags        []string
+	MatchAnyTag bool
+
+	// Filter is a parsed search query; nil matches every note.
+	Filter query.Node
+
+	// IncludeArchived lists archived notes alongside the others.
+	IncludeArchived bool
+}
+
+// Cursor marks the boundary note of a page. Pinned notes sort before all
+// others, then Value holds the boundary note's sort key (RFC 3339 for
+// timestamps) and ID breaks ties between equal keys. Backward cursors select
+// the page that precedes the boundary.
+type Cursor struct {
+	Sort     SortField `json:"s"`
+	Desc     bool      `json:"d,omitempty"`
+	Pinned   bool      `json:"p,omitempty"`
+	Value    string    `json:"v"`
+	ID       int       `json:"i"`
+	Backward bool      `json:"b,omitempty"`
+}
+
+// NewCursor returns a cursor pointing at n for the ordering described by q.
+func NewCursor(q ListQuery, n *entity.Note, backward bool) *Cursor {
+	c := &Cursor{Sort: q.Sort, Desc: q.Desc, Pinned: n.Pinned, ID: n.ID, Backward: backward}
+	switch q.Sort {
+	case SortTitle:
+		c.Value = n.Title
+	case SortUpdatedAt:
+		c.Value = n.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		c.Value = n.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return c
+}
+
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || !c.Sort.Valid() {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != SortTitle {
+		if _, err := time.Parse(time.RFC3339Nano, c.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &c, nil
+}
+
+type NotePage struct {
+	Notes []entity.Note
+	Next  *Cursor
+	Prev  *Cursor
+}
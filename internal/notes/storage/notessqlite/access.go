@@ -0,0 +1,42 @@
+package notessqlite
+
+import (
+	"database/sql"
+	"errors"
+	"gonotes/internal/notes/storage"
+)
+
+// RowQuerier is satisfied by *sql.DB and *sql.Tx.
+type RowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// CheckNote verifies that a note exists, is not in the trash and that the
+// user has the given access to it. Users who cannot see the note get
+// storage.ErrNoteNotFound; users it is shared with read-only get
+// storage.ErrForbidden when edit access is needed. Repositories of data kept
+// about notes use it to guard that data.
+func CheckNote(q RowQuerier, noteID int, userID int, access storage.Access) error {
+	var (
+		ownerID    int
+		permission sql.NullString
+	)
+	err := q.QueryRow("SELECT user_id, (SELECT permission FROM note_shares WHERE note_id = notes.id AND user_id = ?) FROM notes WHERE id = ? AND deleted_at IS NULL", userID, noteID).
+		Scan(&ownerID, &permission)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNoteNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ownerID == userID:
+		return nil
+	case access == storage.AccessOwner, !permission.Valid:
+		return storage.ErrNoteNotFound
+	case access == storage.AccessEdit && permission.String != "edit":
+		return storage.ErrForbidden
+	}
+	return nil
+}
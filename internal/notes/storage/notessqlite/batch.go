@@ -0,0 +1,133 @@
+package notessqlite
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"slices"
+)
+
+func (r *NoteRepository) Batch(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+	const op = "storage.sqlite.Batch"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	results := make([]storage.BatchResult, len(ops))
+	for i, o := range ops {
+		if atomic {
+			n, err := applyBatchOp(tx, userID, o)
+			if err != nil {
+				for j := range results {
+					results[j] = storage.BatchResult{Err: storage.ErrBatchAborted}
+				}
+				results[i].Err = batchError(op, err)
+				return results, nil
+			}
+			results[i].Note = n
+			continue
+		}
+
+		// Savepoints let a failed operation be undone without losing the
+		// ones before it.
+		if _, err := tx.Exec("SAVEPOINT batch_op"); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		n, err := applyBatchOp(tx, userID, o)
+		if err != nil {
+			if _, rbErr := tx.Exec("ROLLBACK TO batch_op"); rbErr != nil {
+				return nil, fmt.Errorf("%s: %w", op, rbErr)
+			}
+			results[i].Err = batchError(op, err)
+		} else {
+			results[i].Note = n
+		}
+		if _, err := tx.Exec("RELEASE batch_op"); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+func applyBatchOp(q querier, userID int, o storage.BatchOp) (*entity.Note, error) {
+	switch o.Type {
+	case storage.BatchCreate:
+		n := &entity.Note{UserID: userID, NotebookID: o.NotebookID}
+		if o.Title != nil {
+			n.Title = *o.Title
+		}
+		if o.Content != nil {
+			n.Content = *o.Content
+		}
+		if o.Format != nil {
+			n.Format = *o.Format
+		}
+		if o.Tags != nil {
+			n.Tags = *o.Tags
+		}
+		return createNote(q, n)
+
+	case storage.BatchUpdate:
+		n, err := getNote(q, o.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		n.Version = o.Version
+		if o.Title != nil {
+			n.Title = *o.Title
+		}
+		if o.Content != nil {
+			n.Content = *o.Content
+		}
+		n.Format = ""
+		if o.Format != nil {
+			n.Format = *o.Format
+		}
+		n.Tags = nil
+		if o.Tags != nil {
+			n.Tags = *o.Tags
+		}
+		return updateNote(q, o.ID, userID, n)
+
+	case storage.BatchDelete:
+		return deleteNote(q, o.ID, userID, o.Version)
+
+	case storage.BatchTag:
+		n, err := getNote(q, o.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		tags := slices.DeleteFunc(n.Tags, func(t string) bool { return slices.Contains(o.RemoveTags, t) })
+		for _, t := range o.AddTags {
+			if !slices.Contains(tags, t) {
+				tags = append(tags, t)
+			}
+		}
+		n.Version = o.Version
+		n.Format = ""
+		n.Tags = tags
+		return updateNote(q, o.ID, userID, n)
+	}
+
+	return nil, fmt.Errorf("unknown batch operation %q", o.Type)
+}
+
+// batchError keeps the sentinel errors callers map to statuses and wraps
+// anything else.
+func batchError(op string, err error) error {
+	for _, sentinel := range []error{storage.ErrNoteNotFound, storage.ErrNotebookNotFound, storage.ErrVersionMismatch, storage.ErrForbidden} {
+		if errors.Is(err, sentinel) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
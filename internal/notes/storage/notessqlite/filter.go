@@ -0,0 +1,68 @@
+package notessqlite
+
+import (
+	"fmt"
+	"gonotes/internal/notes/query"
+	"gonotes/internal/notes/storage"
+	"strings"
+)
+
+// compileFilter translates a parsed search query into a parameterised SQL
+// condition over the notes table.
+func compileFilter(node query.Node) (string, []any, error) {
+	switch n := node.(type) {
+	case *query.And:
+		return compileList(n.Nodes, " AND ")
+	case *query.Or:
+		return compileList(n.Nodes, " OR ")
+	case *query.Not:
+		cond, args, err := compileFilter(n.Node)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + cond, args, nil
+	case *query.Text:
+		// Words match case-insensitively; quoted phrases must appear exactly
+		// as written.
+		match, arg := `%s LIKE ? ESCAPE '\'`, any("%"+escapeLike(n.Value)+"%")
+		if n.Exact {
+			match, arg = "instr(%s, ?) > 0", n.Value
+		}
+		switch n.Field {
+		case query.FieldTitle:
+			return "(" + fmt.Sprintf(match, "title") + ")", []any{arg}, nil
+		case query.FieldContent:
+			return "(" + fmt.Sprintf(match, "content") + ")", []any{arg}, nil
+		default:
+			return "(" + fmt.Sprintf(match, "title") + " OR " + fmt.Sprintf(match, "content") + ")", []any{arg, arg}, nil
+		}
+	case *query.Date:
+		if n.Field == query.FieldBefore {
+			return "(created_at < ?)", []any{n.Time.UTC()}, nil
+		}
+		return "(created_at >= ?)", []any{n.Time.UTC()}, nil
+	case *query.Tag:
+		return "id IN (SELECT nt.note_id FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE t.name = ?)", []any{n.Name}, nil
+	}
+	return "", nil, fmt.Errorf("%w: unexpected node %T", storage.ErrInvalidQuery, node)
+}
+
+func compileList(nodes []query.Node, sep string) (string, []any, error) {
+	conds := make([]string, len(nodes))
+	var args []any
+	for i, node := range nodes {
+		cond, nodeArgs, err := compileFilter(node)
+		if err != nil {
+			return "", nil, err
+		}
+		conds[i] = cond
+		args = append(args, nodeArgs...)
+	}
+	return "(" + strings.Join(conds, sep) + ")", args, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
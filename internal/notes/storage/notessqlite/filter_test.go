@@ -0,0 +1,81 @@
+package notessqlite
+
+import (
+	"gonotes/internal/notes/query"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compileFilter(t *testing.T) {
+	cases := []struct {
+		name         string
+		input        string
+		expectedSQL  string
+		expectedArgs []any
+	}{
+		{
+			name:         "bare word matches title or content",
+			input:        "draft",
+			expectedSQL:  `(title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\')`,
+			expectedArgs: []any{"%draft%", "%draft%"},
+		},
+		{
+			name:         "like wildcards are escaped",
+			input:        `100%_done`,
+			expectedSQL:  `(title LIKE ? ESCAPE '\' OR content LIKE ? ESCAPE '\')`,
+			expectedArgs: []any{`%100\%\_done%`, `%100\%\_done%`},
+		},
+		{
+			name:         "phrase matches exactly",
+			input:        `"100% done_"`,
+			expectedSQL:  `(instr(title, ?) > 0 OR instr(content, ?) > 0)`,
+			expectedArgs: []any{"100% done_", "100% done_"},
+		},
+		{
+			name:         "phrase in a field",
+			input:        `content:"Weekly Sync"`,
+			expectedSQL:  `(instr(content, ?) > 0)`,
+			expectedArgs: []any{"Weekly Sync"},
+		},
+		{
+			name:        "fields, dates and negation",
+			input:       "title:meeting -content:draft before:2026-01-01",
+			expectedSQL: `((title LIKE ? ESCAPE '\') AND NOT (content LIKE ? ESCAPE '\') AND (created_at < ?))`,
+			expectedArgs: []any{
+				"%meeting%",
+				"%draft%",
+				time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:         "or group",
+			input:        "title:a OR after:2025-01-01",
+			expectedSQL:  `((title LIKE ? ESCAPE '\') OR (created_at >= ?))`,
+			expectedArgs: []any{"%a%", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := query.Parse(tc.input)
+			assert.NoError(t, err)
+
+			sql, args, err := compileFilter(node)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSQL, sql)
+			assert.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
+
+func Test_compileFilterTag(t *testing.T) {
+	node, err := query.Parse("-tag:Draft")
+	assert.NoError(t, err)
+
+	sql, args, err := compileFilter(node)
+	assert.NoError(t, err)
+	assert.Equal(t, "NOT id IN (SELECT nt.note_id FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE t.name = ?)", sql)
+	assert.Equal(t, []any{"draft"}, args)
+}
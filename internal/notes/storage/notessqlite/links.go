@@ -0,0 +1,84 @@
+package notessqlite
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+)
+
+// indexBatch is how many notes IndexLinks reads per transaction.
+const indexBatch = 100
+
+// setLinks replaces the wiki links of a note with those in content.
+func setLinks(q querier, noteID int, content string) error {
+	if _, err := q.Exec("DELETE FROM note_links WHERE note_id = ?", noteID); err != nil {
+		return err
+	}
+
+	for i, title := range entity.WikiLinks(content) {
+		// Titles differing only in case are the same link.
+		_, err := q.Exec("INSERT INTO note_links(note_id, position, title) VALUES (?, ?, ?) ON CONFLICT(note_id, title) DO NOTHING", noteID, i, title)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := q.Exec("UPDATE notes SET links_indexed = 1 WHERE id = ?", noteID)
+	return err
+}
+
+// IndexLinks parses the wiki links of notes written before links were
+// tracked and returns how many notes it indexed.
+func (r *NoteRepository) IndexLinks() (int, error) {
+	const op = "storage.sqlite.IndexLinks"
+
+	total := 0
+	for {
+		n, err := r.indexLinksBatch()
+		if err != nil {
+			return total, fmt.Errorf("%s: %w", op, err)
+		}
+		if n == 0 {
+			return total, nil
+		}
+		total += n
+	}
+}
+
+func (r *NoteRepository) indexLinksBatch() (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, content FROM notes WHERE links_indexed = 0 LIMIT ?", indexBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	type pending struct {
+		id      int
+		content string
+	}
+	var notes []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		notes = append(notes, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range notes {
+		if err := setLinks(tx, p.id, p.content); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(notes), tx.Commit()
+}
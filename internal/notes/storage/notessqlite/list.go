@@ -0,0 +1,176 @@
+package notessqlite
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (r *NoteRepository) List(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+	const op = "storage.sqlite.List"
+
+	where := []string{"user_id = ?", "deleted_at IS NULL"}
+	args := []any{userID}
+
+	addRange := func(column string, after, before time.Time) {
+		if !after.IsZero() {
+			where = append(where, column+" >= ?")
+			args = append(args, after.UTC())
+		}
+		if !before.IsZero() {
+			where = append(where, column+" < ?")
+			args = append(args, before.UTC())
+		}
+	}
+	addRange("created_at", q.CreatedAfter, q.CreatedBefore)
+	addRange("updated_at", q.UpdatedAfter, q.UpdatedBefore)
+
+	if !q.IncludeArchived {
+		where = append(where, "archived = 0")
+	}
+
+	if q.NotebookID != nil {
+		where = append(where, "notebook_id = ?")
+		args = append(args, *q.NotebookID)
+	}
+
+	if len(q.Tags) > 0 {
+		cond, tagArgs := tagCondition(q.Tags, q.MatchAnyTag)
+		where = append(where, cond)
+		args = append(args, tagArgs...)
+	}
+
+	if q.Filter != nil {
+		cond, filterArgs, err := compileFilter(q.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		where = append(where, cond)
+		args = append(args, filterArgs...)
+	}
+
+	column := string(q.Sort)
+	desc := q.Desc
+	backward := q.Cursor != nil && q.Cursor.Backward
+	if backward {
+		desc = !desc
+	}
+
+	// Pinned notes come first whatever the sort direction. The pin key is
+	// oriented so that it sorts in the same direction as the rest of the key,
+	// which keeps the row-value comparison below valid.
+	pinKey := "(1 - pinned)"
+	if q.Desc {
+		pinKey = "pinned"
+	}
+
+	if q.Cursor != nil {
+		value, err := cursorValue(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		cmp := ">"
+		if desc {
+			cmp = "<"
+		}
+		pinned := 0
+		if q.Cursor.Pinned {
+			pinned = 1
+		}
+		if !q.Desc {
+			pinned = 1 - pinned
+		}
+		where = append(where, fmt.Sprintf("(%s, %s, id) %s (?, ?, ?)", pinKey, column, cmp))
+		args = append(args, pinned, value, q.Cursor.ID)
+	}
+
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM notes WHERE %s ORDER BY %s %s, %s %s, id %s LIMIT ?",
+		noteColumns, strings.Join(where, " AND "), pinKey, dir, column, dir, dir)
+	args = append(args, q.Limit+1)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := []entity.Note{}
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := loadTagsList(r.db, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	more := len(notes) > q.Limit
+	if more {
+		notes = notes[:q.Limit]
+	}
+	if backward {
+		slices.Reverse(notes)
+	}
+
+	page := &storage.NotePage{Notes: notes}
+	if len(notes) == 0 {
+		return page, nil
+	}
+
+	first, last := &notes[0], &notes[len(notes)-1]
+	if backward {
+		page.Next = storage.NewCursor(q, last, false)
+		if more {
+			page.Prev = storage.NewCursor(q, first, true)
+		}
+	} else {
+		if more {
+			page.Next = storage.NewCursor(q, last, false)
+		}
+		if q.Cursor != nil {
+			page.Prev = storage.NewCursor(q, first, true)
+		}
+	}
+
+	return page, nil
+}
+
+func cursorValue(c *storage.Cursor) (any, error) {
+	if c.Sort == storage.SortTitle {
+		return c.Value, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+	return t.UTC(), nil
+}
+
+// tagCondition matches notes carrying every tag, or any of them when
+// matchAny is set.
+func tagCondition(tags []string, matchAny bool) (string, []any) {
+	args := make([]any, len(tags))
+	for i, t := range tags {
+		args[i] = t
+	}
+
+	sub := `SELECT nt.note_id FROM note_tags nt JOIN tags t ON t.id = nt.tag_id WHERE t.name IN (` + placeholders(len(tags)) + `)`
+	if matchAny {
+		return "id IN (" + sub + ")", args
+	}
+	return "id IN (" + sub + " GROUP BY nt.note_id HAVING COUNT(DISTINCT t.id) = ?)", append(args, len(tags))
+}
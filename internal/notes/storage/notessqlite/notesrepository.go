@@ -6,11 +6,53 @@ import (
 	"fmt"
 	"gonotes/internal/notes/entity"
 	"gonotes/internal/notes/storage"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const noteColumns = "id, user_id, notebook_id, title, content, format, created_at, updated_at, version, pinned, archived, favourite, deleted_at"
+
+// qualifiedNoteColumns returns noteColumns prefixed with a table alias.
+func qualifiedNoteColumns(alias string) string {
+	cols := strings.Split(noteColumns, ", ")
+	for i, c := range cols {
+		cols[i] = alias + "." + c
+	}
+	return strings.Join(cols, ", ")
+}
+
+// sharedAccess is the WHERE condition for notes a user owns or has been given
+// any share on. It takes the user ID twice.
+const sharedAccess = "(user_id = ? OR id IN (SELECT note_id FROM note_shares WHERE user_id = ?))"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanNote scans a row starting with noteColumns; extra receives any
+// trailing columns.
+func scanNote(s scanner, extra ...any) (*entity.Note, error) {
+	var (
+		n          entity.Note
+		notebookID sql.NullInt64
+		deletedAt  sql.NullTime
+	)
+	dest := append([]any{&n.ID, &n.UserID, &notebookID, &n.Title, &n.Content, &n.Format, &n.CreatedAt, &n.UpdatedAt, &n.Version, &n.Pinned, &n.Archived, &n.Favourite, &deletedAt}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if notebookID.Valid {
+		id := int(notebookID.Int64)
+		n.NotebookID = &id
+	}
+	if deletedAt.Valid {
+		n.DeletedAt = &deletedAt.Time
+	}
+	return &n, nil
+}
+
 type NoteRepository struct {
 	db *sql.DB
 }
@@ -22,43 +64,134 @@ func NewNoteRepository(db *sql.DB) *NoteRepository {
 func (r *NoteRepository) Create(note *entity.Note) (*entity.Note, error) {
 	const op = "storage.sqlite.Create"
 
-	time := time.Now()
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
 
-	res, err := r.db.Exec("INSERT INTO notes(user_id, title, content, created_at) VALUES (?, ?, ?, ?)", note.UserID, note.Title, note.Content, time)
+	created, err := createNote(tx, note)
 	if err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return created, nil
+}
+
+func createNote(q querier, note *entity.Note) (*entity.Note, error) {
+	if err := checkNotebook(q, note.UserID, note.NotebookID); err != nil {
+		return nil, err
+	}
+
+	format := note.Format
+	if format == "" {
+		format = entity.FormatPlain
+	}
+
+	// Imported notes keep their original timestamps.
+	createdAt := note.CreatedAt.UTC()
+	if note.CreatedAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+	updatedAt := note.UpdatedAt.UTC()
+	if note.UpdatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+
+	res, err := q.Exec("INSERT INTO notes(user_id, notebook_id, title, content, format, created_at, updated_at, pinned, archived, favourite) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		note.UserID, note.NotebookID, note.Title, note.Content, format, createdAt, updatedAt, note.Pinned, note.Archived, note.Favourite)
+	if err != nil {
+		return nil, err
+	}
+
 	id, err := res.LastInsertId()
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
+	}
+
+	if err = addRevision(q, int(id), note.UserID, note.Title, note.Content, updatedAt); err != nil {
+		return nil, err
+	}
+
+	if err = setLinks(q, int(id), note.Content); err != nil {
+		return nil, err
+	}
+
+	tags := note.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	if err = setTags(q, note.UserID, int(id), tags); err != nil {
+		return nil, err
 	}
 
 	return &entity.Note{
-		ID:        int(id),
-		Title:     note.Title,
-		Content:   note.Content,
-		CreatedAt: time,
+		ID:         int(id),
+		UserID:     note.UserID,
+		NotebookID: note.NotebookID,
+		Title:      note.Title,
+		Content:    note.Content,
+		Format:     format,
+		Tags:       tags,
+		CreatedAt:  createdAt,
+		UpdatedAt:  updatedAt,
+		Version:    1,
+		Pinned:     note.Pinned,
+		Archived:   note.Archived,
+		Favourite:  note.Favourite,
 	}, nil
 }
 
+// Get returns a note the user owns or that has been shared with them.
 func (r *NoteRepository) Get(id int, userID int) (*entity.Note, error) {
 	const op = "storage.sqlite.Get"
 
-	var n entity.Note
-	err := r.db.QueryRow("SELECT id, title, content FROM notes WHERE id = ? AND user_id = ?", id, userID).Scan(&n.ID, &n.Title, &n.Content)
+	n, err := getNote(r.db, id, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func getNote(q querier, id int, userID int) (*entity.Note, error) {
+	n, err := scanNote(q.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ? AND deleted_at IS NULL AND "+sharedAccess, id, userID, userID))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrNoteNotFound
 		}
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, err
 	}
 
-	return &n, nil
+	if err := loadTags(q, []*entity.Note{n}); err != nil {
+		return nil, err
+	}
+
+	return n, nil
 }
 
-func (r *NoteRepository) Delete(id int, userID int) (*entity.Note, error) {
-	const op = "storage.sqlite.Delete"
+// Update replaces the title and content of a note, its format when
+// note.Format is set and its tags when note.Tags is non-nil. A non-zero
+// note.Version is treated as the version the caller expects to overwrite; if
+// the stored note has moved on, ErrVersionMismatch is returned and nothing is
+// written. A new revision is recorded whenever the title or content changes,
+// authored by userID.
+//
+// The owner and users the note is shared with for editing may update it;
+// users with read access get ErrForbidden. Tags are always resolved in the
+// owner's namespace.
+func (r *NoteRepository) Update(id int, userID int, note *entity.Note) (*entity.Note, error) {
+	const op = "storage.sqlite.Update"
 
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -66,31 +199,184 @@ func (r *NoteRepository) Delete(id int, userID int) (*entity.Note, error) {
 	}
 	defer tx.Rollback()
 
-	var n entity.Note
-	err = tx.QueryRow("SELECT id, title, content FROM notes WHERE id = ? AND user_id = ?", id, userID).Scan(&n.ID, &n.Title, &n.Content)
+	n, err := updateNote(tx, id, userID, note)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrVersionMismatch) || errors.Is(err, storage.ErrForbidden) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func updateNote(q querier, id int, userID int, note *entity.Note) (*entity.Note, error) {
+	var canEdit bool
+	current, err := scanNote(q.QueryRow("SELECT "+noteColumns+", user_id = ? OR EXISTS (SELECT 1 FROM note_shares WHERE note_id = notes.id AND user_id = ? AND permission = 'edit') FROM notes WHERE id = ? AND deleted_at IS NULL AND "+sharedAccess, userID, userID, id, userID, userID), &canEdit)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, storage.ErrNoteNotFound
 		}
+		return nil, err
+	}
+	if !canEdit {
+		return nil, storage.ErrForbidden
+	}
+	ownerID := current.UserID
+
+	if note.Version != 0 && note.Version != current.Version {
+		return nil, storage.ErrVersionMismatch
+	}
+
+	format := note.Format
+	if format == "" {
+		format = current.Format
+	}
+
+	now := time.Now().UTC()
+	_, err = q.Exec("UPDATE notes SET title = ?, content = ?, format = ?, updated_at = ?, version = version + 1 WHERE id = ?", note.Title, note.Content, format, now, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Title != current.Title || note.Content != current.Content {
+		if err = addRevision(q, id, userID, note.Title, note.Content, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if note.Content != current.Content {
+		if err = setLinks(q, id, note.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	if note.Tags != nil {
+		if err = setTags(q, ownerID, id, note.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	n, err := scanNote(q.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ?", id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = loadTags(q, []*entity.Note{n}); err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// Move places a note in a notebook, or at the top level when notebookID is
+// nil.
+func (r *NoteRepository) Move(id int, userID int, notebookID *int) (*entity.Note, error) {
+	const op = "storage.sqlite.Move"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err = checkNotebook(tx, userID, notebookID); err != nil {
+		if errors.Is(err, storage.ErrNotebookNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := tx.Exec("UPDATE notes SET notebook_id = ?, updated_at = ?, version = version + 1 WHERE id = ? AND user_id = ? AND deleted_at IS NULL", notebookID, time.Now().UTC(), id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	if affected == 0 {
+		return nil, storage.ErrNoteNotFound
+	}
 
-	_, err = tx.Exec("DELETE FROM notes WHERE id = ? AND user_id = ?", id, userID)
+	n, err := scanNote(tx.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, userID))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err = loadTags(tx, []*entity.Note{n}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &n, nil
+	return n, nil
+}
+
+// Delete moves a note to the trash. A non-zero version must match the stored
+// one, otherwise ErrVersionMismatch is returned.
+func (r *NoteRepository) Delete(id int, userID int, version int) (*entity.Note, error) {
+	const op = "storage.sqlite.Delete"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	n, err := deleteNote(tx, id, userID, version)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrVersionMismatch) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func deleteNote(q querier, id int, userID int, version int) (*entity.Note, error) {
+	n, err := scanNote(q.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ? AND user_id = ? AND deleted_at IS NULL", id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrNoteNotFound
+		}
+		return nil, err
+	}
+	if version != 0 && n.Version != version {
+		return nil, storage.ErrVersionMismatch
+	}
+
+	now := time.Now().UTC()
+	_, err = q.Exec("UPDATE notes SET deleted_at = ?, version = version + 1 WHERE id = ? AND user_id = ?", now, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	n.DeletedAt = &now
+	n.Version++
+
+	if err = loadTags(q, []*entity.Note{n}); err != nil {
+		return nil, err
+	}
+
+	return n, nil
 }
 
 func (r *NoteRepository) GetAll(userID int) ([]entity.Note, error) {
 	const op = "storage.sqlite.GetAll"
 
-	rows, err := r.db.Query("SELECT id, title, content, created_at FROM notes WHERE user_id = ?", userID)
+	rows, err := r.db.Query("SELECT "+noteColumns+" FROM notes WHERE user_id = ? AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -98,16 +384,123 @@ func (r *NoteRepository) GetAll(userID int) ([]entity.Note, error) {
 
 	var notes []entity.Note
 	for rows.Next() {
-		var n entity.Note
-		if err := rows.Scan(&n.ID, &n.Title, &n.Content, &n.CreatedAt); err != nil {
+		n, err := scanNote(rows)
+		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		notes = append(notes, n)
+		notes = append(notes, *n)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := loadTagsList(r.db, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	return notes, nil
 }
+
+// Lookup returns the user's notes with the given IDs, trashed or not, in ID
+// order. IDs that do not match one of the user's notes are skipped.
+func (r *NoteRepository) Lookup(userID int, ids []int) ([]entity.Note, error) {
+	const op = "storage.sqlite.Lookup"
+
+	notes := []entity.Note{}
+	if len(ids) == 0 {
+		return notes, nil
+	}
+
+	args := []any{userID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	rows, err := r.db.Query("SELECT "+noteColumns+" FROM notes WHERE user_id = ? AND id IN ("+placeholders(len(ids))+") ORDER BY id", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, *n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := loadTagsList(r.db, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notes, nil
+}
+
+// checkNotebook verifies that a notebook exists and belongs to the user. A nil
+// notebook ID stands for the top level and always passes.
+func checkNotebook(q querier, userID int, notebookID *int) error {
+	if notebookID == nil {
+		return nil
+	}
+
+	var exists int
+	err := q.QueryRow("SELECT 1 FROM notebooks WHERE id = ? AND user_id = ?", *notebookID, userID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return storage.ErrNotebookNotFound
+	}
+	return err
+}
+
+// SetFlag turns a pinned, archived or favourite flag on or off. Flags do not
+// touch updated_at but do bump the version, so the note's ETag changes.
+func (r *NoteRepository) SetFlag(id int, userID int, flag storage.Flag, value bool) (*entity.Note, error) {
+	const op = "storage.sqlite.SetFlag"
+
+	var column string
+	switch flag {
+	case storage.FlagPinned, storage.FlagArchived, storage.FlagFavourite:
+		column = string(flag)
+	default:
+		return nil, fmt.Errorf("%s: unknown flag %q", op, flag)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE notes SET "+column+" = ?, version = version + 1 WHERE id = ? AND user_id = ? AND deleted_at IS NULL", value, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return nil, storage.ErrNoteNotFound
+	}
+
+	n, err := scanNote(tx.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = loadTags(tx, []*entity.Note{n}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
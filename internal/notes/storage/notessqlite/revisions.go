@@ -0,0 +1,13 @@
+package notessqlite
+
+import "time"
+
+// addRevision records the title and content of a note as its next revision.
+func addRevision(q querier, noteID int, authorID int, title, content string, at time.Time) error {
+	_, err := q.Exec(`
+		INSERT INTO note_revisions(note_id, revision, author_id, title, content, created_at)
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?
+		FROM note_revisions WHERE note_id = ?`,
+		noteID, authorID, title, content, at, noteID)
+	return err
+}
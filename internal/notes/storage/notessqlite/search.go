@@ -0,0 +1,68 @@
+package notessqlite
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"strings"
+)
+
+// Search ranks the user's notes against query with bm25, weighting title
+// matches above content matches. The notes_fts table requires the
+// sqlite_fts5 build tag.
+func (r *NoteRepository) Search(userID int, query string, limit int) ([]entity.SearchResult, error) {
+	const op = "storage.sqlite.Search"
+
+	rows, err := r.db.Query(`
+		SELECT `+qualifiedNoteColumns("n")+`,
+			highlight(notes_fts, 0, ?, ?),
+			snippet(notes_fts, 1, ?, ?, '…', 24),
+			bm25(notes_fts, 10.0, 1.0) AS rank
+		FROM notes_fts
+		JOIN notes n ON n.id = notes_fts.rowid
+		WHERE notes_fts MATCH ? AND n.user_id = ? AND n.deleted_at IS NULL
+		ORDER BY rank
+		LIMIT ?`,
+		entity.HighlightStart, entity.HighlightEnd,
+		entity.HighlightStart, entity.HighlightEnd,
+		ftsQuery(query), userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	results := []entity.SearchResult{}
+	for rows.Next() {
+		var res entity.SearchResult
+		n, err := scanNote(rows, &res.TitleHighlight, &res.Snippet, &res.Rank)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		res.Note = *n
+		results = append(results, res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	notes := make([]*entity.Note, len(results))
+	for i := range results {
+		notes[i] = &results[i].Note
+	}
+	if err := loadTags(r.db, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// ftsQuery turns free text into an FTS5 query that matches every word,
+// quoting each one so user input cannot inject FTS5 syntax.
+func ftsQuery(q string) string {
+	words := strings.Fields(q)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
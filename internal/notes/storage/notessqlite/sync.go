@@ -0,0 +1,104 @@
+package notessqlite
+
+import (
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+)
+
+// Changes reads from a single transaction so the page and the token that
+// follows it agree. Sequences are assigned by triggers, see the
+// add_notes_sync migration.
+func (r *NoteRepository) Changes(userID int, since storage.SyncToken, limit int) (*storage.ChangeSet, error) {
+	const op = "storage.sqlite.Changes"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT seq, id, deleted_at IS NOT NULL FROM notes WHERE seq > ? AND `+sharedAccess+`
+		UNION ALL
+		SELECT seq, note_id, 1 FROM note_tombstones WHERE seq > ? AND user_id = ?
+		ORDER BY seq
+		LIMIT ?`, since.Seq, userID, userID, since.Seq, userID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	set := &storage.ChangeSet{Changes: []storage.Change{}}
+	for rows.Next() {
+		var c storage.Change
+		if err := rows.Scan(&c.Seq, &c.NoteID, &c.Deleted); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		set.Changes = append(set.Changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(set.Changes) > limit {
+		set.Changes = set.Changes[:limit]
+		set.HasMore = true
+	}
+
+	if err := loadChangedNotes(tx, set.Changes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if set.HasMore {
+		set.Next = storage.SyncToken{Seq: set.Changes[len(set.Changes)-1].Seq}
+	} else if err := tx.QueryRow("SELECT value FROM sync_seq").Scan(&set.Next.Seq); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return set, nil
+}
+
+// loadChangedNotes fills in the Note of every change that is not a deletion.
+func loadChangedNotes(q querier, changes []storage.Change) error {
+	var args []any
+	for _, c := range changes {
+		if !c.Deleted {
+			args = append(args, c.NoteID)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	rows, err := q.Query("SELECT "+noteColumns+" FROM notes WHERE id IN ("+placeholders(len(args))+")", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byID := make(map[int]*entity.Note, len(args))
+	var notes []*entity.Note
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return err
+		}
+		byID[n.ID] = n
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := loadTags(q, notes); err != nil {
+		return err
+	}
+
+	for i := range changes {
+		if !changes[i].Deleted {
+			changes[i].Note = byID[changes[i].NoteID]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package notessqlite
+
+import (
+	"database/sql"
+	"gonotes/internal/notes/entity"
+	"strings"
+)
+
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// setTags replaces the tags of a note, creating missing tags for the user.
+func setTags(q querier, userID, noteID int, tags []string) error {
+	if _, err := q.Exec("DELETE FROM note_tags WHERE note_id = ?", noteID); err != nil {
+		return err
+	}
+
+	for _, name := range tags {
+		if _, err := q.Exec("INSERT INTO tags(user_id, name) VALUES (?, ?) ON CONFLICT(user_id, name) DO NOTHING", userID, name); err != nil {
+			return err
+		}
+		_, err := q.Exec("INSERT INTO note_tags(note_id, tag_id) SELECT ?, id FROM tags WHERE user_id = ? AND name = ?", noteID, userID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags fills in the Tags of every note with a single query.
+func loadTags(q querier, notes []*entity.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*entity.Note, len(notes))
+	args := make([]any, len(notes))
+	for i, n := range notes {
+		n.Tags = []string{}
+		byID[n.ID] = n
+		args[i] = n.ID
+	}
+
+	rows, err := q.Query(`
+		SELECT nt.note_id, t.name
+		FROM note_tags nt
+		JOIN tags t ON t.id = nt.tag_id
+		WHERE nt.note_id IN (`+placeholders(len(args))+`)
+		ORDER BY t.name`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			noteID int
+			name   string
+		)
+		if err := rows.Scan(&noteID, &name); err != nil {
+			return err
+		}
+		if n, ok := byID[noteID]; ok {
+			n.Tags = append(n.Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+func loadTagsList(q querier, notes []entity.Note) error {
+	ptrs := make([]*entity.Note, len(notes))
+	for i := range notes {
+		ptrs[i] = &notes[i]
+	}
+	return loadTags(q, ptrs)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,149 @@
+package notessqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"time"
+)
+
+// ListTrash returns the user's trashed notes, most recently deleted first.
+func (r *NoteRepository) ListTrash(userID int) ([]entity.Note, error) {
+	const op = "storage.sqlite.ListTrash"
+
+	rows, err := r.db.Query("SELECT "+noteColumns+" FROM notes WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := []entity.Note{}
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, *n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := loadTagsList(r.db, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notes, nil
+}
+
+// Restore takes a note out of the trash.
+func (r *NoteRepository) Restore(id int, userID int) (*entity.Note, error) {
+	const op = "storage.sqlite.Restore"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE notes SET deleted_at = NULL, version = version + 1 WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return nil, storage.ErrNoteNotFound
+	}
+
+	n, err := scanNote(tx.QueryRow("SELECT "+noteColumns+" FROM notes WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = loadTags(tx, []*entity.Note{n}); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// Purge permanently removes a note that is in the trash.
+func (r *NoteRepository) Purge(id int, userID int) error {
+	const op = "storage.sqlite.Purge"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow("SELECT 1 FROM notes WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNoteNotFound
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = purgeNotes(tx, "id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// PurgeTrash permanently removes every note, of any user, that was moved to
+// the trash before the given time. It returns the number of notes removed.
+func (r *NoteRepository) PurgeTrash(before time.Time) (int64, error) {
+	const op = "storage.sqlite.PurgeTrash"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	n, err := purgeNotes(tx, "deleted_at IS NOT NULL AND deleted_at < ?", before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// purgeNotes hard-deletes the notes matching cond together with the rows
+// that reference them.
+func purgeNotes(q querier, cond string, args ...any) (int64, error) {
+	for _, table := range []string{"note_tags", "note_revisions", "attachments", "note_shares", "public_links", "reminders", "note_events", "note_crdt", "note_links"} {
+		_, err := q.Exec("DELETE FROM "+table+" WHERE note_id IN (SELECT id FROM notes WHERE "+cond+")", args...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := q.Exec("DELETE FROM notes WHERE "+cond, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -3,15 +3,62 @@ package storage
 import (
 	"errors"
 	"gonotes/internal/notes/entity"
+	"time"
 )
 
 var (
-	ErrNoteNotFound = errors.New("note not found")
+	ErrNoteNotFound     = errors.New("note not found")
+	ErrVersionMismatch  = errors.New("note version mismatch")
+	ErrNotebookNotFound = errors.New("notebook not found")
+	ErrForbidden        = errors.New("note is shared read-only")
+)
+
+// Access is what a user needs to be allowed to do with a note.
+type Access int
+
+const (
+	// AccessOwner is held by the owner alone.
+	AccessOwner Access = iota
+	// AccessRead is held by the owner and every user the note is shared with.
+	AccessRead
+	// AccessEdit is held by the owner and users the note is shared with for
+	// editing.
+	AccessEdit
+)
+
+// Flag is a boolean state of a note that can be toggled on its own.
+type Flag string
+
+const (
+	FlagPinned    Flag = "pinned"
+	FlagArchived  Flag = "archived"
+	FlagFavourite Flag = "favourite"
 )
 
 type NoteRepository interface {
 	Create(note *entity.Note) (*entity.Note, error)
 	Get(id int, userID int) (*entity.Note, error)
-	Delete(id int, userID int) (*entity.Note, error)
+	Update(id int, userID int, note *entity.Note) (*entity.Note, error)
+	Move(id int, userID int, notebookID *int) (*entity.Note, error)
+	SetFlag(id int, userID int, flag Flag, value bool) (*entity.Note, error)
+	Delete(id int, userID int, version int) (*entity.Note, error)
 	GetAll(userID int) ([]entity.Note, error)
+	// Lookup returns the user's notes with the given IDs, including those in
+	// the trash. IDs that match none of the user's notes are skipped.
+	Lookup(userID int, ids []int) ([]entity.Note, error)
+	List(userID int, q ListQuery) (*NotePage, error)
+	Search(userID int, query string, limit int) ([]entity.SearchResult, error)
+	ListTrash(userID int) ([]entity.Note, error)
+	Restore(id int, userID int) (*entity.Note, error)
+	Purge(id int, userID int) error
+	PurgeTrash(before time.Time) (int64, error)
+	// Batch applies ops in order within one transaction. When atomic is set,
+	// the first failing operation rolls back the whole batch and every other
+	// result carries ErrBatchAborted; otherwise each operation succeeds or
+	// fails on its own. The returned error is only set when the batch could
+	// not run at all.
+	Batch(userID int, ops []BatchOp, atomic bool) ([]BatchResult, error)
+	// Changes returns up to limit changes to the notes the user owns or has
+	// been shared, made after since.
+	Changes(userID int, since SyncToken, limit int) (*ChangeSet, error)
 }
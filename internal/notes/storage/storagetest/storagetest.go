@@ -0,0 +1,116 @@
+// Package storagetest provides test doubles for code built on the notes
+// storage.
+package storagetest
+
+import (
+	"gonotes/internal/events"
+	"gonotes/internal/notes/entity"
+	"gonotes/internal/notes/storage"
+	"sync"
+	"time"
+)
+
+// NoteRepository implements storage.NoteRepository with a func field per
+// method. Tests set the fields of the methods they expect to be called.
+type NoteRepository struct {
+	CreateFunc func(note *entity.Note) (*entity.Note, error)
+	GetFunc    func(id int, userID int) (*entity.Note, error)
+	UpdateFunc func(id int, userID int, note *entity.Note) (*entity.Note, error)
+	MoveFunc   func(id int, userID int, notebookID *int) (*entity.Note, error)
+	FlagFunc   func(id int, userID int, flag storage.Flag, value bool) (*entity.Note, error)
+	DeleteFunc func(id int, userID int, version int) (*entity.Note, error)
+	GetAllFunc func(userID int) ([]entity.Note, error)
+	LookupFunc func(userID int, ids []int) ([]entity.Note, error)
+	ListFunc   func(userID int, q storage.ListQuery) (*storage.NotePage, error)
+	SearchFunc func(userID int, query string, limit int) ([]entity.SearchResult, error)
+
+	ListTrashFunc  func(userID int) ([]entity.Note, error)
+	RestoreFunc    func(id int, userID int) (*entity.Note, error)
+	PurgeFunc      func(id int, userID int) error
+	PurgeTrashFunc func(before time.Time) (int64, error)
+
+	BatchFunc   func(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error)
+	ChangesFunc func(userID int, since storage.SyncToken, limit int) (*storage.ChangeSet, error)
+}
+
+func (m *NoteRepository) Create(n *entity.Note) (*entity.Note, error) {
+	return m.CreateFunc(n)
+}
+func (m *NoteRepository) Get(id int, userID int) (*entity.Note, error) {
+	return m.GetFunc(id, userID)
+}
+func (m *NoteRepository) Update(id int, userID int, n *entity.Note) (*entity.Note, error) {
+	return m.UpdateFunc(id, userID, n)
+}
+func (m *NoteRepository) Move(id int, userID int, notebookID *int) (*entity.Note, error) {
+	return m.MoveFunc(id, userID, notebookID)
+}
+func (m *NoteRepository) SetFlag(id int, userID int, flag storage.Flag, value bool) (*entity.Note, error) {
+	return m.FlagFunc(id, userID, flag, value)
+}
+func (m *NoteRepository) Delete(id, userID, version int) (*entity.Note, error) {
+	return m.DeleteFunc(id, userID, version)
+}
+func (m *NoteRepository) GetAll(userID int) ([]entity.Note, error) {
+	return m.GetAllFunc(userID)
+}
+func (m *NoteRepository) Lookup(userID int, ids []int) ([]entity.Note, error) {
+	return m.LookupFunc(userID, ids)
+}
+func (m *NoteRepository) List(userID int, q storage.ListQuery) (*storage.NotePage, error) {
+	return m.ListFunc(userID, q)
+}
+func (m *NoteRepository) Search(userID int, query string, limit int) ([]entity.SearchResult, error) {
+	return m.SearchFunc(userID, query, limit)
+}
+
+func (m *NoteRepository) ListTrash(userID int) ([]entity.Note, error) {
+	return m.ListTrashFunc(userID)
+}
+func (m *NoteRepository) Restore(id int, userID int) (*entity.Note, error) {
+	return m.RestoreFunc(id, userID)
+}
+func (m *NoteRepository) Purge(id int, userID int) error {
+	return m.PurgeFunc(id, userID)
+}
+func (m *NoteRepository) PurgeTrash(before time.Time) (int64, error) {
+	return m.PurgeTrashFunc(before)
+}
+
+func (m *NoteRepository) Batch(userID int, ops []storage.BatchOp, atomic bool) ([]storage.BatchResult, error) {
+	return m.BatchFunc(userID, ops, atomic)
+}
+
+func (m *NoteRepository) Changes(userID int, since storage.SyncToken, limit int) (*storage.ChangeSet, error) {
+	return m.ChangesFunc(userID, since, limit)
+}
+
+// LookupOwned is a LookupFunc that finds every requested note, owned by the
+// user.
+func LookupOwned(userID int, ids []int) ([]entity.Note, error) {
+	notes := []entity.Note{}
+	for _, id := range ids {
+		notes = append(notes, entity.Note{ID: id, UserID: userID})
+	}
+	return notes, nil
+}
+
+// EventRecorder collects the events published to it. It is safe for
+// concurrent use.
+type EventRecorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *EventRecorder) Publish(ev events.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+// Events returns the events published so far, oldest first.
+func (r *EventRecorder) Events() []events.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]events.Event(nil), r.events...)
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/notes/entity"
+)
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+// SyncToken marks how far a client has synced: every change up to and
+// including Seq.
+type SyncToken struct {
+	Seq int `json:"q"`
+}
+
+func (t SyncToken) Encode() string {
+	b, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeSyncToken parses a token from Encode. The empty string is the token
+// of a client that has never synced.
+func DecodeSyncToken(s string) (SyncToken, error) {
+	if s == "" {
+		return SyncToken{}, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return SyncToken{}, ErrInvalidSyncToken
+	}
+
+	var t SyncToken
+	if err := json.Unmarshal(b, &t); err != nil || t.Seq < 0 {
+		return SyncToken{}, ErrInvalidSyncToken
+	}
+	return t, nil
+}
+
+// Change is the latest state of a note a user can see, or, when Deleted is
+// set, the news that they no longer can: it was moved to the trash, purged or
+// unshared. Note is nil for deletions.
+type Change struct {
+	Seq     int
+	NoteID  int
+	Deleted bool
+	Note    *entity.Note
+}
+
+// ChangeSet is a page of changes in the order they happened. Next resumes
+// after them; when HasMore is false it also skips changes the user cannot
+// see.
+type ChangeSet struct {
+	Changes []Change
+	Next    SyncToken
+	HasMore bool
+}
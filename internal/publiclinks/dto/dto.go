@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/publiclinks/entity"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// maxPasswordLength is the longest password bcrypt accepts.
+const maxPasswordLength = 72
+
+type PublicLinkRequest struct {
+	// ExpiresAt is optional; links without it stay valid until revoked.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Password is optional; visitors must enter it before the note is shown.
+	Password string `json:"password"`
+}
+
+func (p *PublicLinkRequest) Bind(r *http.Request) error {
+	if p.ExpiresAt != nil {
+		if !p.ExpiresAt.After(time.Now()) {
+			return fmt.Errorf("expires_at must be in the future")
+		}
+		t := p.ExpiresAt.UTC()
+		p.ExpiresAt = &t
+	}
+	if len(p.Password) > maxPasswordLength {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordLength)
+	}
+	return nil
+}
+
+type PublicLinkResponse struct {
+	ID                int        `json:"id"`
+	NoteID            int        `json:"note_id"`
+	Token             string     `json:"token"`
+	URL               string     `json:"url"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	PasswordProtected bool       `json:"password_protected"`
+	Views             int        `json:"views"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+func (pr *PublicLinkResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewPublicLinkResponse(l *entity.PublicLink, token string) *PublicLinkResponse {
+	return &PublicLinkResponse{
+		ID:                l.ID,
+		NoteID:            l.NoteID,
+		Token:             token,
+		URL:               "/p/" + token,
+		ExpiresAt:         l.ExpiresAt,
+		PasswordProtected: l.PasswordHash != "",
+		Views:             l.Views,
+		CreatedAt:         l.CreatedAt,
+	}
+}
+
+// NewPublicLinkListResponse renders links with the tokens returned by token.
+func NewPublicLinkListResponse(links []entity.PublicLink, token func(l *entity.PublicLink) string) []render.Renderer {
+	list := make([]render.Renderer, len(links))
+	for i := range links {
+		list[i] = NewPublicLinkResponse(&links[i], token(&links[i]))
+	}
+	return list
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	notesentity "gonotes/internal/notes/entity"
+	"time"
+)
+
+// PublicLink lets anyone holding its token read a note without an account.
+// Nonce is the hex-encoded random part of the token; deleting the link
+// revokes the token.
+type PublicLink struct {
+	ID           int        `json:"id"`
+	NoteID       int        `json:"note_id"`
+	UserID       int        `json:"user_id"`
+	Nonce        string     `json:"-"`
+	PasswordHash string     `json:"-"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Views        int        `json:"views"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (l *PublicLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// PublicNote is a link together with the note it points at.
+type PublicNote struct {
+	Link      PublicLink
+	Title     string
+	Content   string
+	Format    notesentity.Format
+	UpdatedAt time.Time
+}
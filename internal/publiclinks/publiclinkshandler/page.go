@@ -0,0 +1,60 @@
+package publiclinkshandler
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// pageCSP only allows the inline styles of the page itself and images the
+// note links to.
+const pageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src https: data:; form-action 'self'; frame-ancestors 'none'"
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}</title>
+<style>body{max-width:48rem;margin:2rem auto;padding:0 1rem;font-family:system-ui,sans-serif;line-height:1.5}pre{white-space:pre-wrap}.error{color:#b00}</style>
+</head>
+<body>
+{{- if .Password}}
+<h1>This note is password protected</h1>
+{{- if .Message}}
+<p class="error">{{.Message}}</p>
+{{- end}}
+<form method="post">
+<input type="password" name="password" autofocus required>
+<button type="submit">Open</button>
+</form>
+{{- else if .Message}}
+<h1>{{.Message}}</h1>
+{{- else}}
+<h1>{{.Title}}</h1>
+{{.Body}}
+{{- end}}
+</body>
+</html>
+`))
+
+type page struct {
+	Title    string
+	Body     template.HTML
+	Message  string
+	Password bool
+}
+
+// writePage renders a standalone page. Link URLs contain the token, so the
+// page must not leak it through the Referer header or shared caches.
+func writePage(w http.ResponseWriter, status int, p page) error {
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Content-Security-Policy", pageCSP)
+	h.Set("Referrer-Policy", "no-referrer")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Robots-Tag", "noindex")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	return pageTemplate.Execute(w, p)
+}
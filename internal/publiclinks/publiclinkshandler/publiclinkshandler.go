@@ -0,0 +1,271 @@
+package publiclinkshandler
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/lib/markdown"
+	"gonotes/internal/middleware"
+	notesentity "gonotes/internal/notes/entity"
+	"gonotes/internal/publiclinks/dto"
+	"gonotes/internal/publiclinks/entity"
+	"gonotes/internal/publiclinks/storage"
+	"gonotes/internal/publiclinks/token"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxFormSize bounds the password form posted to a public link.
+const maxFormSize = 4 << 10
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.PublicLinkRepository
+	signer  *token.Signer
+	now     func() time.Time
+}
+
+func NewHandler(log *slog.Logger, storage storage.PublicLinkRepository, signer *token.Signer) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		signer:  signer,
+		now:     time.Now,
+	}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "publiclinks.handler.create"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.PublicLinkRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	nonce, err := token.NewNonce()
+	if err != nil {
+		log.Error("failed to generate nonce", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	link := &entity.PublicLink{
+		NoteID:    noteID,
+		UserID:    userID,
+		Nonce:     hex.EncodeToString(nonce),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if req.Password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Error("failed to hash password", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+			return
+		}
+		link.PasswordHash = string(hashed)
+	}
+
+	created, err := h.storage.Create(link)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to create public link", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewPublicLinkResponse(created, h.token(created)))
+
+	log.Info("public link created", slog.Int("id", created.ID), slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "publiclinks.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	list, err := h.storage.List(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list public links", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewPublicLinkListResponse(list, h.token))
+
+	log.Info("public links retrieved", slog.Int("note_id", noteID), slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+// Delete revokes a link; its token stops working immediately.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "publiclinks.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+	linkID, err := strconv.Atoi(chi.URLParam(r, "linkID"))
+	if err != nil {
+		log.Error("invalid link id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid link id format")))
+		return
+	}
+
+	if err := h.storage.Delete(linkID, noteID, userID); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrLinkNotFound) {
+			log.Error("public link not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to revoke public link", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("public link revoked", slog.Int("id", linkID), slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+// View renders the note behind a public link as a read-only page. It needs no
+// authentication. Password-protected links show a form on GET and check the
+// posted password on POST.
+func (h *Handler) View(w http.ResponseWriter, r *http.Request) {
+	const op = "publiclinks.handler.view"
+	log := h.log.With(slog.String("op", op))
+
+	id, nonce, err := h.signer.Parse(chi.URLParam(r, "token"))
+	if err != nil {
+		log.Info("invalid public link token")
+		writePage(w, http.StatusNotFound, page{Title: "Not found", Message: "This link does not exist or has been revoked."})
+		return
+	}
+
+	n, err := h.storage.Resolve(int(id))
+	if err != nil {
+		if errors.Is(err, storage.ErrLinkNotFound) {
+			log.Info("public link not found", slog.Int64("id", id))
+			writePage(w, http.StatusNotFound, page{Title: "Not found", Message: "This link does not exist or has been revoked."})
+			return
+		}
+		log.Error("failed to resolve public link", slog.Any("err", err))
+		writePage(w, http.StatusInternalServerError, page{Title: "Error", Message: "Something went wrong."})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(nonce)), []byte(n.Link.Nonce)) != 1 {
+		log.Info("public link nonce mismatch", slog.Int64("id", id))
+		writePage(w, http.StatusNotFound, page{Title: "Not found", Message: "This link does not exist or has been revoked."})
+		return
+	}
+	if n.Link.Expired(h.now()) {
+		log.Info("public link expired", slog.Int64("id", id))
+		writePage(w, http.StatusGone, page{Title: "Expired", Message: "This link has expired."})
+		return
+	}
+
+	if n.Link.PasswordHash != "" {
+		if r.Method != http.MethodPost {
+			writePage(w, http.StatusUnauthorized, page{Title: "Password required", Password: true})
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+		password := r.PostFormValue("password")
+		if bcrypt.CompareHashAndPassword([]byte(n.Link.PasswordHash), []byte(password)) != nil {
+			log.Info("wrong public link password", slog.Int64("id", id))
+			writePage(w, http.StatusUnauthorized, page{Title: "Password required", Password: true, Message: "Wrong password."})
+			return
+		}
+	}
+
+	body, err := renderNote(n)
+	if err != nil {
+		log.Error("failed to render note", slog.Any("err", err))
+		writePage(w, http.StatusInternalServerError, page{Title: "Error", Message: "Something went wrong."})
+		return
+	}
+
+	if err := h.storage.CountView(n.Link.ID); err != nil {
+		log.Error("failed to count view", slog.Any("err", err))
+	}
+
+	writePage(w, http.StatusOK, page{Title: n.Title, Body: body})
+
+	log.Info("public link viewed", slog.Int("id", n.Link.ID), slog.Int("note_id", n.Link.NoteID))
+}
+
+func (h *Handler) token(l *entity.PublicLink) string {
+	nonce, err := hex.DecodeString(l.Nonce)
+	if err != nil {
+		return ""
+	}
+	return h.signer.Sign(int64(l.ID), nonce)
+}
+
+// renderNote returns the sanitized HTML of the note, which is safe to embed
+// in the page unescaped.
+func renderNote(n *entity.PublicNote) (template.HTML, error) {
+	if n.Format == notesentity.FormatMarkdown {
+		out, err := markdown.Render(n.Content)
+		return template.HTML(out), err
+	}
+	return template.HTML(markdown.RenderPlain(n.Content)), nil
+}
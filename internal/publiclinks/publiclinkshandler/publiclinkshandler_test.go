@@ -0,0 +1,224 @@
+package publiclinkshandler_test
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	notesentity "gonotes/internal/notes/entity"
+	"gonotes/internal/publiclinks/dto"
+	"gonotes/internal/publiclinks/entity"
+	"gonotes/internal/publiclinks/publiclinkshandler"
+	"gonotes/internal/publiclinks/storage"
+	"gonotes/internal/publiclinks/token"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type mockLinksRepo struct {
+	links map[int]entity.PublicNote
+	views map[int]int
+}
+
+func newRepo() *mockLinksRepo {
+	return &mockLinksRepo{links: map[int]entity.PublicNote{}, views: map[int]int{}}
+}
+
+func (m *mockLinksRepo) Create(l *entity.PublicLink) (*entity.PublicLink, error) {
+	if l.NoteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	created := *l
+	created.ID = len(m.links) + 1
+	m.links[created.ID] = entity.PublicNote{Link: created, Title: "Plan", Content: "**bold**", Format: notesentity.FormatMarkdown}
+	return &created, nil
+}
+func (m *mockLinksRepo) List(noteID, userID int) ([]entity.PublicLink, error) {
+	var list []entity.PublicLink
+	for _, n := range m.links {
+		list = append(list, n.Link)
+	}
+	return list, nil
+}
+func (m *mockLinksRepo) Delete(id, noteID, userID int) error {
+	if _, ok := m.links[id]; !ok {
+		return storage.ErrLinkNotFound
+	}
+	delete(m.links, id)
+	return nil
+}
+func (m *mockLinksRepo) Resolve(id int) (*entity.PublicNote, error) {
+	n, ok := m.links[id]
+	if !ok {
+		return nil, storage.ErrLinkNotFound
+	}
+	return &n, nil
+}
+func (m *mockLinksRepo) CountView(id int) error {
+	m.views[id]++
+	return nil
+}
+
+var signer = token.NewSigner("secret")
+
+func newHandler(repo *mockLinksRepo) *publiclinkshandler.Handler {
+	return publiclinkshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, signer)
+}
+
+func withParams(req *http.Request, params map[string]string, withUser bool) *http.Request {
+	rctx := chi.NewRouteContext()
+	for k, v := range params {
+		rctx.URLParams.Add(k, v)
+	}
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+// addLink stores a link directly and returns its token.
+func addLink(t *testing.T, repo *mockLinksRepo, id int, password string, expiresAt *time.Time) string {
+	nonce, err := token.NewNonce()
+	require.NoError(t, err)
+
+	link := entity.PublicLink{ID: id, NoteID: 1, UserID: 1, Nonce: hex.EncodeToString(nonce), ExpiresAt: expiresAt}
+	if password != "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		require.NoError(t, err)
+		link.PasswordHash = string(hashed)
+	}
+	repo.links[id] = entity.PublicNote{Link: link, Title: "<Plan>", Content: "**bold**", Format: notesentity.FormatMarkdown}
+
+	return signer.Sign(int64(id), nonce)
+}
+
+func Test_Create(t *testing.T) {
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name              string
+		noteID            string
+		body              string
+		withUser          bool
+		expectedStatus    int
+		expectedProtected bool
+	}{
+		{name: "unauthorized", noteID: "1", body: `{}`, expectedStatus: http.StatusUnauthorized},
+		{name: "plain link", noteID: "1", body: `{}`, withUser: true, expectedStatus: http.StatusCreated},
+		{name: "expiring with password", noteID: "1", body: `{"expires_at":"` + future + `","password":"hunter2"}`, withUser: true, expectedStatus: http.StatusCreated, expectedProtected: true},
+		{name: "expiry in the past", noteID: "1", body: `{"expires_at":"` + past + `"}`, withUser: true, expectedStatus: http.StatusBadRequest},
+		{name: "note not found", noteID: "2", body: `{}`, withUser: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo()
+			h := newHandler(repo)
+
+			req := httptest.NewRequest(http.MethodPost, "/notes/"+tt.noteID+"/public-links", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			h.Create(rr, withParams(req, map[string]string{"id": tt.noteID}, tt.withUser))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var resp dto.PublicLinkResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedProtected, resp.PasswordProtected)
+			assert.Equal(t, "/p/"+resp.Token, resp.URL)
+
+			id, _, err := signer.Parse(resp.Token)
+			require.NoError(t, err)
+			assert.Equal(t, int64(resp.ID), id)
+		})
+	}
+}
+
+func Test_View(t *testing.T) {
+	repo := newRepo()
+	open := addLink(t, repo, 1, "", nil)
+	protected := addLink(t, repo, 2, "hunter2", nil)
+	past := time.Now().Add(-time.Minute)
+	expired := addLink(t, repo, 3, "", &past)
+	revoked := addLink(t, repo, 4, "", nil)
+	delete(repo.links, 4)
+
+	forged := []byte(open)
+	forged[len(forged)-1] ^= 1
+
+	tests := []struct {
+		name           string
+		token          string
+		password       string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "open link", token: open, expectedStatus: http.StatusOK, expectedBody: "<strong>bold</strong>"},
+		{name: "forged token", token: string(forged), expectedStatus: http.StatusNotFound},
+		{name: "revoked", token: revoked, expectedStatus: http.StatusNotFound},
+		{name: "expired", token: expired, expectedStatus: http.StatusGone},
+		{name: "password form", token: protected, expectedStatus: http.StatusUnauthorized, expectedBody: `type="password"`},
+		{name: "wrong password", token: protected, password: "nope", expectedStatus: http.StatusUnauthorized, expectedBody: "Wrong password."},
+		{name: "right password", token: protected, password: "hunter2", expectedStatus: http.StatusOK, expectedBody: "<strong>bold</strong>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/p/"+tt.token, nil)
+			if tt.password != "" {
+				form := url.Values{"password": {tt.password}}
+				req = httptest.NewRequest(http.MethodPost, "/p/"+tt.token, strings.NewReader(form.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			rr := httptest.NewRecorder()
+			newHandler(repo).View(rr, withParams(req, map[string]string{"token": tt.token}, false))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "no-referrer", rr.Header().Get("Referrer-Policy"))
+			assert.Contains(t, rr.Body.String(), tt.expectedBody)
+		})
+	}
+
+	assert.Equal(t, map[int]int{1: 1, 2: 1}, repo.views)
+}
+
+func Test_ViewEscapesTitle(t *testing.T) {
+	repo := newRepo()
+	tok := addLink(t, repo, 1, "", nil)
+
+	rr := httptest.NewRecorder()
+	newHandler(repo).View(rr, withParams(httptest.NewRequest(http.MethodGet, "/p/"+tok, nil), map[string]string{"token": tok}, false))
+
+	assert.Contains(t, rr.Body.String(), "<h1>&lt;Plan&gt;</h1>")
+}
+
+func Test_Delete(t *testing.T) {
+	repo := newRepo()
+	addLink(t, repo, 1, "", nil)
+	h := newHandler(repo)
+
+	req := withParams(httptest.NewRequest(http.MethodDelete, "/notes/1/public-links/1", nil), map[string]string{"id": "1", "linkID": "1"}, true)
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Delete(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -0,0 +1,167 @@
+package publiclinkssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+	"gonotes/internal/publiclinks/entity"
+	"gonotes/internal/publiclinks/storage"
+	"time"
+)
+
+const linkColumns = "l.id, l.note_id, l.user_id, l.nonce, l.password_hash, l.expires_at, l.views, l.created_at"
+
+type PublicLinkRepository struct {
+	db *sql.DB
+}
+
+func NewPublicLinkRepository(db *sql.DB) *PublicLinkRepository {
+	return &PublicLinkRepository{db: db}
+}
+
+func (r *PublicLinkRepository) Create(link *entity.PublicLink) (*entity.PublicLink, error) {
+	const op = "publiclinks.sqlite.Create"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := notessqlite.CheckNote(tx, link.NoteID, link.UserID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created := *link
+	created.CreatedAt = time.Now().UTC()
+	created.Views = 0
+
+	res, err := tx.Exec("INSERT INTO public_links (note_id, user_id, nonce, password_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		created.NoteID, created.UserID, created.Nonce, created.PasswordHash, created.ExpiresAt, created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	created.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &created, nil
+}
+
+// List returns the links of a note, newest first.
+func (r *PublicLinkRepository) List(noteID int, userID int) ([]entity.PublicLink, error) {
+	const op = "publiclinks.sqlite.List"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query("SELECT "+linkColumns+" FROM public_links l WHERE l.note_id = ? ORDER BY l.id DESC", noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	links := []entity.PublicLink{}
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		links = append(links, *l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return links, nil
+}
+
+func (r *PublicLinkRepository) Delete(id int, noteID int, userID int) error {
+	const op = "publiclinks.sqlite.Delete"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := r.db.Exec("DELETE FROM public_links WHERE id = ? AND note_id = ?", id, noteID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *PublicLinkRepository) Resolve(id int) (*entity.PublicNote, error) {
+	const op = "publiclinks.sqlite.Resolve"
+
+	var n entity.PublicNote
+	row := r.db.QueryRow("SELECT "+linkColumns+", n.title, n.content, n.format, n.updated_at FROM public_links l JOIN notes n ON n.id = l.note_id AND n.deleted_at IS NULL WHERE l.id = ?", id)
+	l, err := scanLink(row, &n.Title, &n.Content, &n.Format, &n.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	n.Link = *l
+
+	return &n, nil
+}
+
+func (r *PublicLinkRepository) CountView(id int) error {
+	const op = "publiclinks.sqlite.CountView"
+
+	if _, err := r.db.Exec("UPDATE public_links SET views = views + 1 WHERE id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanLink scans a row starting with linkColumns; extra receives any trailing
+// columns.
+func scanLink(s scanner, extra ...any) (*entity.PublicLink, error) {
+	var (
+		l         entity.PublicLink
+		expiresAt sql.NullTime
+	)
+	dest := append([]any{&l.ID, &l.NoteID, &l.UserID, &l.Nonce, &l.PasswordHash, &expiresAt, &l.Views, &l.CreatedAt}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		l.ExpiresAt = &expiresAt.Time
+	}
+	return &l, nil
+}
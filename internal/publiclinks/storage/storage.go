@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"errors"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/publiclinks/entity"
+)
+
+var (
+	ErrNoteNotFound = notesstorage.ErrNoteNotFound
+	ErrLinkNotFound = errors.New("public link not found")
+)
+
+type PublicLinkRepository interface {
+	Create(link *entity.PublicLink) (*entity.PublicLink, error)
+	List(noteID int, userID int) ([]entity.PublicLink, error)
+	Delete(id int, noteID int, userID int) error
+	// Resolve looks a link up by ID for an anonymous visitor. Links to notes
+	// in the trash are reported as not found.
+	Resolve(id int) (*entity.PublicNote, error)
+	CountView(id int) error
+}
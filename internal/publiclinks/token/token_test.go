@@ -0,0 +1,47 @@
+package token
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignParse(t *testing.T) {
+	s := NewSigner("secret")
+	nonce, err := NewNonce()
+	require.NoError(t, err)
+
+	for _, id := range []int64{1, 127, 128, 1 << 40} {
+		tok := s.Sign(id, nonce)
+
+		gotID, gotNonce, err := s.Parse(tok)
+		require.NoError(t, err)
+		assert.Equal(t, id, gotID)
+		assert.True(t, bytes.Equal(nonce, gotNonce))
+	}
+}
+
+func Test_ParseRejects(t *testing.T) {
+	s := NewSigner("secret")
+	nonce := bytes.Repeat([]byte{7}, NonceSize)
+	tok := s.Sign(42, nonce)
+
+	tampered := []byte(tok)
+	tampered[0] ^= 1
+
+	cases := map[string]string{
+		"empty":        "",
+		"not base64":   "!!!",
+		"too short":    tok[:10],
+		"tampered":     string(tampered),
+		"other secret": NewSigner("other").Sign(42, nonce),
+	}
+	for name, tok := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := s.Parse(tok)
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/reminders/entity"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type ReminderRequest struct {
+	RemindAt time.Time `json:"remind_at"`
+}
+
+func (rr *ReminderRequest) Bind(r *http.Request) error {
+	if rr.RemindAt.IsZero() {
+		return fmt.Errorf("remind_at is required")
+	}
+	if !rr.RemindAt.After(time.Now()) {
+		return fmt.Errorf("remind_at must be in the future")
+	}
+	rr.RemindAt = rr.RemindAt.UTC()
+	return nil
+}
+
+// SnoozeRequest moves a reminder either to remind_at or by a duration such as
+// "15m" or "2h" from now.
+type SnoozeRequest struct {
+	RemindAt *time.Time `json:"remind_at"`
+	Duration string     `json:"duration"`
+
+	Until time.Time `json:"-"`
+}
+
+func (s *SnoozeRequest) Bind(r *http.Request) error {
+	switch {
+	case s.RemindAt != nil && s.Duration != "":
+		return fmt.Errorf("use either remind_at or duration, not both")
+	case s.RemindAt != nil:
+		s.Until = s.RemindAt.UTC()
+	case s.Duration != "":
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("duration must be a positive duration such as 15m or 2h")
+		}
+		s.Until = time.Now().Add(d).UTC()
+	default:
+		return fmt.Errorf("remind_at or duration is required")
+	}
+	if !s.Until.After(time.Now()) {
+		return fmt.Errorf("remind_at must be in the future")
+	}
+	return nil
+}
+
+type ReminderResponse struct {
+	ID        int           `json:"id"`
+	NoteID    int           `json:"note_id"`
+	RemindAt  time.Time     `json:"remind_at"`
+	Status    entity.Status `json:"status"`
+	Attempts  int           `json:"attempts"`
+	LastError string        `json:"last_error,omitempty"`
+	SentAt    *time.Time    `json:"sent_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+func (rr *ReminderResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewReminderResponse(rem *entity.Reminder) *ReminderResponse {
+	return &ReminderResponse{
+		ID:        rem.ID,
+		NoteID:    rem.NoteID,
+		RemindAt:  rem.RemindAt,
+		Status:    rem.Status,
+		Attempts:  rem.Attempts,
+		LastError: rem.LastError,
+		SentAt:    rem.SentAt,
+		CreatedAt: rem.CreatedAt,
+	}
+}
+
+func NewReminderListResponse(reminders []entity.Reminder) []render.Renderer {
+	list := make([]render.Renderer, len(reminders))
+	for i := range reminders {
+		list[i] = NewReminderResponse(&reminders[i])
+	}
+	return list
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSent      Status = "sent"
+	StatusDismissed Status = "dismissed"
+	// StatusFailed marks a reminder the scheduler gave up delivering.
+	StatusFailed Status = "failed"
+)
+
+// Reminder asks for a notification about a note at RemindAt. It stays
+// pending until the notifier accepts it, and is retried until then.
+type Reminder struct {
+	ID        int        `json:"id"`
+	NoteID    int        `json:"note_id"`
+	UserID    int        `json:"user_id"`
+	RemindAt  time.Time  `json:"remind_at"`
+	Status    Status     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Due is a reminder claimed by the scheduler, with what a notifier needs to
+// tell the user. LeaseUntil is when the claim lapses and the reminder becomes
+// due again if it has not been marked sent.
+type Due struct {
+	Reminder
+	Email      string
+	NoteTitle  string
+	LeaseUntil time.Time
+}
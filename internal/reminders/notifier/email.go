@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"gonotes/internal/reminders/entity"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends each reminder as a plain-text message to the user's address
+// through an SMTP server. net/smtp upgrades to STARTTLS when the server
+// offers it.
+type Email struct {
+	addr string
+	auth smtp.Auth
+	from mail.Address
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail returns an SMTP notifier. Authentication is skipped when username
+// is empty.
+func NewEmail(host string, port int, username, password, from string) (*Email, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+
+	e := &Email{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: *addr,
+		send: smtp.SendMail,
+	}
+	if username != "" {
+		e.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return e, nil
+}
+
+func (e *Email) Notify(ctx context.Context, d *entity.Due) error {
+	const op = "notifier.Email.Notify"
+
+	to, err := mail.ParseAddress(d.Email)
+	if err != nil {
+		return fmt.Errorf("%s: invalid recipient: %w", op, err)
+	}
+
+	msg := e.message(to, d)
+	if err := e.send(e.addr, e.auth, e.from.Address, []string{to.Address}, msg); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (e *Email) message(to *mail.Address, d *entity.Due) []byte {
+	title := oneLine(d.NoteTitle)
+	if title == "" {
+		title = fmt.Sprintf("note %d", d.NoteID)
+	}
+
+	var b bytes.Buffer
+	header := func(k, v string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+	}
+	header("From", e.from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", "Reminder: "+title))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@gonotes>", idempotencyKey(d)))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `text/plain; charset="utf-8"`)
+	header("Content-Transfer-Encoding", "8bit")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "You asked to be reminded about %q at %s.\r\n", title, d.RemindAt.UTC().Format("2006-01-02 15:04 MST"))
+	return b.Bytes()
+}
+
+// oneLine keeps user text from starting new header lines.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
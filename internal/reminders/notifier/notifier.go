@@ -0,0 +1,36 @@
+// Package notifier delivers due reminders to their users. Delivery is at
+// least once: a notifier may be asked to send the same reminder again after a
+// crash or a lost acknowledgement, so receivers should de-duplicate on the
+// reminder ID and time.
+package notifier
+
+import (
+	"context"
+	"gonotes/internal/reminders/entity"
+	"log/slog"
+)
+
+type Notifier interface {
+	Notify(ctx context.Context, d *entity.Due) error
+}
+
+// Log writes reminders to the application log. It never fails, which makes
+// it a safe default when no other channel is configured.
+type Log struct {
+	log *slog.Logger
+}
+
+func NewLog(log *slog.Logger) *Log {
+	return &Log{log: log}
+}
+
+func (l *Log) Notify(ctx context.Context, d *entity.Due) error {
+	l.log.Info("reminder due",
+		slog.Int("reminder_id", d.ID),
+		slog.Int("note_id", d.NoteID),
+		slog.Int("user_id", d.UserID),
+		slog.String("note_title", d.NoteTitle),
+		slog.Time("remind_at", d.RemindAt),
+	)
+	return nil
+}
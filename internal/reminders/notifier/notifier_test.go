@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/reminders/entity"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDue() *entity.Due {
+	return &entity.Due{
+		Reminder: entity.Reminder{
+			ID:       4,
+			NoteID:   9,
+			UserID:   1,
+			RemindAt: time.Date(2026, 5, 1, 9, 30, 0, 0, time.UTC),
+		},
+		Email:     "a@example.com",
+		NoteTitle: "Dentist\r\nBcc: evil@example.com",
+	}
+}
+
+func Test_Webhook(t *testing.T) {
+	var (
+		got     webhookPayload
+		key     string
+		ctype   string
+		respond = http.StatusNoContent
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		ctype = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(respond)
+	}))
+	defer srv.Close()
+
+	wh := NewWebhook(srv.URL, time.Second)
+	d := testDue()
+
+	require.NoError(t, wh.Notify(context.Background(), d))
+	assert.Equal(t, "application/json", ctype)
+	assert.Equal(t, "reminder-4-1777627800", key)
+	assert.Equal(t, "reminder.due", got.Event)
+	assert.Equal(t, 9, got.NoteID)
+	assert.Equal(t, d.NoteTitle, got.NoteTitle)
+	assert.True(t, d.RemindAt.Equal(got.RemindAt))
+
+	respond = http.StatusServiceUnavailable
+	err := wh.Notify(context.Background(), d)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "503")
+}
+
+func Test_Email(t *testing.T) {
+	e, err := NewEmail("smtp.example.com", 587, "user", "secret", "GoNotes <noreply@example.com>")
+	require.NoError(t, err)
+
+	var (
+		addr string
+		from string
+		to   []string
+		msg  string
+	)
+	e.send = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, string(m)
+		return nil
+	}
+
+	require.NoError(t, e.Notify(context.Background(), testDue()))
+	assert.Equal(t, "smtp.example.com:587", addr)
+	assert.Equal(t, "noreply@example.com", from)
+	assert.Equal(t, []string{"a@example.com"}, to)
+
+	header, body, ok := strings.Cut(msg, "\r\n\r\n")
+	require.True(t, ok)
+	assert.Contains(t, header, "Subject: Reminder: Dentist Bcc: evil@example.com\r\n")
+	assert.NotContains(t, header, "\r\nBcc:")
+	assert.Contains(t, header, "Message-ID: <reminder-4-1777627800@gonotes>")
+	assert.Contains(t, body, "2026-05-01 09:30 UTC")
+
+	e.send = func(string, smtp.Auth, string, []string, []byte) error { return errors.New("421 try later") }
+	assert.Error(t, e.Notify(context.Background(), testDue()))
+}
+
+func Test_EmailRejectsBadAddresses(t *testing.T) {
+	_, err := NewEmail("smtp.example.com", 25, "", "", "not an address")
+	assert.Error(t, err)
+
+	e, err := NewEmail("smtp.example.com", 25, "", "", "noreply@example.com")
+	require.NoError(t, err)
+	e.send = func(string, smtp.Auth, string, []string, []byte) error {
+		t.Fatal("must not send")
+		return nil
+	}
+	d := testDue()
+	d.Email = "broken"
+	assert.Error(t, e.Notify(context.Background(), d))
+}
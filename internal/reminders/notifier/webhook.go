@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gonotes/internal/reminders/entity"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Webhook posts each reminder as JSON to a fixed URL. Any 2xx response counts
+// as delivered. The Idempotency-Key header is the same for every attempt at
+// one reminder firing.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Event      string    `json:"event"`
+	ReminderID int       `json:"reminder_id"`
+	NoteID     int       `json:"note_id"`
+	NoteTitle  string    `json:"note_title"`
+	UserID     int       `json:"user_id"`
+	Email      string    `json:"email"`
+	RemindAt   time.Time `json:"remind_at"`
+}
+
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (wh *Webhook) Notify(ctx context.Context, d *entity.Due) error {
+	const op = "notifier.Webhook.Notify"
+
+	body, err := json.Marshal(webhookPayload{
+		Event:      "reminder.due",
+		ReminderID: d.ID,
+		NoteID:     d.NoteID,
+		NoteTitle:  d.NoteTitle,
+		UserID:     d.UserID,
+		Email:      d.Email,
+		RemindAt:   d.RemindAt,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey(d))
+
+	resp, err := wh.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: unexpected status %s", op, resp.Status)
+	}
+	return nil
+}
+
+// idempotencyKey identifies one firing of a reminder; snoozing it gives the
+// next firing a new key.
+func idempotencyKey(d *entity.Due) string {
+	return fmt.Sprintf("reminder-%d-%d", d.ID, d.RemindAt.Unix())
+}
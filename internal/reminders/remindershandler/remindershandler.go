@@ -0,0 +1,221 @@
+package remindershandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/middleware"
+	"gonotes/internal/reminders/dto"
+	"gonotes/internal/reminders/entity"
+	"gonotes/internal/reminders/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.ReminderRepository
+}
+
+func NewHandler(log *slog.Logger, storage storage.ReminderRepository) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// Create sets a reminder on a note the user owns or has been shared.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "reminders.handler.create"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.ReminderRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	reminder, err := h.storage.Create(&entity.Reminder{NoteID: noteID, UserID: userID, RemindAt: req.RemindAt})
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to create reminder", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewReminderResponse(reminder))
+
+	log.Info("reminder created", slog.Int("id", reminder.ID), slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+// GetAll lists the user's reminders on a note, including sent and dismissed
+// ones.
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "reminders.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	list, err := h.storage.List(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list reminders", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewReminderListResponse(list))
+
+	log.Info("reminders retrieved", slog.Int("note_id", noteID), slog.Int("count", len(list)))
+}
+
+// Upcoming lists the user's pending reminders across all notes.
+func (h *Handler) Upcoming(w http.ResponseWriter, r *http.Request) {
+	const op = "reminders.handler.upcoming"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.Upcoming(userID)
+	if err != nil {
+		log.Error("failed to list reminders", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewReminderListResponse(list))
+
+	log.Info("upcoming reminders retrieved", slog.Int("count", len(list)))
+}
+
+// Snooze moves a reminder to a later time. Reminders that were already sent
+// fire again at the new time.
+func (h *Handler) Snooze(w http.ResponseWriter, r *http.Request) {
+	const op = "reminders.handler.snooze"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.SnoozeRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	reminder, err := h.storage.Snooze(id, userID, req.Until)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrReminderNotFound):
+			log.Error("reminder not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, storage.ErrDismissed):
+			log.Error("reminder dismissed", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusConflict, err))
+		default:
+			log.Error("failed to snooze reminder", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewReminderResponse(reminder))
+
+	log.Info("reminder snoozed", slog.Int("id", id), slog.Time("remind_at", reminder.RemindAt))
+}
+
+func (h *Handler) Dismiss(w http.ResponseWriter, r *http.Request) {
+	const op = "reminders.handler.dismiss"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	reminder, err := h.storage.Dismiss(id, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrReminderNotFound) {
+			log.Error("reminder not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to dismiss reminder", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewReminderResponse(reminder))
+
+	log.Info("reminder dismissed", slog.Int("id", id))
+}
@@ -0,0 +1,261 @@
+package remindershandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/reminders/dto"
+	"gonotes/internal/reminders/entity"
+	"gonotes/internal/reminders/remindershandler"
+	"gonotes/internal/reminders/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRemindersRepo leaves the scheduler methods unimplemented; they panic if
+// called.
+type mockRemindersRepo struct {
+	storage.Scheduler
+	createFunc   func(r *entity.Reminder) (*entity.Reminder, error)
+	listFunc     func(noteID, userID int) ([]entity.Reminder, error)
+	upcomingFunc func(userID int) ([]entity.Reminder, error)
+	snoozeFunc   func(id, userID int, until time.Time) (*entity.Reminder, error)
+	dismissFunc  func(id, userID int) (*entity.Reminder, error)
+}
+
+func (m *mockRemindersRepo) Create(r *entity.Reminder) (*entity.Reminder, error) {
+	return m.createFunc(r)
+}
+func (m *mockRemindersRepo) List(noteID, userID int) ([]entity.Reminder, error) {
+	return m.listFunc(noteID, userID)
+}
+func (m *mockRemindersRepo) Upcoming(userID int) ([]entity.Reminder, error) {
+	return m.upcomingFunc(userID)
+}
+func (m *mockRemindersRepo) Snooze(id, userID int, until time.Time) (*entity.Reminder, error) {
+	return m.snoozeFunc(id, userID, until)
+}
+func (m *mockRemindersRepo) Dismiss(id, userID int) (*entity.Reminder, error) {
+	return m.dismissFunc(id, userID)
+}
+
+func newRequest(method, target, body, id string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_Create(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	past := time.Now().Add(-time.Hour).UTC()
+
+	tests := []struct {
+		name           string
+		withUser       bool
+		noteID         string
+		body           string
+		mockCreate     func(r *entity.Reminder) (*entity.Reminder, error)
+		expectedStatus int
+	}{
+		{
+			name:           "unauthorized",
+			noteID:         "1",
+			body:           `{"remind_at":"` + future.Format(time.RFC3339) + `"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "created",
+			withUser: true,
+			noteID:   "1",
+			body:     `{"remind_at":"` + future.Format(time.RFC3339) + `"}`,
+			mockCreate: func(r *entity.Reminder) (*entity.Reminder, error) {
+				if r.NoteID != 1 || r.UserID != 1 || !r.RemindAt.Equal(future) {
+					return nil, errors.New("unexpected reminder")
+				}
+				created := *r
+				created.ID = 3
+				created.Status = entity.StatusPending
+				return &created, nil
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing time",
+			withUser:       true,
+			noteID:         "1",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "in the past",
+			withUser:       true,
+			noteID:         "1",
+			body:           `{"remind_at":"` + past.Format(time.RFC3339) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid id",
+			withUser:       true,
+			noteID:         "x",
+			body:           `{"remind_at":"` + future.Format(time.RFC3339) + `"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "note not found",
+			withUser: true,
+			noteID:   "2",
+			body:     `{"remind_at":"` + future.Format(time.RFC3339) + `"}`,
+			mockCreate: func(r *entity.Reminder) (*entity.Reminder, error) {
+				return nil, storage.ErrNoteNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := remindershandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockRemindersRepo{createFunc: tc.mockCreate})
+
+			rr := httptest.NewRecorder()
+			h.Create(rr, newRequest(http.MethodPost, "/notes/"+tc.noteID+"/reminders", tc.body, tc.noteID, tc.withUser))
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.expectedStatus == http.StatusCreated {
+				var resp dto.ReminderResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+				assert.Equal(t, 3, resp.ID)
+				assert.Equal(t, entity.StatusPending, resp.Status)
+			}
+		})
+	}
+}
+
+func Test_Snooze(t *testing.T) {
+	future := time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockErr        error
+		expectedStatus int
+		check          func(t *testing.T, until time.Time)
+	}{
+		{
+			name:           "to a time",
+			body:           `{"remind_at":"` + future.Format(time.RFC3339) + `"}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, until time.Time) {
+				assert.True(t, until.Equal(future))
+			},
+		},
+		{
+			name:           "by a duration",
+			body:           `{"duration":"15m"}`,
+			expectedStatus: http.StatusOK,
+			check: func(t *testing.T, until time.Time) {
+				assert.WithinDuration(t, time.Now().Add(15*time.Minute), until, time.Minute)
+			},
+		},
+		{name: "both", body: `{"duration":"15m","remind_at":"` + future.Format(time.RFC3339) + `"}`, expectedStatus: http.StatusBadRequest},
+		{name: "neither", body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "negative duration", body: `{"duration":"-5m"}`, expectedStatus: http.StatusBadRequest},
+		{name: "not found", body: `{"duration":"1h"}`, mockErr: storage.ErrReminderNotFound, expectedStatus: http.StatusNotFound},
+		{name: "dismissed", body: `{"duration":"1h"}`, mockErr: storage.ErrDismissed, expectedStatus: http.StatusConflict},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got time.Time
+			repo := &mockRemindersRepo{snoozeFunc: func(id, userID int, until time.Time) (*entity.Reminder, error) {
+				if tc.mockErr != nil {
+					return nil, tc.mockErr
+				}
+				got = until
+				return &entity.Reminder{ID: id, RemindAt: until, Status: entity.StatusPending}, nil
+			}}
+			h := remindershandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+			rr := httptest.NewRecorder()
+			h.Snooze(rr, newRequest(http.MethodPost, "/reminders/5/snooze", tc.body, "5", true))
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.check != nil {
+				tc.check(t, got)
+			}
+		})
+	}
+}
+
+func Test_Dismiss(t *testing.T) {
+	repo := &mockRemindersRepo{dismissFunc: func(id, userID int) (*entity.Reminder, error) {
+		if id != 5 {
+			return nil, storage.ErrReminderNotFound
+		}
+		return &entity.Reminder{ID: id, Status: entity.StatusDismissed}, nil
+	}}
+	h := remindershandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+	rr := httptest.NewRecorder()
+	h.Dismiss(rr, newRequest(http.MethodPost, "/reminders/5/dismiss", "", "5", true))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp dto.ReminderResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, entity.StatusDismissed, resp.Status)
+
+	rr = httptest.NewRecorder()
+	h.Dismiss(rr, newRequest(http.MethodPost, "/reminders/6/dismiss", "", "6", true))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Dismiss(rr, newRequest(http.MethodPost, "/reminders/5/dismiss", "", "5", false))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func Test_ListEndpoints(t *testing.T) {
+	repo := &mockRemindersRepo{
+		listFunc: func(noteID, userID int) ([]entity.Reminder, error) {
+			if noteID != 1 {
+				return nil, storage.ErrNoteNotFound
+			}
+			return []entity.Reminder{{ID: 1}, {ID: 2, Status: entity.StatusSent}}, nil
+		},
+		upcomingFunc: func(userID int) ([]entity.Reminder, error) {
+			return []entity.Reminder{{ID: 1}}, nil
+		},
+	}
+	h := remindershandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+	rr := httptest.NewRecorder()
+	h.GetAll(rr, newRequest(http.MethodGet, "/notes/1/reminders", "", "1", true))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var list []dto.ReminderResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&list))
+	assert.Len(t, list, 2)
+
+	rr = httptest.NewRecorder()
+	h.GetAll(rr, newRequest(http.MethodGet, "/notes/2/reminders", "", "2", true))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Upcoming(rr, newRequest(http.MethodGet, "/reminders", "", "", true))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&list))
+	assert.Len(t, list, 1)
+}
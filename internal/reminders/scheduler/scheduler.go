@@ -0,0 +1,132 @@
+// Package scheduler fires due reminders. Reminders are claimed from storage
+// with a lease before they are sent, so a reminder whose delivery was cut
+// short by a crash or restart is claimed and sent again once the lease runs
+// out.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/reminders/entity"
+	"gonotes/internal/reminders/notifier"
+	"gonotes/internal/reminders/storage"
+	"log/slog"
+	"time"
+)
+
+const (
+	// MaxAttempts is how many times delivery is tried before a reminder is
+	// marked failed.
+	MaxAttempts = 10
+
+	batchSize  = 100
+	minBackoff = time.Minute
+	maxBackoff = time.Hour
+)
+
+type Scheduler struct {
+	log      *slog.Logger
+	storage  storage.Scheduler
+	notifier notifier.Notifier
+	interval time.Duration
+	lease    time.Duration
+	now      func() time.Time
+}
+
+// New returns a scheduler that polls every interval. The lease must outlast
+// a whole batch of notifications, or reminders still being sent are claimed
+// again.
+func New(log *slog.Logger, storage storage.Scheduler, notifier notifier.Notifier, interval, lease time.Duration) *Scheduler {
+	return &Scheduler{
+		log:      log,
+		storage:  storage,
+		notifier: notifier,
+		interval: interval,
+		lease:    lease,
+		now:      time.Now,
+	}
+}
+
+// Run fires due reminders straight away and then every interval until ctx
+// is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.fire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fire sends every reminder that is due, a batch at a time.
+func (s *Scheduler) fire(ctx context.Context) {
+	const op = "reminders.scheduler.fire"
+	log := s.log.With(slog.String("op", op))
+
+	for ctx.Err() == nil {
+		due, err := s.storage.ClaimDue(s.now(), s.lease, batchSize)
+		if err != nil {
+			log.Error("failed to claim due reminders", slog.Any("err", err))
+			return
+		}
+
+		for i := range due {
+			if ctx.Err() != nil {
+				// Unsent reminders are picked up again when their lease ends.
+				return
+			}
+			s.deliver(ctx, log, &due[i])
+		}
+
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+func (s *Scheduler) deliver(ctx context.Context, log *slog.Logger, d *entity.Due) {
+	log = log.With(slog.Int("reminder_id", d.ID), slog.Int("attempt", d.Attempts))
+
+	if err := s.notifier.Notify(ctx, d); err != nil {
+		var retryAt *time.Time
+		if d.Attempts < MaxAttempts {
+			t := s.now().Add(backoff(d.Attempts))
+			retryAt = &t
+		}
+		log.Error("failed to send reminder", slog.Any("err", err), slog.Bool("giving_up", retryAt == nil))
+
+		if err := s.storage.MarkFailed(d, retryAt, err.Error()); err != nil && !errors.Is(err, storage.ErrLeaseLost) {
+			log.Error("failed to record reminder failure", slog.Any("err", err))
+		}
+		return
+	}
+
+	// If this fails the reminder is sent again after the lease; that is the
+	// price of never losing one.
+	if err := s.storage.MarkSent(d, s.now()); err != nil {
+		if errors.Is(err, storage.ErrLeaseLost) {
+			log.Info("reminder changed while it was being sent")
+			return
+		}
+		log.Error("failed to mark reminder sent", slog.Any("err", err))
+		return
+	}
+
+	log.Info("reminder sent", slog.Int("note_id", d.NoteID))
+}
+
+// backoff doubles the wait after every failed attempt, from a minute up to
+// an hour.
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/reminders/entity"
+	"gonotes/internal/reminders/storage"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failure struct {
+	retryAt *time.Time
+	reason  string
+}
+
+// mockStorage hands out its due reminders in batches and records outcomes.
+type mockStorage struct {
+	due     []entity.Due
+	claims  int
+	leases  []time.Duration
+	sent    map[int]time.Time
+	failed  map[int]failure
+	sentErr error
+}
+
+func newMockStorage(due ...entity.Due) *mockStorage {
+	return &mockStorage{due: due, sent: map[int]time.Time{}, failed: map[int]failure{}}
+}
+
+func (m *mockStorage) ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error) {
+	m.claims++
+	m.leases = append(m.leases, lease)
+	n := min(limit, len(m.due))
+	batch := m.due[:n]
+	m.due = m.due[n:]
+	return batch, nil
+}
+
+func (m *mockStorage) MarkSent(d *entity.Due, at time.Time) error {
+	if m.sentErr != nil {
+		return m.sentErr
+	}
+	m.sent[d.ID] = at
+	return nil
+}
+
+func (m *mockStorage) MarkFailed(d *entity.Due, retryAt *time.Time, reason string) error {
+	m.failed[d.ID] = failure{retryAt: retryAt, reason: reason}
+	return nil
+}
+
+type mockNotifier struct {
+	err      map[int]error
+	notified []int
+	cancel   func()
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, d *entity.Due) error {
+	m.notified = append(m.notified, d.ID)
+	if m.cancel != nil {
+		m.cancel()
+	}
+	return m.err[d.ID]
+}
+
+func due(id, attempts int) entity.Due {
+	return entity.Due{Reminder: entity.Reminder{ID: id, Attempts: attempts}}
+}
+
+func newScheduler(s *mockStorage, n *mockNotifier, now time.Time) *Scheduler {
+	sch := New(slogdiscard.NewDiscardLogger(), s, n, time.Minute, 5*time.Minute)
+	sch.now = func() time.Time { return now }
+	return sch
+}
+
+func Test_fireDelivers(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	store := newMockStorage(due(1, 1), due(2, 3), due(3, MaxAttempts))
+	n := &mockNotifier{err: map[int]error{
+		2: errors.New("connection refused"),
+		3: errors.New("connection refused"),
+	}}
+
+	newScheduler(store, n, now).fire(context.Background())
+
+	assert.Equal(t, []int{1, 2, 3}, n.notified)
+	assert.Equal(t, map[int]time.Time{1: now}, store.sent)
+	assert.Equal(t, []time.Duration{5 * time.Minute}, store.leases)
+
+	require.Contains(t, store.failed, 2)
+	require.NotNil(t, store.failed[2].retryAt)
+	assert.Equal(t, now.Add(4*time.Minute), *store.failed[2].retryAt)
+	assert.Equal(t, "connection refused", store.failed[2].reason)
+
+	require.Contains(t, store.failed, 3)
+	assert.Nil(t, store.failed[3].retryAt, "gives up after MaxAttempts")
+}
+
+func Test_fireDrainsFullBatches(t *testing.T) {
+	var all []entity.Due
+	for i := range batchSize + 1 {
+		all = append(all, due(i+1, 1))
+	}
+	store := newMockStorage(all...)
+
+	newScheduler(store, &mockNotifier{}, time.Now()).fire(context.Background())
+
+	assert.Len(t, store.sent, batchSize+1)
+	assert.Equal(t, 2, store.claims)
+}
+
+func Test_fireStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store := newMockStorage(due(1, 1), due(2, 1))
+	n := &mockNotifier{cancel: cancel}
+
+	newScheduler(store, n, time.Now()).fire(ctx)
+
+	assert.Equal(t, []int{1}, n.notified, "the rest waits for its lease to run out")
+}
+
+func Test_fireToleratesLostLease(t *testing.T) {
+	store := newMockStorage(due(1, 1))
+	store.sentErr = storage.ErrLeaseLost
+
+	newScheduler(store, &mockNotifier{}, time.Now()).fire(context.Background())
+
+	assert.Empty(t, store.sent)
+	assert.Empty(t, store.failed)
+}
+
+func Test_backoff(t *testing.T) {
+	assert.Equal(t, time.Minute, backoff(1))
+	assert.Equal(t, 2*time.Minute, backoff(2))
+	assert.Equal(t, 32*time.Minute, backoff(6))
+	assert.Equal(t, time.Hour, backoff(7))
+	assert.Equal(t, time.Hour, backoff(MaxAttempts))
+}
@@ -0,0 +1,311 @@
+package reminderssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+	"gonotes/internal/reminders/entity"
+	"gonotes/internal/reminders/storage"
+	"time"
+)
+
+const reminderColumns = "id, note_id, user_id, remind_at, status, attempts, last_error, sent_at, created_at"
+
+type ReminderRepository struct {
+	db *sql.DB
+}
+
+func NewReminderRepository(db *sql.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// Create adds a pending reminder on a note the user can read.
+func (r *ReminderRepository) Create(rem *entity.Reminder) (*entity.Reminder, error) {
+	const op = "reminders.sqlite.Create"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := notessqlite.CheckNote(tx, rem.NoteID, rem.UserID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	created := entity.Reminder{
+		NoteID:    rem.NoteID,
+		UserID:    rem.UserID,
+		RemindAt:  rem.RemindAt.UTC(),
+		Status:    entity.StatusPending,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	res, err := tx.Exec("INSERT INTO reminders (note_id, user_id, remind_at, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		created.NoteID, created.UserID, created.RemindAt, created.RemindAt, created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	created.ID = int(id)
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &created, nil
+}
+
+// List returns the user's reminders on a note, soonest first.
+func (r *ReminderRepository) List(noteID int, userID int) ([]entity.Reminder, error) {
+	const op = "reminders.sqlite.List"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	reminders, err := list(r.db, "SELECT "+reminderColumns+" FROM reminders WHERE note_id = ? AND user_id = ? ORDER BY remind_at, id", noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return reminders, nil
+}
+
+func (r *ReminderRepository) Upcoming(userID int) ([]entity.Reminder, error) {
+	const op = "reminders.sqlite.Upcoming"
+
+	reminders, err := list(r.db, `SELECT `+reminderColumns+` FROM reminders
+		WHERE user_id = ? AND status = ? AND note_id IN (SELECT id FROM notes WHERE deleted_at IS NULL)
+		ORDER BY remind_at, id`, userID, entity.StatusPending)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return reminders, nil
+}
+
+// Snooze moves a reminder to a new time and makes it pending again, even if
+// it has already been sent or given up on.
+func (r *ReminderRepository) Snooze(id int, userID int, until time.Time) (*entity.Reminder, error) {
+	const op = "reminders.sqlite.Snooze"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rem, err := get(tx, id, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrReminderNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if rem.Status == entity.StatusDismissed {
+		return nil, storage.ErrDismissed
+	}
+
+	until = until.UTC()
+	_, err = tx.Exec("UPDATE reminders SET remind_at = ?, next_attempt_at = ?, status = ?, attempts = 0, last_error = '', sent_at = NULL WHERE id = ?",
+		until, until, entity.StatusPending, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rem.RemindAt = until
+	rem.Status = entity.StatusPending
+	rem.Attempts = 0
+	rem.LastError = ""
+	rem.SentAt = nil
+	return rem, nil
+}
+
+// Dismiss stops a reminder from firing. Dismissing twice is not an error.
+func (r *ReminderRepository) Dismiss(id int, userID int) (*entity.Reminder, error) {
+	const op = "reminders.sqlite.Dismiss"
+
+	res, err := r.db.Exec("UPDATE reminders SET status = ? WHERE id = ? AND user_id = ?", entity.StatusDismissed, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return nil, storage.ErrReminderNotFound
+	}
+
+	rem, err := get(r.db, id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return rem, nil
+}
+
+// ClaimDue leases up to limit pending reminders whose next attempt is due.
+// Reminders on trashed notes, or on notes the user can no longer read, wait
+// until the note is back.
+func (r *ReminderRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error) {
+	const op = "reminders.sqlite.ClaimDue"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT r.id, r.note_id, r.user_id, r.remind_at, r.status, r.attempts, r.last_error, r.sent_at, r.created_at, u.email, n.title
+		FROM reminders r
+		JOIN notes n ON n.id = r.note_id
+		JOIN users u ON u.id = r.user_id
+		WHERE r.status = ? AND r.next_attempt_at <= ? AND n.deleted_at IS NULL
+			AND (n.user_id = r.user_id OR n.id IN (SELECT note_id FROM note_shares WHERE user_id = r.user_id))
+		ORDER BY r.next_attempt_at, r.id
+		LIMIT ?`, entity.StatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	due := []entity.Due{}
+	for rows.Next() {
+		var d entity.Due
+		rem, err := scanReminder(rows, &d.Email, &d.NoteTitle)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		d.Reminder = *rem
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	leaseUntil := now.Add(lease).UTC()
+	for i := range due {
+		_, err := tx.Exec("UPDATE reminders SET next_attempt_at = ?, attempts = attempts + 1 WHERE id = ?", leaseUntil, due[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		due[i].Attempts++
+		due[i].LeaseUntil = leaseUntil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return due, nil
+}
+
+func (r *ReminderRepository) MarkSent(d *entity.Due, at time.Time) error {
+	const op = "reminders.sqlite.MarkSent"
+
+	res, err := r.db.Exec("UPDATE reminders SET status = ?, sent_at = ?, last_error = '' WHERE id = ? AND status = ? AND next_attempt_at = ?",
+		entity.StatusSent, at.UTC(), d.ID, entity.StatusPending, d.LeaseUntil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkLease(op, res)
+}
+
+func (r *ReminderRepository) MarkFailed(d *entity.Due, retryAt *time.Time, reason string) error {
+	const op = "reminders.sqlite.MarkFailed"
+
+	var (
+		res sql.Result
+		err error
+	)
+	if retryAt != nil {
+		res, err = r.db.Exec("UPDATE reminders SET next_attempt_at = ?, last_error = ? WHERE id = ? AND status = ? AND next_attempt_at = ?",
+			retryAt.UTC(), reason, d.ID, entity.StatusPending, d.LeaseUntil)
+	} else {
+		res, err = r.db.Exec("UPDATE reminders SET status = ?, last_error = ? WHERE id = ? AND status = ? AND next_attempt_at = ?",
+			entity.StatusFailed, reason, d.ID, entity.StatusPending, d.LeaseUntil)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return checkLease(op, res)
+}
+
+func checkLease(op string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrLeaseLost
+	}
+	return nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func get(q querier, id int, userID int) (*entity.Reminder, error) {
+	rem, err := scanReminder(q.QueryRow("SELECT "+reminderColumns+" FROM reminders WHERE id = ? AND user_id = ?", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrReminderNotFound
+	}
+	return rem, err
+}
+
+func list(q querier, query string, args ...any) ([]entity.Reminder, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []entity.Reminder{}
+	for rows.Next() {
+		rem, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, *rem)
+	}
+	return reminders, rows.Err()
+}
+
+// scanReminder scans a row starting with reminderColumns; extra receives any
+// trailing columns.
+func scanReminder(s scanner, extra ...any) (*entity.Reminder, error) {
+	var (
+		rem    entity.Reminder
+		sentAt sql.NullTime
+	)
+	dest := append([]any{&rem.ID, &rem.NoteID, &rem.UserID, &rem.RemindAt, &rem.Status, &rem.Attempts, &rem.LastError, &sentAt, &rem.CreatedAt}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if sentAt.Valid {
+		rem.SentAt = &sentAt.Time
+	}
+	return &rem, nil
+}
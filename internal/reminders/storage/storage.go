@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"errors"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/reminders/entity"
+	"time"
+)
+
+var (
+	ErrNoteNotFound     = notesstorage.ErrNoteNotFound
+	ErrReminderNotFound = errors.New("reminder not found")
+	ErrDismissed        = errors.New("reminder has been dismissed")
+	// ErrLeaseLost means the reminder changed after it was claimed, for
+	// example because it was snoozed, so the outcome was not recorded.
+	ErrLeaseLost = errors.New("reminder is no longer claimed")
+)
+
+type ReminderRepository interface {
+	Create(r *entity.Reminder) (*entity.Reminder, error)
+	List(noteID int, userID int) ([]entity.Reminder, error)
+	// Upcoming returns the user's pending reminders, soonest first.
+	Upcoming(userID int) ([]entity.Reminder, error)
+	Snooze(id int, userID int, until time.Time) (*entity.Reminder, error)
+	Dismiss(id int, userID int) (*entity.Reminder, error)
+	Scheduler
+}
+
+// Scheduler is the part of the repository the scheduler drives. A claimed
+// reminder is hidden from other claims until its lease runs out; it must be
+// marked sent or failed by then or it is delivered again.
+type Scheduler interface {
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error)
+	MarkSent(d *entity.Due, at time.Time) error
+	// MarkFailed records a failed attempt. The reminder is retried at retryAt,
+	// or given up on when retryAt is nil.
+	MarkFailed(d *entity.Due, retryAt *time.Time, reason string) error
+}
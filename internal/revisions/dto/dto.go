@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"gonotes/internal/revisions/entity"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+// RevisionSummaryResponse lists a revision without its content.
+type RevisionSummaryResponse struct {
+	Revision  int       `json:"revision"`
+	AuthorID  int       `json:"author_id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (rs *RevisionSummaryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewRevisionListResponse(revisions []entity.Revision) []render.Renderer {
+	list := make([]render.Renderer, len(revisions))
+	for i, rev := range revisions {
+		list[i] = &RevisionSummaryResponse{
+			Revision:  rev.Revision,
+			AuthorID:  rev.AuthorID,
+			Title:     rev.Title,
+			CreatedAt: rev.CreatedAt,
+		}
+	}
+	return list
+}
+
+type RevisionResponse struct {
+	NoteID    int       `json:"note_id"`
+	Revision  int       `json:"revision"`
+	AuthorID  int       `json:"author_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (rr *RevisionResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewRevisionResponse(rev *entity.Revision) *RevisionResponse {
+	return &RevisionResponse{
+		NoteID:    rev.NoteID,
+		Revision:  rev.Revision,
+		AuthorID:  rev.AuthorID,
+		Title:     rev.Title,
+		Content:   rev.Content,
+		CreatedAt: rev.CreatedAt,
+	}
+}
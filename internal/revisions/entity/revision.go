@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+// Revision is an immutable snapshot of a note's title and content.
+type Revision struct {
+	ID        int       `json:"id"`
+	NoteID    int       `json:"note_id"`
+	Revision  int       `json:"revision"`
+	AuthorID  int       `json:"author_id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,252 @@
+package revisionshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/diff"
+	"gonotes/internal/middleware"
+	notesdto "gonotes/internal/notes/dto"
+	notesentity "gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/revisions/dto"
+	"gonotes/internal/revisions/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+const diffContext = 3
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.RevisionRepository
+	notes   notesstorage.NoteRepository
+	events  events.Publisher
+}
+
+func NewHandler(log *slog.Logger, storage storage.RevisionRepository, notes notesstorage.NoteRepository, pub events.Publisher) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		notes:   notes,
+		events:  pub,
+	}
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "revisions.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	list, err := h.storage.List(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list revisions", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewRevisionListResponse(list))
+
+	log.Info("revisions retrieved", slog.Int("note_id", noteID), slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "revisions.handler.get"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, revision, err := parseIDs(r)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	rev, err := h.storage.Get(noteID, userID, revision)
+	if err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewRevisionResponse(rev))
+
+	log.Info("revision retrieved", slog.Int("note_id", noteID), slog.Int("revision", revision), slog.Int("user_id", userID))
+}
+
+// Diff writes a unified diff of the content between the revisions given by
+// the from and to query parameters.
+func (h *Handler) Diff(w http.ResponseWriter, r *http.Request) {
+	const op = "revisions.handler.diff"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	query := r.URL.Query()
+	from, err := strconv.Atoi(query.Get("from"))
+	if err != nil || from <= 0 {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("from must be a revision number")))
+		return
+	}
+	to, err := strconv.Atoi(query.Get("to"))
+	if err != nil || to <= 0 {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("to must be a revision number")))
+		return
+	}
+
+	fromRev, err := h.storage.Get(noteID, userID, from)
+	if err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+	toRev, err := h.storage.Get(noteID, userID, to)
+	if err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+
+	if len(diff.SplitLines(fromRev.Content))+len(diff.SplitLines(toRev.Content)) > diff.MaxLines {
+		log.Error("revisions too large to compare", slog.Int("note_id", noteID))
+		render.Render(w, r, api.NewErrResponse(http.StatusRequestEntityTooLarge, fmt.Errorf("revisions have more than %d lines together", diff.MaxLines)))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.PlainText(w, r, diff.Unified(
+		fmt.Sprintf("revision %d\t%s", fromRev.Revision, fromRev.Title),
+		fmt.Sprintf("revision %d\t%s", toRev.Revision, toRev.Title),
+		fromRev.Content, toRev.Content, diffContext,
+	))
+
+	log.Info("revisions compared", slog.Int("note_id", noteID), slog.Int("from", from), slog.Int("to", to), slog.Int("user_id", userID))
+}
+
+// Revert restores the title and content of an old revision. The note is
+// updated like any other edit, so the revert is recorded as a new revision.
+func (h *Handler) Revert(w http.ResponseWriter, r *http.Request) {
+	const op = "revisions.handler.revert"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, revision, err := parseIDs(r)
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	version, err := api.IfMatch(r)
+	if err != nil {
+		log.Error("invalid If-Match header", slog.Any("err", err))
+		status := http.StatusBadRequest
+		if errors.Is(err, api.ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		render.Render(w, r, api.NewErrResponse(status, err))
+		return
+	}
+
+	rev, err := h.storage.Get(noteID, userID, revision)
+	if err != nil {
+		h.renderGetError(w, r, log, err)
+		return
+	}
+
+	updated, err := h.notes.Update(noteID, userID, &notesentity.Note{
+		Title:   rev.Title,
+		Content: rev.Content,
+		Version: version,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, notesstorage.ErrVersionMismatch):
+			render.Render(w, r, api.NewErrResponse(http.StatusPreconditionFailed, err))
+		case errors.Is(err, notesstorage.ErrNoteNotFound):
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, notesstorage.ErrForbidden):
+			render.Render(w, r, api.NewErrResponse(http.StatusForbidden, err))
+		default:
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		log.Error("failed to revert note", slog.Any("err", err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, updated))
+
+	w.Header().Set("ETag", api.ETag(updated.Version))
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, notesdto.NewNoteResponse(updated))
+
+	log.Info("note reverted", slog.Int("note_id", noteID), slog.Int("revision", revision), slog.Int("user_id", userID))
+}
+
+func (h *Handler) renderGetError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrRevisionNotFound) {
+		log.Error("revision not found", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		return
+	}
+	log.Error("failed to get revision", slog.Any("err", err))
+	render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+}
+
+func parseIDs(r *http.Request) (int, int, error) {
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid id format")
+	}
+	revision, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid revision format")
+	}
+	return noteID, revision, nil
+}
@@ -0,0 +1,229 @@
+package revisionshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/diff"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	notesdto "gonotes/internal/notes/dto"
+	notesentity "gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"gonotes/internal/revisions/dto"
+	"gonotes/internal/revisions/entity"
+	"gonotes/internal/revisions/revisionshandler"
+	"gonotes/internal/revisions/storage"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockRevisionsRepo struct {
+	revisions map[int]entity.Revision
+}
+
+func (m *mockRevisionsRepo) List(noteID, userID int) ([]entity.Revision, error) {
+	if noteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	var list []entity.Revision
+	for i := len(m.revisions); i > 0; i-- {
+		list = append(list, m.revisions[i])
+	}
+	return list, nil
+}
+func (m *mockRevisionsRepo) Get(noteID, userID, revision int) (*entity.Revision, error) {
+	if noteID != 1 {
+		return nil, storage.ErrNoteNotFound
+	}
+	rev, ok := m.revisions[revision]
+	if !ok {
+		return nil, storage.ErrRevisionNotFound
+	}
+	return &rev, nil
+}
+
+func newRepo() *mockRevisionsRepo {
+	return &mockRevisionsRepo{revisions: map[int]entity.Revision{
+		1: {NoteID: 1, Revision: 1, AuthorID: 1, Title: "Plan", Content: "one\ntwo\n"},
+		2: {NoteID: 1, Revision: 2, AuthorID: 1, Title: "Plan v2", Content: "one\nthree\n"},
+	}}
+}
+
+func newRequest(method, target, noteID, rev string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", noteID)
+	rctx.URLParams.Add("rev", rev)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_GetAll(t *testing.T) {
+	h := revisionshandler.NewHandler(slogdiscard.NewDiscardLogger(), newRepo(), &storagetest.NoteRepository{}, events.NewBus())
+
+	rec := httptest.NewRecorder()
+	h.GetAll(rec, newRequest(http.MethodGet, "/notes/1/revisions", "1", "", true))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp []dto.RevisionSummaryResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	if assert.Len(t, resp, 2) {
+		assert.Equal(t, 2, resp[0].Revision)
+	}
+
+	rec = httptest.NewRecorder()
+	h.GetAll(rec, newRequest(http.MethodGet, "/notes/2/revisions", "2", "", true))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.GetAll(rec, newRequest(http.MethodGet, "/notes/1/revisions", "1", "", false))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func Test_Get(t *testing.T) {
+	tests := []struct {
+		name           string
+		noteID, rev    string
+		expectedStatus int
+	}{
+		{name: "found", noteID: "1", rev: "2", expectedStatus: http.StatusOK},
+		{name: "revision not found", noteID: "1", rev: "9", expectedStatus: http.StatusNotFound},
+		{name: "note not found", noteID: "2", rev: "1", expectedStatus: http.StatusNotFound},
+		{name: "invalid revision", noteID: "1", rev: "x", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := revisionshandler.NewHandler(slogdiscard.NewDiscardLogger(), newRepo(), &storagetest.NoteRepository{}, events.NewBus())
+
+			rec := httptest.NewRecorder()
+			h.Get(rec, newRequest(http.MethodGet, "/notes/"+tc.noteID+"/revisions/"+tc.rev, tc.noteID, tc.rev, true))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusOK {
+				var resp dto.RevisionResponse
+				_ = json.NewDecoder(rec.Body).Decode(&resp)
+				assert.Equal(t, "one\nthree\n", resp.Content)
+			}
+		})
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "diff between revisions",
+			query:          "?from=1&to=2",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "--- revision 1\tPlan\n+++ revision 2\tPlan v2\n@@ -1,2 +1,2 @@\n one\n-two\n+three\n",
+		},
+		{
+			name:           "same revision",
+			query:          "?from=2&to=2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing to",
+			query:          "?from=1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "too large",
+			query:          "?from=1&to=3",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:           "unknown revision",
+			query:          "?from=1&to=5",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := newRepo()
+			repo.revisions[3] = entity.Revision{NoteID: 1, Revision: 3, AuthorID: 1, Title: "Plan v3", Content: strings.Repeat("line\n", diff.MaxLines)}
+			h := revisionshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo, &storagetest.NoteRepository{}, events.NewBus())
+
+			rec := httptest.NewRecorder()
+			h.Diff(rec, newRequest(http.MethodGet, "/notes/1/revisions/diff"+tc.query, "1", "", true))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusOK {
+				body, _ := io.ReadAll(rec.Body)
+				assert.Equal(t, tc.expectedBody, string(body))
+			}
+		})
+	}
+}
+
+func Test_Revert(t *testing.T) {
+	tests := []struct {
+		name           string
+		rev            string
+		ifMatch        string
+		updateErr      error
+		expectedStatus int
+	}{
+		{name: "reverted", rev: "1", expectedStatus: http.StatusOK},
+		{name: "reverted with matching version", rev: "1", ifMatch: `"4"`, expectedStatus: http.StatusOK},
+		{name: "version mismatch", rev: "1", ifMatch: `"3"`, updateErr: notesstorage.ErrVersionMismatch, expectedStatus: http.StatusPreconditionFailed},
+		{name: "unknown revision", rev: "7", expectedStatus: http.StatusNotFound},
+		{name: "read-only share", rev: "1", updateErr: notesstorage.ErrForbidden, expectedStatus: http.StatusForbidden},
+		{name: "repo error", rev: "1", updateErr: errors.New("db error"), expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *notesentity.Note
+			notes := &storagetest.NoteRepository{
+				UpdateFunc: func(id, userID int, note *notesentity.Note) (*notesentity.Note, error) {
+					if tc.updateErr != nil {
+						return nil, tc.updateErr
+					}
+					got = note
+					return &notesentity.Note{ID: id, Title: note.Title, Content: note.Content, Version: 5}, nil
+				},
+			}
+			h := revisionshandler.NewHandler(slogdiscard.NewDiscardLogger(), newRepo(), notes, events.NewBus())
+
+			req := newRequest(http.MethodPost, "/notes/1/revisions/"+tc.rev+"/revert", "1", tc.rev, true)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			h.Revert(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusOK {
+				var resp notesdto.NoteResponse
+				_ = json.NewDecoder(rec.Body).Decode(&resp)
+				assert.Equal(t, "one\ntwo\n", resp.Content)
+				assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+				assert.Nil(t, got.Tags, "revert must keep the note's tags")
+				if tc.ifMatch != "" {
+					assert.Equal(t, 4, got.Version)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package revisionssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+	"gonotes/internal/revisions/entity"
+	"gonotes/internal/revisions/storage"
+)
+
+const revisionColumns = "r.id, r.note_id, r.revision, r.author_id, r.title, r.content, r.created_at"
+
+type RevisionRepository struct {
+	db *sql.DB
+}
+
+func NewRevisionRepository(db *sql.DB) *RevisionRepository {
+	return &RevisionRepository{db: db}
+}
+
+// List returns the revisions of a note, newest first.
+func (r *RevisionRepository) List(noteID int, userID int) ([]entity.Revision, error) {
+	const op = "revisions.sqlite.List"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query("SELECT "+revisionColumns+" FROM note_revisions r WHERE r.note_id = ? ORDER BY r.revision DESC", noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	revisions := []entity.Revision{}
+	for rows.Next() {
+		var rev entity.Revision
+		if err := rows.Scan(&rev.ID, &rev.NoteID, &rev.Revision, &rev.AuthorID, &rev.Title, &rev.Content, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+func (r *RevisionRepository) Get(noteID int, userID int, revision int) (*entity.Revision, error) {
+	const op = "revisions.sqlite.Get"
+
+	if err := notessqlite.CheckNote(r.db, noteID, userID, notesstorage.AccessRead); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var rev entity.Revision
+	err := r.db.QueryRow("SELECT "+revisionColumns+" FROM note_revisions r WHERE r.note_id = ? AND r.revision = ?", noteID, revision).
+		Scan(&rev.ID, &rev.NoteID, &rev.Revision, &rev.AuthorID, &rev.Title, &rev.Content, &rev.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrRevisionNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &rev, nil
+}
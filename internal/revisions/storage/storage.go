@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"errors"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/revisions/entity"
+)
+
+var (
+	ErrNoteNotFound     = notesstorage.ErrNoteNotFound
+	ErrRevisionNotFound = errors.New("revision not found")
+)
+
+// RevisionRepository reads the revision history of the notes a user owns or
+// has been shared. Revisions are written by the note repository as notes
+// change.
+type RevisionRepository interface {
+	List(noteID int, userID int) ([]entity.Revision, error)
+	Get(noteID int, userID int, revision int) (*entity.Revision, error)
+}
@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/shares/entity"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+type ShareRequest struct {
+	Email      string            `json:"email"`
+	Permission entity.Permission `json:"permission"`
+}
+
+func (s *ShareRequest) Bind(r *http.Request) error {
+	s.Email = strings.TrimSpace(s.Email)
+	if s.Email == "" {
+		return fmt.Errorf("email is required")
+	}
+	if s.Permission == "" {
+		s.Permission = entity.PermissionRead
+	}
+	if !s.Permission.Valid() {
+		return fmt.Errorf("permission must be %s or %s", entity.PermissionRead, entity.PermissionEdit)
+	}
+	return nil
+}
+
+type ShareResponse struct {
+	NoteID     int               `json:"note_id"`
+	UserID     int               `json:"user_id"`
+	Email      string            `json:"email"`
+	Permission entity.Permission `json:"permission"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+func (sr *ShareResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewShareResponse(s *entity.Share) *ShareResponse {
+	return &ShareResponse{
+		NoteID:     s.NoteID,
+		UserID:     s.UserID,
+		Email:      s.Email,
+		Permission: s.Permission,
+		CreatedAt:  s.CreatedAt,
+	}
+}
+
+func NewShareListResponse(shares []entity.Share) []render.Renderer {
+	list := make([]render.Renderer, len(shares))
+	for i := range shares {
+		list[i] = NewShareResponse(&shares[i])
+	}
+	return list
+}
+
+type SharedNoteResponse struct {
+	NoteID     int               `json:"note_id"`
+	Title      string            `json:"title"`
+	OwnerID    int               `json:"owner_id"`
+	OwnerEmail string            `json:"owner_email"`
+	Permission entity.Permission `json:"permission"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	SharedAt   time.Time         `json:"shared_at"`
+}
+
+func (sn *SharedNoteResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewSharedNoteListResponse(notes []entity.SharedNote) []render.Renderer {
+	list := make([]render.Renderer, len(notes))
+	for i, n := range notes {
+		list[i] = &SharedNoteResponse{
+			NoteID:     n.NoteID,
+			Title:      n.Title,
+			OwnerID:    n.OwnerID,
+			OwnerEmail: n.OwnerEmail,
+			Permission: n.Permission,
+			UpdatedAt:  n.UpdatedAt,
+			SharedAt:   n.SharedAt,
+		}
+	}
+	return list
+}
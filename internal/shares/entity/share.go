@@ -0,0 +1,35 @@
+package entity
+
+import "time"
+
+// Permission is the access a share grants on a note.
+type Permission string
+
+const (
+	PermissionRead Permission = "read"
+	PermissionEdit Permission = "edit"
+)
+
+func (p Permission) Valid() bool {
+	return p == PermissionRead || p == PermissionEdit
+}
+
+// Share grants a user other than the owner access to a note.
+type Share struct {
+	NoteID     int        `json:"note_id"`
+	UserID     int        `json:"user_id"`
+	Email      string     `json:"email"`
+	Permission Permission `json:"permission"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// SharedNote summarises a note shared with the current user.
+type SharedNote struct {
+	NoteID     int        `json:"note_id"`
+	Title      string     `json:"title"`
+	OwnerID    int        `json:"owner_id"`
+	OwnerEmail string     `json:"owner_email"`
+	Permission Permission `json:"permission"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	SharedAt   time.Time  `json:"shared_at"`
+}
@@ -0,0 +1,179 @@
+package shareshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/middleware"
+	"gonotes/internal/shares/dto"
+	"gonotes/internal/shares/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.ShareRepository
+}
+
+func NewHandler(log *slog.Logger, storage storage.ShareRepository) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// Grant shares a note with another user, or changes the permission of an
+// existing share.
+func (h *Handler) Grant(w http.ResponseWriter, r *http.Request) {
+	const op = "shares.handler.grant"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.ShareRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	share, err := h.storage.Grant(noteID, userID, req.Email, req.Permission)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNoteNotFound), errors.Is(err, storage.ErrUserNotFound):
+			log.Error("share target not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, storage.ErrSelfShare):
+			log.Error("share with owner", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		default:
+			log.Error("failed to share note", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewShareResponse(share))
+
+	log.Info("note shared", slog.Int("note_id", noteID), slog.Int("shared_with", share.UserID), slog.String("permission", string(share.Permission)), slog.Int("user_id", userID))
+}
+
+// Revoke removes the share of the user given by the email query parameter.
+func (h *Handler) Revoke(w http.ResponseWriter, r *http.Request) {
+	const op = "shares.handler.revoke"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	email := strings.TrimSpace(r.URL.Query().Get("email"))
+	if email == "" {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("email is required")))
+		return
+	}
+
+	if err := h.storage.Revoke(noteID, userID, email); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) || errors.Is(err, storage.ErrShareNotFound) {
+			log.Error("share not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to revoke share", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("share revoked", slog.Int("note_id", noteID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "shares.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	noteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	list, err := h.storage.List(noteID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			log.Error("note not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list shares", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewShareListResponse(list))
+
+	log.Info("shares retrieved", slog.Int("note_id", noteID), slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) SharedWithMe(w http.ResponseWriter, r *http.Request) {
+	const op = "shares.handler.sharedWithMe"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.SharedWith(userID)
+	if err != nil {
+		log.Error("failed to list shared notes", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewSharedNoteListResponse(list))
+
+	log.Info("shared notes retrieved", slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
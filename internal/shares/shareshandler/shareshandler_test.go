@@ -0,0 +1,211 @@
+package shareshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/shares/dto"
+	"gonotes/internal/shares/entity"
+	"gonotes/internal/shares/shareshandler"
+	"gonotes/internal/shares/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSharesRepo struct {
+	grantFunc      func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error)
+	revokeFunc     func(noteID, ownerID int, email string) error
+	listFunc       func(noteID, ownerID int) ([]entity.Share, error)
+	sharedWithFunc func(userID int) ([]entity.SharedNote, error)
+}
+
+func (m *mockSharesRepo) Grant(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+	return m.grantFunc(noteID, ownerID, email, permission)
+}
+func (m *mockSharesRepo) Revoke(noteID, ownerID int, email string) error {
+	return m.revokeFunc(noteID, ownerID, email)
+}
+func (m *mockSharesRepo) List(noteID, ownerID int) ([]entity.Share, error) {
+	return m.listFunc(noteID, ownerID)
+}
+func (m *mockSharesRepo) SharedWith(userID int) ([]entity.SharedNote, error) {
+	return m.sharedWithFunc(userID)
+}
+
+func newRequest(method, target, body, noteID string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", noteID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_Grant(t *testing.T) {
+	tests := []struct {
+		name               string
+		withUser           bool
+		body               string
+		mockGrant          func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error)
+		expectedStatus     int
+		expectedPermission entity.Permission
+	}{
+		{
+			name:           "unauthorized",
+			body:           `{"email":"b@b"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "defaults to read",
+			withUser: true,
+			body:     `{"email":" b@b "}`,
+			mockGrant: func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+				return &entity.Share{NoteID: noteID, UserID: 2, Email: email, Permission: permission}, nil
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedPermission: entity.PermissionRead,
+		},
+		{
+			name:     "edit",
+			withUser: true,
+			body:     `{"email":"b@b","permission":"edit"}`,
+			mockGrant: func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+				return &entity.Share{NoteID: noteID, UserID: 2, Email: email, Permission: permission}, nil
+			},
+			expectedStatus:     http.StatusCreated,
+			expectedPermission: entity.PermissionEdit,
+		},
+		{
+			name:           "invalid permission",
+			withUser:       true,
+			body:           `{"email":"b@b","permission":"admin"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing email",
+			withUser:       true,
+			body:           `{"permission":"read"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown user",
+			withUser: true,
+			body:     `{"email":"nobody@b"}`,
+			mockGrant: func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+				return nil, storage.ErrUserNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:     "share with self",
+			withUser: true,
+			body:     `{"email":"a@a"}`,
+			mockGrant: func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+				return nil, storage.ErrSelfShare
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "repo error",
+			withUser: true,
+			body:     `{"email":"b@b"}`,
+			mockGrant: func(noteID, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := shareshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockSharesRepo{grantFunc: tt.mockGrant})
+
+			rr := httptest.NewRecorder()
+			h.Grant(rr, newRequest(http.MethodPost, "/notes/1/shares", tt.body, "1", tt.withUser))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusCreated {
+				var resp dto.ShareResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				assert.Equal(t, "b@b", resp.Email)
+				assert.Equal(t, tt.expectedPermission, resp.Permission)
+			}
+		})
+	}
+}
+
+func Test_Revoke(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		mockRevoke     func(noteID, ownerID int, email string) error
+		expectedStatus int
+	}{
+		{
+			name:   "revoked",
+			target: "/notes/1/shares?email=b@b",
+			mockRevoke: func(noteID, ownerID int, email string) error {
+				if email != "b@b" {
+					return errors.New("unexpected email")
+				}
+				return nil
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "missing email",
+			target:         "/notes/1/shares",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "not shared",
+			target: "/notes/1/shares?email=c@c",
+			mockRevoke: func(noteID, ownerID int, email string) error {
+				return storage.ErrShareNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := shareshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockSharesRepo{revokeFunc: tt.mockRevoke})
+
+			rr := httptest.NewRecorder()
+			h.Revoke(rr, newRequest(http.MethodDelete, tt.target, "", "1", true))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func Test_SharedWithMe(t *testing.T) {
+	h := shareshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockSharesRepo{
+		sharedWithFunc: func(userID int) ([]entity.SharedNote, error) {
+			return []entity.SharedNote{{NoteID: 3, Title: "Plan", OwnerID: 2, OwnerEmail: "b@b", Permission: entity.PermissionEdit}}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	h.SharedWithMe(rr, newRequest(http.MethodGet, "/notes/shared-with-me", "", "", true))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var resp []dto.SharedNoteResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "b@b", resp[0].OwnerEmail)
+	assert.Equal(t, entity.PermissionEdit, resp[0].Permission)
+}
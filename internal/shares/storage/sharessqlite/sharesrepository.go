@@ -0,0 +1,167 @@
+package sharessqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/notessqlite"
+	"gonotes/internal/shares/entity"
+	"gonotes/internal/shares/storage"
+	"time"
+)
+
+type ShareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+func (r *ShareRepository) Grant(noteID int, ownerID int, email string, permission entity.Permission) (*entity.Share, error) {
+	const op = "shares.sqlite.Grant"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if err := notessqlite.CheckNote(tx, noteID, ownerID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err := userByEmail(tx, email)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if userID == ownerID {
+		return nil, storage.ErrSelfShare
+	}
+
+	share := &entity.Share{NoteID: noteID, UserID: userID, Email: email, Permission: permission}
+	err = tx.QueryRow(`INSERT INTO note_shares (note_id, user_id, permission, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(note_id, user_id) DO UPDATE SET permission = excluded.permission
+		RETURNING created_at`, noteID, userID, permission, time.Now().UTC()).Scan(&share.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return share, nil
+}
+
+func (r *ShareRepository) Revoke(noteID int, ownerID int, email string) error {
+	const op = "shares.sqlite.Revoke"
+
+	if err := notessqlite.CheckNote(r.db, noteID, ownerID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return err
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	res, err := r.db.Exec("DELETE FROM note_shares WHERE note_id = ? AND user_id = (SELECT id FROM users WHERE email = ?)", noteID, email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return storage.ErrShareNotFound
+	}
+
+	return nil
+}
+
+// List returns who a note is shared with, in the order access was granted.
+func (r *ShareRepository) List(noteID int, ownerID int) ([]entity.Share, error) {
+	const op = "shares.sqlite.List"
+
+	if err := notessqlite.CheckNote(r.db, noteID, ownerID, notesstorage.AccessOwner); err != nil {
+		if errors.Is(err, storage.ErrNoteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(`SELECT s.note_id, s.user_id, u.email, s.permission, s.created_at
+		FROM note_shares s JOIN users u ON u.id = s.user_id
+		WHERE s.note_id = ? ORDER BY s.created_at, s.user_id`, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	shares := []entity.Share{}
+	for rows.Next() {
+		var s entity.Share
+		if err := rows.Scan(&s.NoteID, &s.UserID, &s.Email, &s.Permission, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		shares = append(shares, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return shares, nil
+}
+
+// SharedWith lists the notes other users have shared with userID, most
+// recently updated first. Notes in their owner's trash are left out.
+func (r *ShareRepository) SharedWith(userID int) ([]entity.SharedNote, error) {
+	const op = "shares.sqlite.SharedWith"
+
+	rows, err := r.db.Query(`SELECT n.id, n.title, n.user_id, u.email, s.permission, n.updated_at, s.created_at
+		FROM note_shares s
+		JOIN notes n ON n.id = s.note_id AND n.deleted_at IS NULL
+		JOIN users u ON u.id = n.user_id
+		WHERE s.user_id = ? ORDER BY n.updated_at DESC, n.id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	notes := []entity.SharedNote{}
+	for rows.Next() {
+		var n entity.SharedNote
+		if err := rows.Scan(&n.NoteID, &n.Title, &n.OwnerID, &n.OwnerEmail, &n.Permission, &n.UpdatedAt, &n.SharedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		notes = append(notes, n)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return notes, nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func userByEmail(q querier, email string) (int, error) {
+	var id int
+	err := q.QueryRow("SELECT id FROM users WHERE email = ?", email).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, storage.ErrUserNotFound
+	}
+	return id, err
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"errors"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/shares/entity"
+)
+
+var (
+	ErrNoteNotFound  = notesstorage.ErrNoteNotFound
+	ErrUserNotFound  = errors.New("user not found")
+	ErrShareNotFound = errors.New("share not found")
+	ErrSelfShare     = errors.New("cannot share a note with its owner")
+)
+
+type ShareRepository interface {
+	// Grant shares a note owned by ownerID with the user registered under
+	// email, replacing the permission of an existing share.
+	Grant(noteID int, ownerID int, email string, permission entity.Permission) (*entity.Share, error)
+	Revoke(noteID int, ownerID int, email string) error
+	List(noteID int, ownerID int) ([]entity.Share, error)
+	SharedWith(userID int) ([]entity.SharedNote, error)
+}
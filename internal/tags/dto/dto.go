@@ -0,0 +1,59 @@
+package dto
+
+import (
+	"fmt"
+	"gonotes/internal/tags/entity"
+	"net/http"
+
+	"github.com/go-chi/render"
+)
+
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+func (t *RenameRequest) Bind(r *http.Request) error {
+	name, err := entity.NormalizeName(t.Name)
+	if err != nil {
+		return err
+	}
+	t.Name = name
+	return nil
+}
+
+type MergeRequest struct {
+	Into int `json:"into"`
+}
+
+func (m *MergeRequest) Bind(r *http.Request) error {
+	if m.Into <= 0 {
+		return fmt.Errorf("into is required")
+	}
+	return nil
+}
+
+type TagResponse struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	NoteCount int    `json:"note_count"`
+}
+
+func (t *TagResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewTagResponse(t *entity.Tag) *TagResponse {
+	return &TagResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		NoteCount: t.NoteCount,
+	}
+}
+
+func NewTagListResponse(tags []entity.Tag) []render.Renderer {
+	list := make([]render.Renderer, len(tags))
+	for i, t := range tags {
+		list[i] = NewTagResponse(&t)
+	}
+	return list
+}
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxNameLength = 64
+
+type Tag struct {
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	NoteCount int    `json:"note_count"`
+}
+
+// NormalizeName trims and lower-cases a tag name and rejects names that
+// cannot round-trip through the ?tag= list syntax.
+func NormalizeName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("tag name is required")
+	}
+	if utf8.RuneCountInString(name) > MaxNameLength {
+		return "", fmt.Errorf("tag name must be at most %d characters", MaxNameLength)
+	}
+	if strings.Contains(name, ",") {
+		return "", fmt.Errorf("tag name must not contain commas")
+	}
+	return name, nil
+}
+
+// NormalizeNames normalizes every name and drops duplicates, keeping order.
+func NormalizeNames(names []string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		n, err := NormalizeName(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
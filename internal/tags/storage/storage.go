@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/tags/entity"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag already exists")
+)
+
+type TagRepository interface {
+	List(userID int) ([]entity.Tag, error)
+	// Rename, Merge and Delete also return the IDs of the notes whose tags
+	// they changed.
+	Rename(id int, userID int, name string) (*entity.Tag, []int, error)
+	Merge(sourceID int, targetID int, userID int) (*entity.Tag, []int, error)
+	Delete(id int, userID int) ([]int, error)
+}
@@ -0,0 +1,227 @@
+package tagssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/tags/entity"
+	"gonotes/internal/tags/storage"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+func (r *TagRepository) List(userID int) ([]entity.Tag, error) {
+	const op = "tags.sqlite.List"
+
+	rows, err := r.db.Query(`
+		SELECT t.id, t.user_id, t.name, COUNT(n.id)
+		FROM tags t
+		LEFT JOIN note_tags nt ON nt.tag_id = t.id
+		LEFT JOIN notes n ON n.id = nt.note_id AND n.deleted_at IS NULL
+		WHERE t.user_id = ?
+		GROUP BY t.id
+		ORDER BY t.name`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tags := []entity.Tag{}
+	for rows.Next() {
+		var t entity.Tag
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.NoteCount); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// Rename renames a tag and returns the IDs of the notes carrying it.
+func (r *TagRepository) Rename(id int, userID int, name string) (*entity.Tag, []int, error) {
+	const op = "tags.sqlite.Rename"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE tags SET name = ? WHERE id = ? AND user_id = ?", name, id, userID)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return nil, nil, storage.ErrTagExists
+		}
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return nil, nil, storage.ErrTagNotFound
+	}
+
+	noteIDs, err := taggedNotes(tx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	t, err := get(tx, id, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, noteIDs, nil
+}
+
+// Merge retags every note carrying the source tag with the target tag and
+// removes the source tag. It returns the IDs of the retagged notes.
+func (r *TagRepository) Merge(sourceID int, targetID int, userID int) (*entity.Tag, []int, error) {
+	const op = "tags.sqlite.Merge"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range []int{sourceID, targetID} {
+		if _, err := get(tx, id, userID); err != nil {
+			if errors.Is(err, storage.ErrTagNotFound) {
+				return nil, nil, err
+			}
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	noteIDs, err := taggedNotes(tx, sourceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR IGNORE INTO note_tags(note_id, tag_id)
+		SELECT note_id, ? FROM note_tags WHERE tag_id = ?`, targetID, sourceID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", sourceID); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err = tx.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", sourceID, userID); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	t, err := get(tx, targetID, userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, noteIDs, nil
+}
+
+// Delete removes a tag from every note carrying it and returns their IDs.
+func (r *TagRepository) Delete(id int, userID int) ([]int, error) {
+	const op = "tags.sqlite.Delete"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM tags WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if affected == 0 {
+		return nil, storage.ErrTagNotFound
+	}
+
+	noteIDs, err := taggedNotes(tx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM note_tags WHERE tag_id = ?", id); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return noteIDs, nil
+}
+
+func get(tx *sql.Tx, id int, userID int) (*entity.Tag, error) {
+	var t entity.Tag
+	err := tx.QueryRow(`
+		SELECT t.id, t.user_id, t.name, (
+			SELECT COUNT(*) FROM note_tags nt
+			JOIN notes n ON n.id = nt.note_id
+			WHERE nt.tag_id = t.id AND n.deleted_at IS NULL
+		)
+		FROM tags t
+		WHERE t.id = ? AND t.user_id = ?`, id, userID).Scan(&t.ID, &t.UserID, &t.Name, &t.NoteCount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// taggedNotes returns the IDs of the notes outside the trash that carry a tag.
+func taggedNotes(tx *sql.Tx, tagID int) ([]int, error) {
+	rows, err := tx.Query(`
+		SELECT nt.note_id FROM note_tags nt
+		JOIN notes n ON n.id = nt.note_id
+		WHERE nt.tag_id = ? AND n.deleted_at IS NULL
+		ORDER BY nt.note_id`, tagID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,204 @@
+package tagshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/tags/dto"
+	"gonotes/internal/tags/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.TagRepository
+	notes   notesstorage.NoteRepository
+	events  events.Publisher
+}
+
+func NewHandler(log *slog.Logger, storage storage.TagRepository, notes notesstorage.NoteRepository, pub events.Publisher) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		notes:   notes,
+		events:  pub,
+	}
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "tags.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.List(userID)
+	if err != nil {
+		log.Error("failed to list tags", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewTagListResponse(list))
+
+	log.Info("tags retrieved", slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Rename(w http.ResponseWriter, r *http.Request) {
+	const op = "tags.handler.rename"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.RenameRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	t, noteIDs, err := h.storage.Rename(tagID, userID, req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrTagNotFound):
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+		case errors.Is(err, storage.ErrTagExists):
+			render.Render(w, r, api.NewErrResponse(http.StatusConflict, fmt.Errorf("%w, merge the tags instead", err)))
+		default:
+			render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		}
+		log.Error("failed to rename tag", slog.Any("err", err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewTagResponse(t))
+
+	log.Info("tag renamed", slog.Int("id", tagID), slog.Int("user_id", userID))
+
+	h.publishUpdated(log, userID, noteIDs)
+}
+
+func (h *Handler) Merge(w http.ResponseWriter, r *http.Request) {
+	const op = "tags.handler.merge"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.MergeRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+	if req.Into == tagID {
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("cannot merge a tag into itself")))
+		return
+	}
+
+	t, noteIDs, err := h.storage.Merge(tagID, req.Into, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.Error("tag not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to merge tags", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewTagResponse(t))
+
+	log.Info("tags merged", slog.Int("source_id", tagID), slog.Int("target_id", req.Into), slog.Int("user_id", userID))
+
+	h.publishUpdated(log, userID, noteIDs)
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "tags.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	tagID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	noteIDs, err := h.storage.Delete(tagID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTagNotFound) {
+			log.Error("tag not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to delete tag", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("tag deleted", slog.Int("id", tagID), slog.Int("user_id", userID))
+
+	h.publishUpdated(log, userID, noteIDs)
+}
+
+// publishUpdated announces the notes whose tags changed. The change is
+// already stored, so failing to load them only loses the events.
+func (h *Handler) publishUpdated(log *slog.Logger, userID int, noteIDs []int) {
+	notes, err := h.notes.Lookup(userID, noteIDs)
+	if err != nil {
+		log.Error("failed to load notes for events", slog.Any("err", err))
+		return
+	}
+	for i := range notes {
+		h.events.Publish(events.NewNoteEvent(events.NoteUpdated, userID, &notes[i]))
+	}
+}
@@ -0,0 +1,310 @@
+package tagshandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"gonotes/internal/api"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/notes/storage/storagetest"
+	"gonotes/internal/tags/dto"
+	"gonotes/internal/tags/entity"
+	"gonotes/internal/tags/storage"
+	"gonotes/internal/tags/tagshandler"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTagsRepo struct {
+	listFunc   func(userID int) ([]entity.Tag, error)
+	renameFunc func(id, userID int, name string) (*entity.Tag, []int, error)
+	mergeFunc  func(sourceID, targetID, userID int) (*entity.Tag, []int, error)
+	deleteFunc func(id, userID int) ([]int, error)
+}
+
+func (m *mockTagsRepo) List(userID int) ([]entity.Tag, error) {
+	return m.listFunc(userID)
+}
+func (m *mockTagsRepo) Rename(id, userID int, name string) (*entity.Tag, []int, error) {
+	return m.renameFunc(id, userID, name)
+}
+func (m *mockTagsRepo) Merge(sourceID, targetID, userID int) (*entity.Tag, []int, error) {
+	return m.mergeFunc(sourceID, targetID, userID)
+}
+func (m *mockTagsRepo) Delete(id, userID int) ([]int, error) {
+	return m.deleteFunc(id, userID)
+}
+
+// assertUpdated checks that the notes returned by the mocks were announced.
+func assertUpdated(t *testing.T, pub *storagetest.EventRecorder) {
+	t.Helper()
+	published := pub.Events()
+	if assert.Len(t, published, 2) {
+		for i, id := range []int{3, 5} {
+			assert.Equal(t, events.NoteUpdated, published[i].Type)
+			assert.Equal(t, id, published[i].Note.ID)
+		}
+	}
+}
+
+func newRequest(method, target, urlID, body string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", urlID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_GetAll(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupCtx       bool
+		mockList       func(userID int) ([]entity.Tag, error)
+		expectedStatus int
+		expectedLen    int
+	}{
+		{
+			name:           "unauthorized",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:     "repo error",
+			setupCtx: true,
+			mockList: func(userID int) ([]entity.Tag, error) {
+				return nil, errors.New("db error")
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:     "tags retrieved",
+			setupCtx: true,
+			mockList: func(userID int) ([]entity.Tag, error) {
+				return []entity.Tag{{ID: 1, Name: "home", NoteCount: 3}, {ID: 2, Name: "work", NoteCount: 0}}, nil
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := tagshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockTagsRepo{listFunc: tc.mockList}, &storagetest.NoteRepository{}, events.NewBus())
+
+			rec := httptest.NewRecorder()
+			h.GetAll(rec, newRequest(http.MethodGet, "/tags", "", "", tc.setupCtx))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp []dto.TagResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Len(t, resp, tc.expectedLen)
+				assert.Equal(t, 3, resp[0].NoteCount)
+			}
+		})
+	}
+}
+
+func Test_Rename(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		body           string
+		mockRename     func(id, userID int, name string) (*entity.Tag, []int, error)
+		expectedStatus int
+	}{
+		{
+			name:           "invalid id format",
+			urlID:          "abc",
+			body:           `{"name":"x"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "empty name",
+			urlID:          "1",
+			body:           `{"name":"  "}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "tag not found",
+			urlID: "1",
+			body:  `{"name":"x"}`,
+			mockRename: func(id, userID int, name string) (*entity.Tag, []int, error) {
+				return nil, nil, storage.ErrTagNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "name taken",
+			urlID: "1",
+			body:  `{"name":"x"}`,
+			mockRename: func(id, userID int, name string) (*entity.Tag, []int, error) {
+				return nil, nil, storage.ErrTagExists
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:  "tag renamed",
+			urlID: "1",
+			body:  `{"name":" Work "}`,
+			mockRename: func(id, userID int, name string) (*entity.Tag, []int, error) {
+				return &entity.Tag{ID: id, Name: name}, []int{3, 5}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &storagetest.EventRecorder{}
+			h := tagshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockTagsRepo{renameFunc: tc.mockRename}, &storagetest.NoteRepository{LookupFunc: storagetest.LookupOwned}, pub)
+
+			rec := httptest.NewRecorder()
+			h.Rename(rec, newRequest(http.MethodPatch, "/tags/"+tc.urlID, tc.urlID, tc.body, true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.TagResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, "work", resp.Name)
+				assertUpdated(t, pub)
+			} else {
+				assert.Empty(t, pub.Events())
+			}
+			if res.StatusCode >= 400 {
+				var resp api.ErrResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.NotEmpty(t, resp.Err)
+			}
+		})
+	}
+}
+
+func Test_Merge(t *testing.T) {
+	tests := []struct {
+		name           string
+		urlID          string
+		body           string
+		mockMerge      func(sourceID, targetID, userID int) (*entity.Tag, []int, error)
+		expectedStatus int
+	}{
+		{
+			name:           "missing target",
+			urlID:          "1",
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "merge into itself",
+			urlID:          "1",
+			body:           `{"into":1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "tag not found",
+			urlID: "1",
+			body:  `{"into":2}`,
+			mockMerge: func(sourceID, targetID, userID int) (*entity.Tag, []int, error) {
+				return nil, nil, storage.ErrTagNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "tags merged",
+			urlID: "1",
+			body:  `{"into":2}`,
+			mockMerge: func(sourceID, targetID, userID int) (*entity.Tag, []int, error) {
+				return &entity.Tag{ID: targetID, Name: "work", NoteCount: 5}, []int{3, 5}, nil
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &storagetest.EventRecorder{}
+			h := tagshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockTagsRepo{mergeFunc: tc.mockMerge}, &storagetest.NoteRepository{LookupFunc: storagetest.LookupOwned}, pub)
+
+			rec := httptest.NewRecorder()
+			h.Merge(rec, newRequest(http.MethodPost, "/tags/"+tc.urlID+"/merge", tc.urlID, tc.body, true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+
+			if res.StatusCode == http.StatusOK {
+				var resp dto.TagResponse
+				_ = json.NewDecoder(res.Body).Decode(&resp)
+				assert.Equal(t, 2, resp.ID)
+				assert.Equal(t, 5, resp.NoteCount)
+				assertUpdated(t, pub)
+			} else {
+				assert.Empty(t, pub.Events())
+			}
+		})
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	tests := []struct {
+		name           string
+		mockDelete     func(id, userID int) ([]int, error)
+		expectedStatus int
+	}{
+		{
+			name: "tag not found",
+			mockDelete: func(id, userID int) ([]int, error) {
+				return nil, storage.ErrTagNotFound
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "tag deleted",
+			mockDelete: func(id, userID int) ([]int, error) {
+				return []int{3, 5}, nil
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pub := &storagetest.EventRecorder{}
+			h := tagshandler.NewHandler(slogdiscard.NewDiscardLogger(), &mockTagsRepo{deleteFunc: tc.mockDelete}, &storagetest.NoteRepository{LookupFunc: storagetest.LookupOwned}, pub)
+
+			rec := httptest.NewRecorder()
+			h.Delete(rec, newRequest(http.MethodDelete, "/tags/1", "1", "", true))
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, res.StatusCode)
+			if res.StatusCode == http.StatusNoContent {
+				assertUpdated(t, pub)
+			} else {
+				assert.Empty(t, pub.Events())
+			}
+		})
+	}
+}
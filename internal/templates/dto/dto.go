@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"fmt"
+	notesentity "gonotes/internal/notes/entity"
+	tagentity "gonotes/internal/tags/entity"
+	"gonotes/internal/templates/entity"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const maxNameLength = 255
+
+type TemplateRequest struct {
+	Name    string             `json:"name"`
+	Title   string             `json:"title"`
+	Content string             `json:"content"`
+	Format  notesentity.Format `json:"format"`
+}
+
+func (t *TemplateRequest) Bind(r *http.Request) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.Name) > maxNameLength {
+		return fmt.Errorf("name must be at most %d bytes", maxNameLength)
+	}
+	if t.Content == "" {
+		return fmt.Errorf("content is required")
+	}
+	if t.Format != "" && !t.Format.Valid() {
+		return fmt.Errorf("format must be %s or %s", notesentity.FormatPlain, notesentity.FormatMarkdown)
+	}
+	tmpl := entity.Template{Title: t.Title, Content: t.Content}
+	return tmpl.Validate()
+}
+
+// NoteFromTemplateRequest fills in a template. Variables holds the values of
+// its custom variables; built-in ones such as {{date}} are filled in by the
+// server, in Timezone when one is given and UTC otherwise.
+type NoteFromTemplateRequest struct {
+	Variables  map[string]string `json:"variables"`
+	Timezone   string            `json:"timezone"`
+	Tags       []string          `json:"tags"`
+	NotebookID *int              `json:"notebook_id"`
+
+	Location *time.Location `json:"-"`
+}
+
+func (n *NoteFromTemplateRequest) Bind(r *http.Request) error {
+	for name := range n.Variables {
+		if err := entity.ValidName(name); err != nil {
+			return err
+		}
+	}
+
+	n.Location = time.UTC
+	if n.Timezone != "" {
+		loc, err := time.LoadLocation(n.Timezone)
+		if err != nil {
+			return fmt.Errorf("unknown timezone %q", n.Timezone)
+		}
+		n.Location = loc
+	}
+
+	tags, err := tagentity.NormalizeNames(n.Tags)
+	if err != nil {
+		return err
+	}
+	n.Tags = tags
+	return nil
+}
+
+type TemplateResponse struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Title     string             `json:"title"`
+	Content   string             `json:"content"`
+	Format    notesentity.Format `json:"format"`
+	Variables []string           `json:"variables"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func (t *TemplateResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewTemplateResponse(t *entity.Template) *TemplateResponse {
+	return &TemplateResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		Title:     t.Title,
+		Content:   t.Content,
+		Format:    t.Format,
+		Variables: t.Variables(),
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+func NewTemplateListResponse(templates []entity.Template) []render.Renderer {
+	list := make([]render.Renderer, len(templates))
+	for i := range templates {
+		list[i] = NewTemplateResponse(&templates[i])
+	}
+	return list
+}
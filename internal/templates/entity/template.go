@@ -0,0 +1,126 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	notesentity "gonotes/internal/notes/entity"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownVariable = errors.New("unknown variable")
+	ErrMissingVariable = errors.New("missing variable")
+	ErrReservedName    = errors.New("reserved variable name")
+)
+
+// Built-in variables, filled in when a note is created from a template.
+const (
+	VarDate      = "date"
+	VarTime      = "time"
+	VarUserEmail = "user.email"
+)
+
+var builtins = map[string]bool{VarDate: true, VarTime: true, VarUserEmail: true}
+
+// placeholder matches {{name}}, with optional spaces inside the braces.
+// Names with a dot belong to built-in namespaces such as user.
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*)\s*\}\}`)
+
+var customName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Template is the skeleton of a note. Its title and content may hold
+// placeholders like {{date}} or {{attendees}}; everything that is not a
+// built-in variable is supplied by the user when a note is created.
+type Template struct {
+	ID        int                `json:"id"`
+	UserID    int                `json:"user_id"`
+	Name      string             `json:"name"`
+	Title     string             `json:"title"`
+	Content   string             `json:"content"`
+	Format    notesentity.Format `json:"format"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+// Variables returns the custom variables used in the title and content,
+// sorted by name.
+func (t *Template) Variables() []string {
+	seen := map[string]bool{}
+	for _, text := range []string{t.Title, t.Content} {
+		for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+			if !builtins[m[1]] && !strings.Contains(m[1], ".") {
+				seen[m[1]] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks that every dotted placeholder is a built-in variable.
+func (t *Template) Validate() error {
+	for _, text := range []string{t.Title, t.Content} {
+		for _, m := range placeholder.FindAllStringSubmatch(text, -1) {
+			if strings.Contains(m[1], ".") && !builtins[m[1]] {
+				return fmt.Errorf("%w: %s", ErrUnknownVariable, m[1])
+			}
+		}
+	}
+	return nil
+}
+
+// ValidName reports whether name can be used for a custom variable.
+func ValidName(name string) error {
+	if builtins[name] {
+		return fmt.Errorf("%w: %s is built in", ErrReservedName, name)
+	}
+	if !customName.MatchString(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	return nil
+}
+
+// Builtins returns the values of the built-in variables for a note created
+// at now, in the time zone of now, by the user with the given email.
+func Builtins(now time.Time, email string) map[string]string {
+	return map[string]string{
+		VarDate:      now.Format(time.DateOnly),
+		VarTime:      now.Format("15:04"),
+		VarUserEmail: email,
+	}
+}
+
+// Expand returns the title and content with every placeholder replaced by
+// its value in vars. Values are inserted as they are, so placeholders inside
+// them are left alone. If a custom variable has no value, the error names
+// all the missing ones.
+func (t *Template) Expand(vars map[string]string) (title, content string, err error) {
+	var missing []string
+	for _, name := range t.Variables() {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", "", fmt.Errorf("%w: %s", ErrMissingVariable, strings.Join(missing, ", "))
+	}
+
+	expand := func(text string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			name := placeholder.FindStringSubmatch(match)[1]
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			return match
+		})
+	}
+	return expand(t.Title), expand(t.Content), nil
+}
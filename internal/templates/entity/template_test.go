@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Variables(t *testing.T) {
+	tmpl := &Template{
+		Title:   "Standup {{date}} {{ team }}",
+		Content: "By {{user.email}} at {{time}}\n{{team}}: {{yesterday}}, {{today}}\n{{ not a var }} {{1x}}",
+	}
+	assert.Equal(t, []string{"team", "today", "yesterday"}, tmpl.Variables())
+	assert.NoError(t, tmpl.Validate())
+
+	tmpl.Content = "{{user.name}}"
+	assert.ErrorIs(t, tmpl.Validate(), ErrUnknownVariable)
+}
+
+func Test_Expand(t *testing.T) {
+	tmpl := &Template{
+		Title:   "Incident {{date}}",
+		Content: "Reported by {{ user.email }} at {{time}}\nSeverity: {{severity}}\nSummary: {{summary}}",
+	}
+
+	vars := Builtins(time.Date(2026, 3, 4, 9, 5, 0, 0, time.UTC), "u@example.com")
+	vars["severity"] = "high"
+	vars["summary"] = "literal {{date}}"
+
+	title, content, err := tmpl.Expand(vars)
+	require.NoError(t, err)
+	assert.Equal(t, "Incident 2026-03-04", title)
+	assert.Equal(t, "Reported by u@example.com at 09:05\nSeverity: high\nSummary: literal {{date}}", content)
+
+	delete(vars, "severity")
+	delete(vars, "summary")
+	_, _, err = tmpl.Expand(vars)
+	assert.ErrorIs(t, err, ErrMissingVariable)
+	assert.ErrorContains(t, err, "severity, summary")
+}
+
+func Test_ValidName(t *testing.T) {
+	assert.NoError(t, ValidName("attendees"))
+	assert.NoError(t, ValidName("_x1"))
+	assert.ErrorIs(t, ValidName("date"), ErrReservedName)
+	assert.ErrorIs(t, ValidName("user.email"), ErrReservedName)
+	assert.Error(t, ValidName("user.name"))
+	assert.Error(t, ValidName("two words"))
+	assert.Error(t, ValidName(""))
+	assert.Error(t, ValidName("a}}{{b"))
+}
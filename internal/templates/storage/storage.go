@@ -0,0 +1,17 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/templates/entity"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+type TemplateRepository interface {
+	Create(t *entity.Template) (*entity.Template, error)
+	Get(id int, userID int) (*entity.Template, error)
+	List(userID int) ([]entity.Template, error)
+	// Update replaces the name, title, content and format of a template.
+	Update(id int, userID int, t *entity.Template) (*entity.Template, error)
+	Delete(id int, userID int) error
+}
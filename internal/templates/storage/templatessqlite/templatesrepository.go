@@ -0,0 +1,141 @@
+package templatessqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	notesentity "gonotes/internal/notes/entity"
+	"gonotes/internal/templates/entity"
+	"gonotes/internal/templates/storage"
+	"time"
+)
+
+const templateColumns = "id, user_id, name, title, content, format, created_at, updated_at"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTemplate(s scanner) (*entity.Template, error) {
+	var t entity.Template
+	if err := s.Scan(&t.ID, &t.UserID, &t.Name, &t.Title, &t.Content, &t.Format, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+type TemplateRepository struct {
+	db *sql.DB
+}
+
+func NewTemplateRepository(db *sql.DB) *TemplateRepository {
+	return &TemplateRepository{db: db}
+}
+
+func (r *TemplateRepository) Create(t *entity.Template) (*entity.Template, error) {
+	const op = "templates.sqlite.Create"
+
+	format := t.Format
+	if format == "" {
+		format = notesentity.FormatPlain
+	}
+
+	now := time.Now().UTC()
+	res, err := r.db.Exec("INSERT INTO templates(user_id, name, title, content, format, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		t.UserID, t.Name, t.Title, t.Content, format, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &entity.Template{
+		ID:        int(id),
+		UserID:    t.UserID,
+		Name:      t.Name,
+		Title:     t.Title,
+		Content:   t.Content,
+		Format:    format,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, nil
+}
+
+func (r *TemplateRepository) Get(id int, userID int) (*entity.Template, error) {
+	const op = "templates.sqlite.Get"
+
+	t, err := scanTemplate(r.db.QueryRow("SELECT "+templateColumns+" FROM templates WHERE id = ? AND user_id = ?", id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return t, nil
+}
+
+func (r *TemplateRepository) List(userID int) ([]entity.Template, error) {
+	const op = "templates.sqlite.List"
+
+	rows, err := r.db.Query("SELECT "+templateColumns+" FROM templates WHERE user_id = ? ORDER BY name, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	templates := []entity.Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		templates = append(templates, *t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return templates, nil
+}
+
+// Update keeps the format of the template when t.Format is empty.
+func (r *TemplateRepository) Update(id int, userID int, t *entity.Template) (*entity.Template, error) {
+	const op = "templates.sqlite.Update"
+
+	updated, err := scanTemplate(r.db.QueryRow(`
+		UPDATE templates SET name = ?, title = ?, content = ?, format = COALESCE(NULLIF(?, ''), format), updated_at = ?
+		WHERE id = ? AND user_id = ?
+		RETURNING `+templateColumns,
+		t.Name, t.Title, t.Content, t.Format, time.Now().UTC(), id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrTemplateNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return updated, nil
+}
+
+func (r *TemplateRepository) Delete(id int, userID int) error {
+	const op = "templates.sqlite.Delete"
+
+	res, err := r.db.Exec("DELETE FROM templates WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrTemplateNotFound
+	}
+
+	return nil
+}
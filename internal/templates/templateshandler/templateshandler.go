@@ -0,0 +1,306 @@
+package templateshandler
+
+import (
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	authstorage "gonotes/internal/auth/storage"
+	"gonotes/internal/events"
+	"gonotes/internal/middleware"
+	notesdto "gonotes/internal/notes/dto"
+	notesentity "gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/templates/dto"
+	"gonotes/internal/templates/entity"
+	"gonotes/internal/templates/storage"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.TemplateRepository
+	notes   notesstorage.NoteRepository
+	users   authstorage.UserRepository
+	events  events.Publisher
+}
+
+func NewHandler(log *slog.Logger, storage storage.TemplateRepository, notes notesstorage.NoteRepository, users authstorage.UserRepository, pub events.Publisher) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+		notes:   notes,
+		users:   users,
+		events:  pub,
+	}
+}
+
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.create"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	var req dto.TemplateRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	created, err := h.storage.Create(&entity.Template{UserID: userID, Name: req.Name, Title: req.Title, Content: req.Content, Format: req.Format})
+	if err != nil {
+		log.Error("failed to create template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, dto.NewTemplateResponse(created))
+
+	log.Info("template created", slog.Int("id", created.ID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.get"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	t, err := h.storage.Get(templateID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTemplateNotFound) {
+			log.Error("template not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewTemplateResponse(t))
+
+	log.Info("template retrieved", slog.Int("id", templateID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.List(userID)
+	if err != nil {
+		log.Error("failed to list templates", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewTemplateListResponse(list))
+
+	log.Info("templates retrieved", slog.Int("count", len(list)), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.update"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	var req dto.TemplateRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	updated, err := h.storage.Update(templateID, userID, &entity.Template{Name: req.Name, Title: req.Title, Content: req.Content, Format: req.Format})
+	if err != nil {
+		if errors.Is(err, storage.ErrTemplateNotFound) {
+			log.Error("template not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to update template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewTemplateResponse(updated))
+
+	log.Info("template updated", slog.Int("id", templateID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	templateID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	if err := h.storage.Delete(templateID, userID); err != nil {
+		if errors.Is(err, storage.ErrTemplateNotFound) {
+			log.Error("template not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to delete template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("template deleted", slog.Int("id", templateID), slog.Int("user_id", userID))
+}
+
+// FromTemplate serves POST /notes?template={id}, creating a note from one of
+// the user's templates. Requests without the template parameter go on to
+// next.
+func (h *Handler) FromTemplate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("template") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.createNote(w, r)
+	})
+}
+
+func (h *Handler) createNote(w http.ResponseWriter, r *http.Request) {
+	const op = "templates.handler.createNote"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	templateID, err := strconv.Atoi(r.URL.Query().Get("template"))
+	if err != nil {
+		log.Error("invalid template id", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid template id")))
+		return
+	}
+
+	// Templates without custom variables need no body at all.
+	var req dto.NoteFromTemplateRequest
+	if r.ContentLength == 0 {
+		err = req.Bind(r)
+	} else if err = render.Bind(r, &req); errors.Is(err, io.EOF) {
+		err = req.Bind(r)
+	}
+	if err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	t, err := h.storage.Get(templateID, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrTemplateNotFound) {
+			log.Error("template not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	user, err := h.users.GetByID(userID)
+	if err != nil {
+		log.Error("failed to get user", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	vars := entity.Builtins(time.Now().In(req.Location), user.Email)
+	for name, value := range req.Variables {
+		vars[name] = value
+	}
+
+	title, content, err := t.Expand(vars)
+	if err != nil {
+		log.Error("failed to fill in template", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	created, err := h.notes.Create(&notesentity.Note{UserID: userID, NotebookID: req.NotebookID, Title: title, Content: content, Format: t.Format, Tags: req.Tags})
+	if err != nil {
+		if errors.Is(err, notesstorage.ErrNotebookNotFound) {
+			log.Error("notebook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+			return
+		}
+		log.Error("failed to create note", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.events.Publish(events.NewNoteEvent(events.NoteCreated, userID, created))
+
+	w.Header().Set("ETag", api.ETag(created.Version))
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, notesdto.NewNoteResponse(created))
+
+	log.Info("note created from template", slog.Int("id", created.ID), slog.Int("template_id", templateID), slog.Int("user_id", userID))
+}
@@ -0,0 +1,234 @@
+package templateshandler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	authentity "gonotes/internal/auth/entity"
+	authstorage "gonotes/internal/auth/storage"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	notesdto "gonotes/internal/notes/dto"
+	notesentity "gonotes/internal/notes/entity"
+	notesstorage "gonotes/internal/notes/storage"
+	"gonotes/internal/notes/storage/storagetest"
+	"gonotes/internal/templates/dto"
+	"gonotes/internal/templates/entity"
+	"gonotes/internal/templates/storage"
+	"gonotes/internal/templates/templateshandler"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTemplatesRepo struct {
+	templates map[int]*entity.Template
+}
+
+func (m *mockTemplatesRepo) Create(t *entity.Template) (*entity.Template, error) {
+	t.ID = len(m.templates) + 1
+	m.templates[t.ID] = t
+	return t, nil
+}
+func (m *mockTemplatesRepo) Get(id, userID int) (*entity.Template, error) {
+	t, ok := m.templates[id]
+	if !ok || t.UserID != userID {
+		return nil, storage.ErrTemplateNotFound
+	}
+	return t, nil
+}
+func (m *mockTemplatesRepo) List(userID int) ([]entity.Template, error) {
+	list := []entity.Template{}
+	for _, t := range m.templates {
+		if t.UserID == userID {
+			list = append(list, *t)
+		}
+	}
+	return list, nil
+}
+func (m *mockTemplatesRepo) Update(id, userID int, t *entity.Template) (*entity.Template, error) {
+	if _, err := m.Get(id, userID); err != nil {
+		return nil, err
+	}
+	t.ID, t.UserID = id, userID
+	m.templates[id] = t
+	return t, nil
+}
+func (m *mockTemplatesRepo) Delete(id, userID int) error {
+	if _, err := m.Get(id, userID); err != nil {
+		return err
+	}
+	delete(m.templates, id)
+	return nil
+}
+
+// mockUsersRepo only implements GetByID.
+type mockUsersRepo struct {
+	authstorage.UserRepository
+}
+
+func (m *mockUsersRepo) GetByID(id int) (*authentity.User, error) {
+	return &authentity.User{ID: id, Email: "u@example.com"}, nil
+}
+
+// newHandler returns a handler whose notes repository copies the last note
+// created into the returned note.
+func newHandler() (*templateshandler.Handler, *notesentity.Note) {
+	templates := &mockTemplatesRepo{templates: map[int]*entity.Template{
+		1: {ID: 1, UserID: 1, Name: "Standup", Title: "Standup {{date}}", Content: "{{user.email}} at {{time}}\nYesterday: {{yesterday}}\nToday: {{today}}\n", Format: notesentity.FormatMarkdown},
+		2: {ID: 2, UserID: 1, Name: "Plain", Title: "Journal {{date}}", Content: "Dear diary"},
+		3: {ID: 3, UserID: 2, Name: "Other", Content: "not yours"},
+	}}
+	created := &notesentity.Note{}
+	notes := &storagetest.NoteRepository{CreateFunc: func(note *notesentity.Note) (*notesentity.Note, error) {
+		if note.NotebookID != nil {
+			return nil, notesstorage.ErrNotebookNotFound
+		}
+		*created = *note
+		n := *note
+		n.ID, n.Version = 10, 1
+		return &n, nil
+	}}
+	return templateshandler.NewHandler(slogdiscard.NewDiscardLogger(), templates, notes, &mockUsersRepo{}, events.NewBus()), created
+}
+
+func newRequest(method, target, id, body string) *http.Request {
+	req := httptest.NewRequest(method, target, bytes.NewBufferString(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{name: "created", body: `{"name":" Meeting ","title":"Meeting {{date}}","content":"Attendees: {{attendees}}"}`, expectedStatus: http.StatusCreated},
+		{name: "missing name", body: `{"content":"x"}`, expectedStatus: http.StatusBadRequest},
+		{name: "missing content", body: `{"name":"x"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid format", body: `{"name":"x","content":"x","format":"rtf"}`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown built-in", body: `{"name":"x","content":"{{user.name}}"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, _ := newHandler()
+
+			rec := httptest.NewRecorder()
+			h.Create(rec, newRequest(http.MethodPost, "/templates", "", tc.body))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusCreated {
+				var resp dto.TemplateResponse
+				_ = json.NewDecoder(rec.Body).Decode(&resp)
+				assert.Equal(t, "Meeting", resp.Name)
+				assert.Equal(t, []string{"attendees"}, resp.Variables)
+			}
+		})
+	}
+}
+
+func Test_Get(t *testing.T) {
+	h, _ := newHandler()
+
+	rec := httptest.NewRecorder()
+	h.Get(rec, newRequest(http.MethodGet, "/templates/1", "1", ""))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var resp dto.TemplateResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	assert.Equal(t, []string{"today", "yesterday"}, resp.Variables)
+
+	rec = httptest.NewRecorder()
+	h.Get(rec, newRequest(http.MethodGet, "/templates/3", "3", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_Delete(t *testing.T) {
+	h, _ := newHandler()
+
+	rec := httptest.NewRecorder()
+	h.Delete(rec, newRequest(http.MethodDelete, "/templates/2", "2", ""))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	h.Delete(rec, newRequest(http.MethodDelete, "/templates/2", "2", ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func Test_FromTemplate(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		body            string
+		expectedStatus  int
+		expectedTitle   string
+		expectedContent string
+	}{
+		{
+			name:            "filled in",
+			query:           "?template=1",
+			body:            `{"variables":{"yesterday":"reviews","today":"{{date}}"},"tags":["Work"],"timezone":"Europe/Berlin"}`,
+			expectedStatus:  http.StatusCreated,
+			expectedTitle:   `^Standup \d{4}-\d{2}-\d{2}$`,
+			expectedContent: `^u@example.com at \d{2}:\d{2}\nYesterday: reviews\nToday: \{\{date\}\}\n$`,
+		},
+		{
+			name:            "no body",
+			query:           "?template=2",
+			expectedStatus:  http.StatusCreated,
+			expectedTitle:   `^Journal \d{4}-\d{2}-\d{2}$`,
+			expectedContent: `^Dear diary$`,
+		},
+		{name: "missing variable", query: "?template=1", body: `{"variables":{"today":"x"}}`, expectedStatus: http.StatusBadRequest},
+		{name: "built-in variable", query: "?template=2", body: `{"variables":{"date":"x"}}`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown timezone", query: "?template=2", body: `{"timezone":"Mars/Olympus"}`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid template id", query: "?template=x", expectedStatus: http.StatusBadRequest},
+		{name: "someone else's template", query: "?template=3", expectedStatus: http.StatusNotFound},
+		{name: "notebook not found", query: "?template=2", body: `{"notebook_id":5}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, created := newHandler()
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Fatal("request with a template must not reach the notes handler")
+			})
+
+			rec := httptest.NewRecorder()
+			h.FromTemplate(next).ServeHTTP(rec, newRequest(http.MethodPost, "/notes"+tc.query, "", tc.body))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if rec.Code == http.StatusCreated {
+				var resp notesdto.NoteResponse
+				_ = json.NewDecoder(rec.Body).Decode(&resp)
+				assert.Regexp(t, regexp.MustCompile(tc.expectedTitle), resp.Title)
+				assert.Regexp(t, regexp.MustCompile(tc.expectedContent), resp.Content)
+				assert.Equal(t, `"1"`, rec.Header().Get("ETag"))
+				assert.Equal(t, 1, created.UserID)
+			}
+		})
+	}
+}
+
+func Test_FromTemplatePassesThrough(t *testing.T) {
+	h, _ := newHandler()
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	h.FromTemplate(next).ServeHTTP(httptest.NewRecorder(), newRequest(http.MethodPost, "/notes", "", `{"content":"x"}`))
+	assert.True(t, called)
+}
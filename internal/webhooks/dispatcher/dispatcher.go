@@ -0,0 +1,53 @@
+// Package dispatcher turns note events into queued webhook deliveries. The
+// worker sends them; queueing first means a slow or failing endpoint never
+// holds up the request that changed the note.
+package dispatcher
+
+import (
+	"encoding/json"
+	"gonotes/internal/events"
+	"log/slog"
+	"time"
+)
+
+type Enqueuer interface {
+	Enqueue(t events.Type, noteID int, payload []byte, at time.Time) (int, error)
+}
+
+type Dispatcher struct {
+	log   *slog.Logger
+	queue Enqueuer
+	wake  func()
+}
+
+// New returns a dispatcher that calls wake whenever it queued deliveries so
+// the worker can send them without waiting for its next poll.
+func New(log *slog.Logger, queue Enqueuer, wake func()) *Dispatcher {
+	return &Dispatcher{
+		log:   log,
+		queue: queue,
+		wake:  wake,
+	}
+}
+
+// Handle queues ev for the webhooks subscribed to it. It is meant to be
+// subscribed to an events.Bus.
+func (d *Dispatcher) Handle(ev events.Event) {
+	const op = "webhooks.dispatcher.handle"
+	log := d.log.With(slog.String("op", op), slog.String("event", string(ev.Type)), slog.Int("note_id", ev.Note.ID))
+
+	payload, err := json.Marshal(events.NewPayload(ev))
+	if err != nil {
+		log.Error("failed to encode payload", slog.Any("err", err))
+		return
+	}
+
+	n, err := d.queue.Enqueue(ev.Type, ev.Note.ID, payload, ev.OccurredAt)
+	if err != nil {
+		log.Error("failed to queue deliveries", slog.Any("err", err))
+		return
+	}
+	if n > 0 {
+		d.wake()
+	}
+}
@@ -0,0 +1,71 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/notes/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockQueue struct {
+	typ     events.Type
+	noteID  int
+	payload []byte
+	at      time.Time
+	queued  int
+	err     error
+}
+
+func (m *mockQueue) Enqueue(t events.Type, noteID int, payload []byte, at time.Time) (int, error) {
+	m.typ, m.noteID, m.payload, m.at = t, noteID, payload, at
+	return m.queued, m.err
+}
+
+func Test_Handle(t *testing.T) {
+	at := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	ev := events.Event{
+		Type:       events.NoteUpdated,
+		UserID:     2,
+		Note:       &entity.Note{ID: 7, UserID: 1, Title: "Plan", Content: "text", Format: entity.FormatPlain, Version: 3},
+		OccurredAt: at,
+	}
+
+	tests := []struct {
+		name       string
+		queued     int
+		err        error
+		expectWake bool
+	}{
+		{name: "queued", queued: 2, expectWake: true},
+		{name: "no subscribers", queued: 0},
+		{name: "queue error", err: errors.New("db error")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &mockQueue{queued: tc.queued, err: tc.err}
+			woken := false
+			New(slogdiscard.NewDiscardLogger(), q, func() { woken = true }).Handle(ev)
+
+			assert.Equal(t, tc.expectWake, woken)
+			assert.Equal(t, events.NoteUpdated, q.typ)
+			assert.Equal(t, 7, q.noteID)
+			assert.Equal(t, at, q.at)
+
+			var p events.Payload
+			require.NoError(t, json.Unmarshal(q.payload, &p))
+			assert.Equal(t, events.NoteUpdated, p.Event)
+			assert.Equal(t, 2, p.UserID)
+			assert.Equal(t, 1, p.OwnerID)
+			assert.Equal(t, "Plan", p.Note.Title)
+			assert.Equal(t, 3, p.Note.Version)
+			assert.Equal(t, []string{}, p.Note.Tags)
+		})
+	}
+}
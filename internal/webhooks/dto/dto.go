@@ -0,0 +1,131 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"gonotes/internal/events"
+	"gonotes/internal/webhooks/entity"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/go-chi/render"
+)
+
+const maxURLLength = 2048
+
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Events is optional; a webhook without it receives every event type.
+	Events []events.Type `json:"events"`
+}
+
+func (wr *WebhookRequest) Bind(r *http.Request) error {
+	if wr.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if len(wr.URL) > maxURLLength {
+		return fmt.Errorf("url must be at most %d bytes", maxURLLength)
+	}
+	u, err := url.Parse(wr.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	if len(wr.Events) == 0 {
+		wr.Events = slices.Clone(events.Types)
+		return nil
+	}
+	var types []events.Type
+	for _, t := range wr.Events {
+		if !t.Valid() {
+			return fmt.Errorf("unknown event %q", t)
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	wr.Events = types
+	return nil
+}
+
+type WebhookResponse struct {
+	ID     int           `json:"id"`
+	URL    string        `json:"url"`
+	Events []events.Type `json:"events"`
+	// Secret is only returned when the webhook is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (wr *WebhookResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewWebhookResponse(wh *entity.Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:        wh.ID,
+		URL:       wh.URL,
+		Events:    wh.Events,
+		CreatedAt: wh.CreatedAt,
+	}
+}
+
+func NewWebhookListResponse(webhooks []entity.Webhook) []render.Renderer {
+	list := make([]render.Renderer, len(webhooks))
+	for i := range webhooks {
+		list[i] = NewWebhookResponse(&webhooks[i])
+	}
+	return list
+}
+
+type AttemptResponse struct {
+	ResponseCode int       `json:"response_code"`
+	Error        string    `json:"error,omitempty"`
+	DurationMS   int64     `json:"duration_ms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type DeliveryResponse struct {
+	ID         int               `json:"id"`
+	Event      events.Type       `json:"event"`
+	Status     entity.Status     `json:"status"`
+	Attempts   []AttemptResponse `json:"attempts"`
+	Payload    json.RawMessage   `json:"payload"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at"`
+}
+
+func (dr *DeliveryResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	return nil
+}
+
+func NewDeliveryResponse(d *entity.Delivery) *DeliveryResponse {
+	attempts := make([]AttemptResponse, len(d.Log))
+	for i, a := range d.Log {
+		attempts[i] = AttemptResponse{
+			ResponseCode: a.ResponseCode,
+			Error:        a.Error,
+			DurationMS:   a.Duration.Milliseconds(),
+			CreatedAt:    a.CreatedAt,
+		}
+	}
+	return &DeliveryResponse{
+		ID:         d.ID,
+		Event:      d.Event,
+		Status:     d.Status,
+		Attempts:   attempts,
+		Payload:    json.RawMessage(d.Payload),
+		CreatedAt:  d.CreatedAt,
+		FinishedAt: d.FinishedAt,
+	}
+}
+
+func NewDeliveryListResponse(deliveries []entity.Delivery) []render.Renderer {
+	list := make([]render.Renderer, len(deliveries))
+	for i := range deliveries {
+		list[i] = NewDeliveryResponse(&deliveries[i])
+	}
+	return list
+}
@@ -0,0 +1,59 @@
+package entity
+
+import (
+	"gonotes/internal/events"
+	"time"
+)
+
+// Webhook is an endpoint a user registered to receive events about their
+// notes. Secret signs every delivery.
+type Webhook struct {
+	ID        int           `json:"id"`
+	UserID    int           `json:"user_id"`
+	URL       string        `json:"url"`
+	Secret    string        `json:"-"`
+	Events    []events.Type `json:"events"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed marks a delivery the worker gave up on.
+	StatusFailed Status = "failed"
+)
+
+// Delivery is one event queued for one webhook. Payload is the request body
+// and stays the same across attempts.
+type Delivery struct {
+	ID         int         `json:"id"`
+	WebhookID  int         `json:"webhook_id"`
+	Event      events.Type `json:"event"`
+	Payload    []byte      `json:"-"`
+	Status     Status      `json:"status"`
+	Attempts   int         `json:"attempts"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at"`
+	Log        []Attempt   `json:"log"`
+}
+
+// Attempt records one request made for a delivery. ResponseCode is 0 when no
+// response was received.
+type Attempt struct {
+	ResponseCode int           `json:"response_code"`
+	Error        string        `json:"error"`
+	Duration     time.Duration `json:"duration"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// Due is a delivery claimed by the worker, with the endpoint to send it to.
+// LeaseUntil is when the claim lapses and the delivery becomes due again if
+// no attempt has been recorded.
+type Due struct {
+	Delivery
+	URL        string
+	Secret     string
+	LeaseUntil time.Time
+}
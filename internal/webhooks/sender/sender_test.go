@@ -0,0 +1,78 @@
+package sender
+
+import (
+	"context"
+	"gonotes/internal/events"
+	"gonotes/internal/webhooks/entity"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"event":"note.created"}`)
+	sig := Sign("secret", now, body)
+
+	assert.Regexp(t, `^t=1767225600,v1=[0-9a-f]{64}$`, sig)
+	assert.NoError(t, Verify("secret", sig, body, 5*time.Minute, now.Add(time.Minute)))
+
+	assert.ErrorIs(t, Verify("other", sig, body, 5*time.Minute, now), ErrBadSignature)
+	assert.ErrorIs(t, Verify("secret", sig, []byte(`{"event":"note.deleted"}`), 5*time.Minute, now), ErrBadSignature)
+	assert.ErrorIs(t, Verify("secret", sig, body, 5*time.Minute, now.Add(10*time.Minute)), ErrBadSignature, "replayed too late")
+	assert.ErrorIs(t, Verify("secret", "v1=abc", body, 5*time.Minute, now), ErrBadSignature)
+}
+
+func Test_Send(t *testing.T) {
+	var (
+		header  http.Header
+		body    []byte
+		respond = http.StatusOK
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		header = r.Header
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(respond)
+	}))
+	defer srv.Close()
+
+	s := New(time.Second)
+	d := &entity.Due{
+		Delivery: entity.Delivery{ID: 12, Event: events.NoteUpdated, Payload: []byte(`{"event":"note.updated"}`)},
+		URL:      srv.URL,
+		Secret:   "secret",
+	}
+
+	code, err := s.Send(context.Background(), d)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, d.Payload, body)
+	assert.Equal(t, "application/json", header.Get("Content-Type"))
+	assert.Equal(t, "note.updated", header.Get(HeaderEvent))
+	assert.Equal(t, "12", header.Get(HeaderDelivery))
+	assert.NoError(t, Verify("secret", header.Get(HeaderSignature), body, time.Minute, time.Now()))
+
+	respond = http.StatusInternalServerError
+	code, err = s.Send(context.Background(), d)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	d.URL = srv.URL + "/moved"
+	code, err = s.Send(context.Background(), d)
+	assert.Error(t, err, "redirects are not followed")
+	assert.Equal(t, http.StatusFound, code)
+
+	d.URL = "http://127.0.0.1:1"
+	code, err = s.Send(context.Background(), d)
+	assert.Error(t, err)
+	assert.Zero(t, code)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/webhooks/entity"
+	"time"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	// ErrLeaseLost means the delivery was claimed again, or its webhook
+	// deleted, after it was claimed, so the attempt was not recorded.
+	ErrLeaseLost = errors.New("delivery is no longer claimed")
+)
+
+type WebhookRepository interface {
+	Create(w *entity.Webhook) (*entity.Webhook, error)
+	Get(id int, userID int) (*entity.Webhook, error)
+	List(userID int) ([]entity.Webhook, error)
+	// Delete removes the webhook along with its pending deliveries and log.
+	Delete(id int, userID int) error
+	// Deliveries returns the webhook's most recent deliveries, newest first,
+	// each with its attempts.
+	Deliveries(id int, userID int, limit int) ([]entity.Delivery, error)
+}
+
+// Queue is the part of the repository the worker and dispatcher drive. A
+// claimed delivery is hidden from other claims until its lease runs out; an
+// attempt must be recorded by then or it is sent again.
+type Queue interface {
+	// Enqueue queues payload for every webhook subscribed to t that belongs
+	// to the owner of the note or a user it is shared with, and returns how
+	// many deliveries were queued.
+	Enqueue(t events.Type, noteID int, payload []byte, at time.Time) (int, error)
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error)
+	// RecordAttempt logs an attempt and settles the delivery: it succeeded
+	// when the attempt has no error, is retried at retryAt otherwise, and is
+	// given up on when retryAt is nil.
+	RecordAttempt(d *entity.Due, a *entity.Attempt, retryAt *time.Time) error
+	// Prune deletes finished deliveries older than before.
+	Prune(before time.Time) (int, error)
+}
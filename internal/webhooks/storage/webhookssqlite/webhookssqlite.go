@@ -0,0 +1,396 @@
+package webhookssqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"gonotes/internal/events"
+	"gonotes/internal/webhooks/entity"
+	"gonotes/internal/webhooks/storage"
+	"strings"
+	"time"
+)
+
+const (
+	webhookColumns  = "id, user_id, url, secret, events, created_at"
+	deliveryColumns = "d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.created_at, d.finished_at"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) Create(w *entity.Webhook) (*entity.Webhook, error) {
+	const op = "webhooks.sqlite.Create"
+
+	created := *w
+	created.CreatedAt = time.Now().UTC()
+
+	res, err := r.db.Exec("INSERT INTO webhooks (user_id, url, secret, events, created_at) VALUES (?, ?, ?, ?, ?)",
+		created.UserID, created.URL, created.Secret, joinEvents(created.Events), created.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	created.ID = int(id)
+
+	return &created, nil
+}
+
+func (r *WebhookRepository) Get(id int, userID int) (*entity.Webhook, error) {
+	const op = "webhooks.sqlite.Get"
+
+	w, err := get(r.db, id, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return w, nil
+}
+
+func (r *WebhookRepository) List(userID int) ([]entity.Webhook, error) {
+	const op = "webhooks.sqlite.List"
+
+	rows, err := r.db.Query("SELECT "+webhookColumns+" FROM webhooks WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	webhooks := []entity.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webhooks = append(webhooks, *w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return webhooks, nil
+}
+
+func (r *WebhookRepository) Delete(id int, userID int) error {
+	const op = "webhooks.sqlite.Delete"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM webhooks WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrWebhookNotFound
+	}
+
+	if _, err := tx.Exec("DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE webhook_id = ?)", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := tx.Exec("DELETE FROM webhook_deliveries WHERE webhook_id = ?", id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Deliveries(id int, userID int, limit int) ([]entity.Delivery, error) {
+	const op = "webhooks.sqlite.Deliveries"
+
+	if _, err := get(r.db, id, userID); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query("SELECT "+deliveryColumns+" FROM webhook_deliveries d WHERE d.webhook_id = ? ORDER BY d.id DESC LIMIT ?", id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	deliveries := []entity.Delivery{}
+	index := map[int]int{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		d.Log = []entity.Attempt{}
+		index[d.ID] = len(deliveries)
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(deliveries) == 0 {
+		return deliveries, nil
+	}
+
+	oldest := deliveries[len(deliveries)-1].ID
+	attempts, err := r.db.Query(`SELECT a.delivery_id, a.response_code, a.error, a.duration_ms, a.created_at
+		FROM webhook_attempts a JOIN webhook_deliveries d ON d.id = a.delivery_id
+		WHERE d.webhook_id = ? AND d.id >= ?
+		ORDER BY a.id`, id, oldest)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer attempts.Close()
+
+	for attempts.Next() {
+		var (
+			deliveryID int
+			durationMS int64
+			a          entity.Attempt
+		)
+		if err := attempts.Scan(&deliveryID, &a.ResponseCode, &a.Error, &durationMS, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		a.Duration = time.Duration(durationMS) * time.Millisecond
+		if i, ok := index[deliveryID]; ok {
+			deliveries[i].Log = append(deliveries[i].Log, a)
+		}
+	}
+	if err := attempts.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepository) Enqueue(t events.Type, noteID int, payload []byte, at time.Time) (int, error) {
+	const op = "webhooks.sqlite.Enqueue"
+
+	at = at.UTC()
+	res, err := r.db.Exec(`INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ? FROM webhooks
+		WHERE (',' || events || ',') LIKE ?
+			AND (user_id IN (SELECT user_id FROM notes WHERE id = ?) OR user_id IN (SELECT user_id FROM note_shares WHERE note_id = ?))`,
+		t, string(payload), at, at, "%,"+string(t)+",%", noteID, noteID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(n), nil
+}
+
+// ClaimDue leases up to limit pending deliveries whose next attempt is due,
+// oldest first.
+func (r *WebhookRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error) {
+	const op = "webhooks.sqlite.ClaimDue"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT `+deliveryColumns+`, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?`, entity.StatusPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	due := []entity.Due{}
+	for rows.Next() {
+		var d entity.Due
+		delivery, err := scanDelivery(rows, &d.URL, &d.Secret)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		d.Delivery = *delivery
+		due = append(due, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	leaseUntil := now.Add(lease).UTC()
+	for i := range due {
+		_, err := tx.Exec("UPDATE webhook_deliveries SET next_attempt_at = ?, attempts = attempts + 1 WHERE id = ?", leaseUntil, due[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		due[i].Attempts++
+		due[i].LeaseUntil = leaseUntil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return due, nil
+}
+
+func (r *WebhookRepository) RecordAttempt(d *entity.Due, a *entity.Attempt, retryAt *time.Time) error {
+	const op = "webhooks.sqlite.RecordAttempt"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	at := a.CreatedAt.UTC()
+	var res sql.Result
+	switch {
+	case a.Error == "":
+		res, err = tx.Exec("UPDATE webhook_deliveries SET status = ?, finished_at = ? WHERE id = ? AND status = ? AND next_attempt_at = ?",
+			entity.StatusSucceeded, at, d.ID, entity.StatusPending, d.LeaseUntil)
+	case retryAt != nil:
+		res, err = tx.Exec("UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id = ? AND status = ? AND next_attempt_at = ?",
+			retryAt.UTC(), d.ID, entity.StatusPending, d.LeaseUntil)
+	default:
+		res, err = tx.Exec("UPDATE webhook_deliveries SET status = ?, finished_at = ? WHERE id = ? AND status = ? AND next_attempt_at = ?",
+			entity.StatusFailed, at, d.ID, entity.StatusPending, d.LeaseUntil)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n == 0 {
+		return storage.ErrLeaseLost
+	}
+
+	_, err = tx.Exec("INSERT INTO webhook_attempts (delivery_id, response_code, error, duration_ms, created_at) VALUES (?, ?, ?, ?, ?)",
+		d.ID, a.ResponseCode, a.Error, a.Duration.Milliseconds(), at)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) Prune(before time.Time) (int, error) {
+	const op = "webhooks.sqlite.Prune"
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	before = before.UTC()
+	_, err = tx.Exec("DELETE FROM webhook_attempts WHERE delivery_id IN (SELECT id FROM webhook_deliveries WHERE finished_at < ?)", before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	res, err := tx.Exec("DELETE FROM webhook_deliveries WHERE finished_at < ?", before)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return int(n), nil
+}
+
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func get(q querier, id int, userID int) (*entity.Webhook, error) {
+	w, err := scanWebhook(q.QueryRow("SELECT "+webhookColumns+" FROM webhooks WHERE id = ? AND user_id = ?", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, storage.ErrWebhookNotFound
+	}
+	return w, err
+}
+
+func scanWebhook(s scanner) (*entity.Webhook, error) {
+	var (
+		w      entity.Webhook
+		events string
+	)
+	if err := s.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &events, &w.CreatedAt); err != nil {
+		return nil, err
+	}
+	w.Events = splitEvents(events)
+	return &w, nil
+}
+
+// scanDelivery scans a row starting with deliveryColumns; extra receives any
+// trailing columns.
+func scanDelivery(s scanner, extra ...any) (*entity.Delivery, error) {
+	var (
+		d          entity.Delivery
+		payload    string
+		finishedAt sql.NullTime
+	)
+	dest := append([]any{&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &d.CreatedAt, &finishedAt}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return nil, err
+	}
+	d.Payload = []byte(payload)
+	if finishedAt.Valid {
+		d.FinishedAt = &finishedAt.Time
+	}
+	return &d, nil
+}
+
+// Event types are stored comma separated so Enqueue can match them with
+// LIKE.
+func joinEvents(types []events.Type) string {
+	s := make([]string, len(types))
+	for i, t := range types {
+		s[i] = string(t)
+	}
+	return strings.Join(s, ",")
+}
+
+func splitEvents(s string) []events.Type {
+	types := []events.Type{}
+	for _, t := range strings.Split(s, ",") {
+		if t != "" {
+			types = append(types, events.Type(t))
+		}
+	}
+	return types
+}
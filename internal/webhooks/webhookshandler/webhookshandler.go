@@ -0,0 +1,228 @@
+package webhookshandler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gonotes/internal/api"
+	"gonotes/internal/middleware"
+	"gonotes/internal/webhooks/dto"
+	"gonotes/internal/webhooks/entity"
+	"gonotes/internal/webhooks/storage"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultDeliveries = 50
+	maxDeliveries     = 200
+)
+
+type Handler struct {
+	log     *slog.Logger
+	storage storage.WebhookRepository
+}
+
+func NewHandler(log *slog.Logger, storage storage.WebhookRepository) *Handler {
+	return &Handler{
+		log:     log,
+		storage: storage,
+	}
+}
+
+// Create registers a webhook and returns its signing secret. The secret is
+// not shown again.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
+	const op = "webhooks.handler.create"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	var req dto.WebhookRequest
+	if err := render.Bind(r, &req); err != nil {
+		log.Error("invalid request body", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, err))
+		return
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		log.Error("failed to generate secret", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	created, err := h.storage.Create(&entity.Webhook{UserID: userID, URL: req.URL, Secret: secret, Events: req.Events})
+	if err != nil {
+		log.Error("failed to create webhook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	resp := dto.NewWebhookResponse(created)
+	resp.Secret = created.Secret
+
+	render.Status(r, http.StatusCreated)
+	render.Render(w, r, resp)
+
+	log.Info("webhook created", slog.Int("id", created.ID), slog.Int("user_id", userID))
+}
+
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
+	const op = "webhooks.handler.get"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	wh, err := h.storage.Get(id, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Error("webhook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to get webhook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.Render(w, r, dto.NewWebhookResponse(wh))
+
+	log.Info("webhook retrieved", slog.Int("id", id))
+}
+
+func (h *Handler) GetAll(w http.ResponseWriter, r *http.Request) {
+	const op = "webhooks.handler.getAll"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	list, err := h.storage.List(userID)
+	if err != nil {
+		log.Error("failed to list webhooks", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewWebhookListResponse(list))
+
+	log.Info("webhooks retrieved", slog.Int("count", len(list)))
+}
+
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
+	const op = "webhooks.handler.delete"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	if err := h.storage.Delete(id, userID); err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Error("webhook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to delete webhook", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.NoContent(w, r)
+
+	log.Info("webhook deleted", slog.Int("id", id), slog.Int("user_id", userID))
+}
+
+// Deliveries lists the webhook's most recent deliveries with every attempt
+// made for them. The limit query parameter caps how many are returned.
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	const op = "webhooks.handler.deliveries"
+	log := h.log.With(slog.String("op", op))
+
+	val := r.Context().Value(middleware.UserIDKey)
+	userID, ok := val.(int)
+	if !ok {
+		render.Render(w, r, api.NewErrResponse(http.StatusUnauthorized, fmt.Errorf("unauthorized")))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error("invalid id format", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("invalid id format")))
+		return
+	}
+
+	limit := defaultDeliveries
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxDeliveries {
+			render.Render(w, r, api.NewErrResponse(http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxDeliveries)))
+			return
+		}
+	}
+
+	list, err := h.storage.Deliveries(id, userID, limit)
+	if err != nil {
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Error("webhook not found", slog.Any("err", err))
+			render.Render(w, r, api.NewErrResponse(http.StatusNotFound, err))
+			return
+		}
+		log.Error("failed to list deliveries", slog.Any("err", err))
+		render.Render(w, r, api.NewErrResponse(http.StatusInternalServerError, err))
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.RenderList(w, r, dto.NewDeliveryListResponse(list))
+
+	log.Info("webhook deliveries retrieved", slog.Int("id", id), slog.Int("count", len(list)))
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,234 @@
+package webhookshandler_test
+
+import (
+	"context"
+	"encoding/json"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/middleware"
+	"gonotes/internal/webhooks/dto"
+	"gonotes/internal/webhooks/entity"
+	"gonotes/internal/webhooks/storage"
+	"gonotes/internal/webhooks/webhookshandler"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWebhooksRepo struct {
+	createFunc     func(w *entity.Webhook) (*entity.Webhook, error)
+	getFunc        func(id, userID int) (*entity.Webhook, error)
+	listFunc       func(userID int) ([]entity.Webhook, error)
+	deleteFunc     func(id, userID int) error
+	deliveriesFunc func(id, userID, limit int) ([]entity.Delivery, error)
+}
+
+func (m *mockWebhooksRepo) Create(w *entity.Webhook) (*entity.Webhook, error) {
+	return m.createFunc(w)
+}
+func (m *mockWebhooksRepo) Get(id, userID int) (*entity.Webhook, error) {
+	return m.getFunc(id, userID)
+}
+func (m *mockWebhooksRepo) List(userID int) ([]entity.Webhook, error) {
+	return m.listFunc(userID)
+}
+func (m *mockWebhooksRepo) Delete(id, userID int) error {
+	return m.deleteFunc(id, userID)
+}
+func (m *mockWebhooksRepo) Deliveries(id, userID, limit int) ([]entity.Delivery, error) {
+	return m.deliveriesFunc(id, userID, limit)
+}
+
+func newRequest(method, target, body, id string, withUser bool) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	if withUser {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDKey, 1))
+	}
+	return req
+}
+
+func Test_Create(t *testing.T) {
+	tests := []struct {
+		name           string
+		withUser       bool
+		body           string
+		expectedStatus int
+		expectedEvents []events.Type
+	}{
+		{
+			name:           "unauthorized",
+			body:           `{"url":"https://example.com/hook"}`,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "all events by default",
+			withUser:       true,
+			body:           `{"url":"https://example.com/hook"}`,
+			expectedStatus: http.StatusCreated,
+			expectedEvents: []events.Type{events.NoteCreated, events.NoteUpdated, events.NoteDeleted},
+		},
+		{
+			name:           "chosen events",
+			withUser:       true,
+			body:           `{"url":"http://tools.internal:8080/gonotes","events":["note.deleted","note.deleted"]}`,
+			expectedStatus: http.StatusCreated,
+			expectedEvents: []events.Type{events.NoteDeleted},
+		},
+		{
+			name:           "unknown event",
+			withUser:       true,
+			body:           `{"url":"https://example.com/hook","events":["note.viewed"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing url",
+			withUser:       true,
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "relative url",
+			withUser:       true,
+			body:           `{"url":"/hook"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported scheme",
+			withUser:       true,
+			body:           `{"url":"ftp://example.com/hook"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stored *entity.Webhook
+			repo := &mockWebhooksRepo{createFunc: func(w *entity.Webhook) (*entity.Webhook, error) {
+				created := *w
+				created.ID = 4
+				stored = &created
+				return &created, nil
+			}}
+			h := webhookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+			rr := httptest.NewRecorder()
+			h.Create(rr, newRequest(http.MethodPost, "/webhooks", tc.body, "", tc.withUser))
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.expectedStatus != http.StatusCreated {
+				return
+			}
+
+			var resp dto.WebhookResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, 4, resp.ID)
+			assert.Equal(t, tc.expectedEvents, resp.Events)
+			assert.Len(t, resp.Secret, 64)
+			assert.Equal(t, stored.Secret, resp.Secret)
+			assert.Equal(t, 1, stored.UserID)
+		})
+	}
+}
+
+func Test_GetHidesSecret(t *testing.T) {
+	repo := &mockWebhooksRepo{getFunc: func(id, userID int) (*entity.Webhook, error) {
+		if id != 4 {
+			return nil, storage.ErrWebhookNotFound
+		}
+		return &entity.Webhook{ID: 4, UserID: userID, URL: "https://example.com/hook", Secret: "s3cret", Events: []events.Type{events.NoteCreated}}, nil
+	}}
+	h := webhookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+	rr := httptest.NewRecorder()
+	h.Get(rr, newRequest(http.MethodGet, "/webhooks/4", "", "4", true))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "s3cret")
+
+	rr = httptest.NewRecorder()
+	h.Get(rr, newRequest(http.MethodGet, "/webhooks/5", "", "5", true))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func Test_Delete(t *testing.T) {
+	repo := &mockWebhooksRepo{deleteFunc: func(id, userID int) error {
+		if id != 4 {
+			return storage.ErrWebhookNotFound
+		}
+		return nil
+	}}
+	h := webhookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, newRequest(http.MethodDelete, "/webhooks/4", "", "4", true))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Delete(rr, newRequest(http.MethodDelete, "/webhooks/5", "", "5", true))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	h.Delete(rr, newRequest(http.MethodDelete, "/webhooks/x", "", "x", true))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func Test_Deliveries(t *testing.T) {
+	tests := []struct {
+		name           string
+		id             string
+		query          string
+		expectedStatus int
+		expectedLimit  int
+	}{
+		{name: "default limit", id: "4", expectedStatus: http.StatusOK, expectedLimit: 50},
+		{name: "custom limit", id: "4", query: "?limit=5", expectedStatus: http.StatusOK, expectedLimit: 5},
+		{name: "limit too large", id: "4", query: "?limit=1000", expectedStatus: http.StatusBadRequest},
+		{name: "not found", id: "5", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var limit int
+			repo := &mockWebhooksRepo{deliveriesFunc: func(id, userID, l int) ([]entity.Delivery, error) {
+				if id != 4 {
+					return nil, storage.ErrWebhookNotFound
+				}
+				limit = l
+				return []entity.Delivery{{
+					ID:      9,
+					Event:   events.NoteCreated,
+					Status:  entity.StatusPending,
+					Payload: []byte(`{"event":"note.created"}`),
+					Log:     []entity.Attempt{{ResponseCode: 500, Error: "unexpected status 500 Internal Server Error"}},
+				}}, nil
+			}}
+			h := webhookshandler.NewHandler(slogdiscard.NewDiscardLogger(), repo)
+
+			rr := httptest.NewRecorder()
+			h.Deliveries(rr, newRequest(http.MethodGet, "/webhooks/"+tc.id+"/deliveries"+tc.query, "", tc.id, true))
+
+			require.Equal(t, tc.expectedStatus, rr.Code, rr.Body.String())
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+			assert.Equal(t, tc.expectedLimit, limit)
+
+			var resp []dto.DeliveryResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			require.Len(t, resp, 1)
+			assert.JSONEq(t, `{"event":"note.created"}`, string(resp[0].Payload))
+			require.Len(t, resp[0].Attempts, 1)
+			assert.Equal(t, 500, resp[0].Attempts[0].ResponseCode)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package worker sends queued webhook deliveries. Deliveries are claimed from
+// storage with a lease before they are sent, so one cut short by a crash or
+// restart is sent again once the lease runs out. Receivers should use the
+// delivery header to drop duplicates.
+package worker
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/webhooks/entity"
+	"gonotes/internal/webhooks/storage"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is tried before it is marked
+	// failed.
+	MaxAttempts = 8
+
+	batchSize   = 100
+	concurrency = 8
+	minBackoff  = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+type Sender interface {
+	Send(ctx context.Context, d *entity.Due) (int, error)
+}
+
+type Worker struct {
+	log       *slog.Logger
+	queue     storage.Queue
+	sender    Sender
+	interval  time.Duration
+	lease     time.Duration
+	retention time.Duration
+	wake      chan struct{}
+	now       func() time.Time
+}
+
+// New returns a worker that polls every interval and forgets finished
+// deliveries after retention. The lease must outlast a whole batch of
+// requests, or deliveries still being sent are claimed again.
+func New(log *slog.Logger, queue storage.Queue, sender Sender, interval, lease, retention time.Duration) *Worker {
+	return &Worker{
+		log:       log,
+		queue:     queue,
+		sender:    sender,
+		interval:  interval,
+		lease:     lease,
+		retention: retention,
+		wake:      make(chan struct{}, 1),
+		now:       time.Now,
+	}
+}
+
+// Wake makes the worker look for due deliveries now rather than at its next
+// poll. It never blocks.
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends due deliveries straight away, then whenever it is woken and every
+// interval until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.fire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.prune()
+		case <-w.wake:
+		}
+	}
+}
+
+// fire sends every delivery that is due, a batch at a time.
+func (w *Worker) fire(ctx context.Context) {
+	const op = "webhooks.worker.fire"
+	log := w.log.With(slog.String("op", op))
+
+	for ctx.Err() == nil {
+		due, err := w.queue.ClaimDue(w.now(), w.lease, batchSize)
+		if err != nil {
+			log.Error("failed to claim due deliveries", slog.Any("err", err))
+			return
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i := range due {
+			if ctx.Err() != nil {
+				// Unsent deliveries are picked up again when their lease ends.
+				break
+			}
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(d *entity.Due) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				w.deliver(ctx, log, d)
+			}(&due[i])
+		}
+		wg.Wait()
+
+		if len(due) < batchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, log *slog.Logger, d *entity.Due) {
+	log = log.With(slog.Int("delivery_id", d.ID), slog.Int("webhook_id", d.WebhookID), slog.Int("attempt", d.Attempts))
+
+	start := w.now()
+	code, err := w.sender.Send(ctx, d)
+	if ctx.Err() != nil {
+		// Shutting down; the attempt says nothing about the endpoint.
+		return
+	}
+	a := &entity.Attempt{ResponseCode: code, Duration: w.now().Sub(start), CreatedAt: w.now()}
+
+	var retryAt *time.Time
+	if err != nil {
+		a.Error = err.Error()
+		if d.Attempts < MaxAttempts {
+			t := w.now().Add(backoff(d.Attempts))
+			retryAt = &t
+		}
+		log.Error("webhook delivery failed", slog.Any("err", err), slog.Int("status", code), slog.Bool("giving_up", retryAt == nil))
+	}
+
+	if err := w.queue.RecordAttempt(d, a, retryAt); err != nil {
+		if errors.Is(err, storage.ErrLeaseLost) {
+			log.Info("delivery changed while it was being sent")
+			return
+		}
+		log.Error("failed to record delivery attempt", slog.Any("err", err))
+		return
+	}
+
+	if a.Error == "" {
+		log.Info("webhook delivered", slog.Int("status", code))
+	}
+}
+
+func (w *Worker) prune() {
+	const op = "webhooks.worker.prune"
+
+	n, err := w.queue.Prune(w.now().Add(-w.retention))
+	if err != nil {
+		w.log.Error("failed to prune deliveries", slog.String("op", op), slog.Any("err", err))
+		return
+	}
+	if n > 0 {
+		w.log.Info("deliveries pruned", slog.String("op", op), slog.Int("count", n))
+	}
+}
+
+// backoff doubles the wait after every failed attempt, from 30 seconds up to
+// an hour.
+func backoff(attempts int) time.Duration {
+	d := minBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
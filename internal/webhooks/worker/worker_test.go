@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"gonotes/internal/events"
+	"gonotes/internal/lib/logger/slogdiscard"
+	"gonotes/internal/webhooks/entity"
+	"gonotes/internal/webhooks/storage"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recorded struct {
+	attempt entity.Attempt
+	retryAt *time.Time
+}
+
+// mockQueue hands out its due deliveries in batches and records attempts.
+type mockQueue struct {
+	mu        sync.Mutex
+	due       []entity.Due
+	claims    int
+	attempts  map[int]recorded
+	recordErr error
+	prunedAt  time.Time
+}
+
+func newMockQueue(due ...entity.Due) *mockQueue {
+	return &mockQueue{due: due, attempts: map[int]recorded{}}
+}
+
+func (m *mockQueue) Enqueue(events.Type, int, []byte, time.Time) (int, error) {
+	return 0, nil
+}
+
+func (m *mockQueue) ClaimDue(now time.Time, lease time.Duration, limit int) ([]entity.Due, error) {
+	m.claims++
+	n := min(limit, len(m.due))
+	batch := m.due[:n]
+	m.due = m.due[n:]
+	return batch, nil
+}
+
+func (m *mockQueue) RecordAttempt(d *entity.Due, a *entity.Attempt, retryAt *time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.recordErr != nil {
+		return m.recordErr
+	}
+	m.attempts[d.ID] = recorded{attempt: *a, retryAt: retryAt}
+	return nil
+}
+
+func (m *mockQueue) Prune(before time.Time) (int, error) {
+	m.prunedAt = before
+	return 0, nil
+}
+
+type mockSender struct {
+	codes map[int]int
+	errs  map[int]error
+}
+
+func (m *mockSender) Send(ctx context.Context, d *entity.Due) (int, error) {
+	code, ok := m.codes[d.ID]
+	if !ok {
+		code = 200
+	}
+	return code, m.errs[d.ID]
+}
+
+func due(id, attempts int) entity.Due {
+	return entity.Due{Delivery: entity.Delivery{ID: id, Attempts: attempts}}
+}
+
+func newWorker(q *mockQueue, s *mockSender, now time.Time) *Worker {
+	w := New(slogdiscard.NewDiscardLogger(), q, s, time.Minute, 5*time.Minute, 24*time.Hour)
+	w.now = func() time.Time { return now }
+	return w
+}
+
+func Test_fireDelivers(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	q := newMockQueue(due(1, 1), due(2, 3), due(3, MaxAttempts))
+	s := &mockSender{
+		codes: map[int]int{2: 503, 3: 0},
+		errs: map[int]error{
+			2: errors.New("unexpected status 503 Service Unavailable"),
+			3: errors.New("connection refused"),
+		},
+	}
+
+	newWorker(q, s, now).fire(context.Background())
+
+	require.Len(t, q.attempts, 3)
+	assert.Equal(t, 200, q.attempts[1].attempt.ResponseCode)
+	assert.Empty(t, q.attempts[1].attempt.Error)
+	assert.Equal(t, now, q.attempts[1].attempt.CreatedAt)
+
+	assert.Equal(t, 503, q.attempts[2].attempt.ResponseCode)
+	assert.Equal(t, "unexpected status 503 Service Unavailable", q.attempts[2].attempt.Error)
+	require.NotNil(t, q.attempts[2].retryAt)
+	assert.Equal(t, now.Add(2*time.Minute), *q.attempts[2].retryAt)
+
+	assert.Equal(t, "connection refused", q.attempts[3].attempt.Error)
+	assert.Nil(t, q.attempts[3].retryAt, "gives up after MaxAttempts")
+}
+
+func Test_fireDrainsFullBatches(t *testing.T) {
+	var all []entity.Due
+	for i := range batchSize + 1 {
+		all = append(all, due(i+1, 1))
+	}
+	q := newMockQueue(all...)
+
+	newWorker(q, &mockSender{}, time.Now()).fire(context.Background())
+
+	assert.Len(t, q.attempts, batchSize+1)
+	assert.Equal(t, 2, q.claims)
+}
+
+func Test_fireToleratesLostLease(t *testing.T) {
+	q := newMockQueue(due(1, 1))
+	q.recordErr = storage.ErrLeaseLost
+
+	newWorker(q, &mockSender{}, time.Now()).fire(context.Background())
+
+	assert.Empty(t, q.attempts)
+}
+
+func Test_Wake(t *testing.T) {
+	w := newWorker(newMockQueue(), &mockSender{}, time.Now())
+	w.Wake()
+	w.Wake()
+	assert.Len(t, w.wake, 1, "wakes are coalesced and never block")
+}
+
+func Test_prune(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	q := newMockQueue()
+
+	newWorker(q, &mockSender{}, now).prune()
+
+	assert.Equal(t, now.Add(-24*time.Hour), q.prunedAt)
+}
+
+func Test_backoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, backoff(1))
+	assert.Equal(t, time.Minute, backoff(2))
+	assert.Equal(t, 32*time.Minute, backoff(7))
+	assert.Equal(t, time.Hour, backoff(8))
+	assert.Equal(t, time.Hour, backoff(20))
+}
@@ -0,0 +1,2 @@
+ALTER TABLE notes ADD COLUMN updated_at DATETIME;
+UPDATE notes SET updated_at = created_at;
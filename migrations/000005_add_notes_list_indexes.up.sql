@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_notes_user_created ON notes(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_notes_user_updated ON notes(user_id, updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_notes_user_title ON notes(user_id, title, id);
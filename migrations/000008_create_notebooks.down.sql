@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notes_notebook;
+ALTER TABLE notes DROP COLUMN notebook_id;
+DROP INDEX IF EXISTS idx_notebooks_user_parent;
+DROP TABLE IF EXISTS notebooks;
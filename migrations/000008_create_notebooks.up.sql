@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS notebooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    parent_id INTEGER,
+    name TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(user_id) REFERENCES users(id),
+    FOREIGN KEY(parent_id) REFERENCES notebooks(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notebooks_user_parent ON notebooks(user_id, parent_id);
+
+ALTER TABLE notes ADD COLUMN notebook_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_notes_notebook ON notes(notebook_id);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS note_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    author_id INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY(author_id) REFERENCES users(id),
+    UNIQUE(note_id, revision)
+);
+
+INSERT INTO note_revisions(note_id, revision, author_id, title, content, created_at)
+SELECT id, 1, user_id, title, content, updated_at FROM notes;
@@ -0,0 +1,3 @@
+ALTER TABLE notes DROP COLUMN favourite;
+ALTER TABLE notes DROP COLUMN archived;
+ALTER TABLE notes DROP COLUMN pinned;
@@ -0,0 +1,3 @@
+ALTER TABLE notes ADD COLUMN pinned BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE notes ADD COLUMN archived BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE notes ADD COLUMN favourite BOOLEAN NOT NULL DEFAULT 0;
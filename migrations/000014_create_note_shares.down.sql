@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_note_shares_user;
+DROP TABLE IF EXISTS note_shares;
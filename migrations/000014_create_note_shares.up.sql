@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS note_shares (
+    note_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    permission TEXT NOT NULL CHECK (permission IN ('read', 'edit')),
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY(note_id, user_id),
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_shares_user ON note_shares(user_id);
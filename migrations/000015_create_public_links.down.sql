@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_public_links_note;
+DROP TABLE IF EXISTS public_links;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS public_links (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    note_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    nonce TEXT NOT NULL,
+    password_hash TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    views INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE,
+    FOREIGN KEY(user_id) REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_public_links_note ON public_links(note_id);
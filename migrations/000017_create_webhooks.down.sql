@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_webhook_attempts_delivery;
+DROP TABLE IF EXISTS webhook_attempts;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhooks_user;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_note_events_created;
+DROP INDEX IF EXISTS idx_note_events_user;
+DROP TABLE IF EXISTS note_events;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS note_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    note_id INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_events_user ON note_events(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_note_events_created ON note_events(created_at);
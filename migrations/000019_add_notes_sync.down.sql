@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS note_shares_sync_ad;
+DROP TRIGGER IF EXISTS note_shares_sync_ai;
+DROP TRIGGER IF EXISTS tags_sync_au;
+DROP TRIGGER IF EXISTS note_tags_sync_ad;
+DROP TRIGGER IF EXISTS note_tags_sync_ai;
+DROP TRIGGER IF EXISTS notes_sync_ad;
+DROP TRIGGER IF EXISTS notes_sync_au;
+DROP TRIGGER IF EXISTS notes_sync_ai;
+DROP INDEX IF EXISTS idx_note_tombstones_user;
+DROP TABLE IF EXISTS note_tombstones;
+DROP INDEX IF EXISTS idx_notes_seq;
+ALTER TABLE notes DROP COLUMN seq;
+DROP TABLE IF EXISTS sync_seq;
@@ -0,0 +1,68 @@
+-- sync_seq holds the last change sequence handed out. Every change a user can
+-- see, to a note, its tags or who it is shared with, takes the next value, so
+-- a client asks for everything after the last value it saw.
+CREATE TABLE IF NOT EXISTS sync_seq (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    value INTEGER NOT NULL
+);
+
+INSERT INTO sync_seq(id, value) SELECT 1, COALESCE(MAX(id), 0) FROM notes;
+
+ALTER TABLE notes ADD COLUMN seq INTEGER NOT NULL DEFAULT 0;
+UPDATE notes SET seq = id;
+
+CREATE INDEX IF NOT EXISTS idx_notes_seq ON notes(seq);
+
+-- note_tombstones records that a user can no longer see a note, because it
+-- was purged or stopped being shared with them.
+CREATE TABLE IF NOT EXISTS note_tombstones (
+    seq INTEGER PRIMARY KEY,
+    note_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_tombstones_user ON note_tombstones(user_id, seq);
+
+CREATE TRIGGER IF NOT EXISTS notes_sync_ai AFTER INSERT ON notes BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) WHERE id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_sync_au AFTER UPDATE OF notebook_id, title, content, format, version, pinned, archived, favourite, deleted_at ON notes BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) WHERE id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS notes_sync_ad AFTER DELETE ON notes BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    INSERT INTO note_tombstones(seq, note_id, user_id, created_at) VALUES ((SELECT value FROM sync_seq), old.id, old.user_id, CURRENT_TIMESTAMP);
+END;
+
+CREATE TRIGGER IF NOT EXISTS note_tags_sync_ai AFTER INSERT ON note_tags BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) WHERE id = new.note_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS note_tags_sync_ad AFTER DELETE ON note_tags BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) WHERE id = old.note_id;
+END;
+
+-- A renamed tag changes every note carrying it. Each note gets its own
+-- sequence so a page of changes never ends between notes sharing one.
+CREATE TRIGGER IF NOT EXISTS tags_sync_au AFTER UPDATE OF name ON tags BEGIN
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) + (SELECT COUNT(*) FROM note_tags nt WHERE nt.tag_id = new.id AND nt.note_id <= notes.id)
+    WHERE id IN (SELECT note_id FROM note_tags WHERE tag_id = new.id);
+    UPDATE sync_seq SET value = value + (SELECT COUNT(*) FROM note_tags WHERE tag_id = new.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS note_shares_sync_ai AFTER INSERT ON note_shares BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    UPDATE notes SET seq = (SELECT value FROM sync_seq) WHERE id = new.note_id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS note_shares_sync_ad AFTER DELETE ON note_shares BEGIN
+    UPDATE sync_seq SET value = value + 1;
+    INSERT INTO note_tombstones(seq, note_id, user_id, created_at) VALUES ((SELECT value FROM sync_seq), old.note_id, old.user_id, CURRENT_TIMESTAMP);
+END;
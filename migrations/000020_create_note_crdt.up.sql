@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS note_crdt (
+    note_id INTEGER PRIMARY KEY,
+    state TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_notes_user_title_nocase;
+DROP INDEX IF EXISTS idx_note_links_title;
+DROP TABLE IF EXISTS note_links;
+ALTER TABLE notes DROP COLUMN links_indexed;
@@ -0,0 +1,12 @@
+ALTER TABLE notes ADD COLUMN links_indexed INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS note_links (
+    note_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    title TEXT NOT NULL COLLATE NOCASE,
+    PRIMARY KEY (note_id, title),
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_links_title ON note_links(title);
+CREATE INDEX IF NOT EXISTS idx_notes_user_title_nocase ON notes(user_id, title COLLATE NOCASE);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_templates_user;
+DROP TABLE IF EXISTS templates;